@@ -3,7 +3,10 @@ package main
 import (
 	"backend/config"
 	_ "backend/docs" // Swagger documentation
+	"backend/internal/auth"
+	"backend/internal/jobs"
 	"backend/internal/routes"
+	"log"
 	"os"
 
 	"github.com/gin-gonic/gin"
@@ -30,13 +33,30 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API key issued to a partner by an admin.
+
 func main() {
 	config.LoadEnv()
+	keys, err := auth.LoadKeySet()
+	if err != nil {
+		log.Fatalf("Failed to load token signing keys: %v", err)
+	}
+	auth.SetKeySet(keys)
+	tokenSettings := auth.LoadTokenSettings()
+	auth.SetTokenSettings(&tokenSettings)
+
 	db := config.ConnectDB()
 
 	config.SeedDatabase(db)
 
 	router := gin.Default()
+	// Only the proxies in TRUSTED_PROXIES may set the client IP, which login throttling counts by, with X-Forwarded-For
+	if err := router.SetTrustedProxies(config.GetEnvList("TRUSTED_PROXIES", nil)); err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
 
 	// Swagger documentation route
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
@@ -45,6 +65,20 @@ func main() {
 	routes.SetupUserRoutes(router, db)
 	routes.SetupBookingRoutes(router, db)
 	routes.SetupAuthRoutes(router, db)
+	routes.SetupWaitlistRoutes(router, db)
+	routes.SetupStayRestrictionRoutes(router, db)
+	routes.SetupAddOnRoutes(router, db)
+	routes.SetupReviewRoutes(router, db)
+	routes.SetupPhotoRoutes(router, db)
+	routes.SetupSearchRoutes(router, db)
+	routes.SetupOrganizationRoutes(router, db)
+	routes.SetupRoleRoutes(router, db)
+	routes.SetupTeamRoutes(router, db)
+	routes.SetupAPIKeyRoutes(router, db)
+
+	jobs.StartWaitlistJobs(db)
+	jobs.StartBookingJobs(db)
+	jobs.StartSearchJobs(db)
 
 	router.Run(":" + os.Getenv("SERVER_PORT"))
 }
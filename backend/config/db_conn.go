@@ -25,10 +25,35 @@ func ConnectDB() *gorm.DB {
 
 	db.AutoMigrate(
 		&domain.User{},
+		&domain.Organization{},
 		&domain.Hotel{},
 		&domain.Room{},
 		&domain.Facility{},
 		&domain.Booking{},
+		&domain.WaitlistEntry{},
+		&domain.StayRestriction{},
+		&domain.AddOn{},
+		&domain.BookingAddOn{},
+		&domain.Payment{},
+		&domain.AuditEntry{},
+		&domain.Review{},
+		&domain.ReviewReport{},
+		&domain.Photo{},
+		&domain.HotelSearchDocument{},
+		&domain.SearchTerm{},
+		&domain.HotelOrganizationChange{},
+		&domain.RoleAssignment{},
+		&domain.StaffInvitation{},
+		&domain.RefreshToken{},
+		&domain.PasswordResetToken{},
+		&domain.EmailVerificationToken{},
+		&domain.MFAEnrollment{},
+		&domain.MFARecoveryCode{},
+		&domain.MFAChallenge{},
+		&domain.LoginThrottle{},
+		&domain.ExternalIdentity{},
+		&domain.ExternalLoginState{},
+		&domain.APIKey{},
 	)
 	log.Println("Database connected successfully")
 	return db
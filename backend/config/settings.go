@@ -0,0 +1,78 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// GetEnv reads a variable from the environment, falling back to the given default when it is unset or empty
+func GetEnv(key string, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// GetEnvDuration reads a duration such as "15m" or "48h" from the environment,
+// falling back to the given default when the variable is unset or invalid.
+func GetEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Warning: invalid duration %q for %s, using %s", value, key, fallback)
+		return fallback
+	}
+	return duration
+}
+
+// GetEnvList reads a comma-separated list from the environment, trimming blanks,
+// falling back to the given default when the variable is unset.
+func GetEnvList(key string, fallback []string) []string {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return fallback
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetEnvBool reads a boolean such as "true" or "0" from the environment,
+// falling back to the given default when the variable is unset or invalid.
+func GetEnvBool(key string, fallback bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("Warning: invalid boolean %q for %s, using %t", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+// GetEnvInt reads an integer from the environment,
+// falling back to the given default when the variable is unset or invalid.
+func GetEnvInt(key string, fallback int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Warning: invalid integer %q for %s, using %d", value, key, fallback)
+		return fallback
+	}
+	return parsed
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// apiKeyPrefix marks our API keys, so a leaked one is easy to recognise
+	apiKeyPrefix = "hbk_"
+	// apiKeyPrefixLength is how much of a key is stored in the clear to tell keys apart
+	apiKeyPrefixLength = len(apiKeyPrefix) + 6
+)
+
+var (
+	// ErrInvalidAPIKey is returned for keys that are unknown, revoked or expired
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyScope is returned for keys that exist but may not be used for the request
+	ErrAPIKeyScope       = errors.New("API key is not allowed to do this")
+	ErrAPIKeyRateLimited = errors.New("API key rate limit exceeded")
+)
+
+// APIKeyRateLimitedError is returned while a key has used up its requests for the current minute
+type APIKeyRateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *APIKeyRateLimitedError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", ErrAPIKeyRateLimited, e.RetryAfter.Round(time.Second))
+}
+
+func (e *APIKeyRateLimitedError) Is(target error) bool {
+	return target == ErrAPIKeyRateLimited
+}
+
+// GenerateAPIKey returns a new random API key and the prefix that identifies it
+func GenerateAPIKey() (key string, prefix string, err error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	key = apiKeyPrefix + hex.EncodeToString(buf)
+	return key, key[:apiKeyPrefixLength], nil
+}
+
+// HashAPIKey is what the database keeps of an API key. Keys are random enough that a plain hash is safe.
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
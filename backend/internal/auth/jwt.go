@@ -1,41 +1,104 @@
 package auth
 
 import (
+	"backend/config"
 	"backend/internal/domain"
 	"errors"
-	"os"
+	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/google/uuid"
 )
 
-func GenerateToken(user *domain.User) (domain.LoginResponse, error) {
+const (
+	// AccessTokenTTL is how long an access token is accepted
+	AccessTokenTTL = 15 * time.Minute
+	// RefreshTokenTTL is how long a refresh token can be exchanged for new tokens
+	RefreshTokenTTL = 24 * time.Hour
 
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		return domain.LoginResponse{}, errors.New("JWT_SECRET is not set")
+	defaultIssuer = "hotel-booking-api"
+	defaultLeeway = 30 * time.Second
+)
+
+// ErrInvalidToken is returned, wrapped with the reason, for every token that fails validation
+var ErrInvalidToken = errors.New("invalid token")
+
+type TokenType string
+
+const (
+	TokenTypeAccess  TokenType = "access"
+	TokenTypeRefresh TokenType = "refresh"
+)
+
+// Claims are the claims of our access and refresh tokens
+type Claims struct {
+	IsAdmin bool      `json:"isAdmin"`
+	Type    TokenType `json:"type"`
+	jwt.RegisteredClaims
+}
+
+// TokenSettings says who issues tokens, who they are for and how much clock skew to tolerate
+type TokenSettings struct {
+	Issuer string
+	// Audiences are written into every token; a token is accepted when it names at least one of them
+	Audiences []string
+	Leeway    time.Duration
+}
+
+// LoadTokenSettings reads JWT_ISSUER, JWT_AUDIENCES and JWT_LEEWAY from the environment
+func LoadTokenSettings() TokenSettings {
+	issuer := config.GetEnv("JWT_ISSUER", defaultIssuer)
+	audiences := config.GetEnvList("JWT_AUDIENCES", nil)
+	if len(audiences) == 0 {
+		audiences = []string{issuer}
+	}
+	return TokenSettings{
+		Issuer:    issuer,
+		Audiences: audiences,
+		Leeway:    config.GetEnvDuration("JWT_LEEWAY", defaultLeeway),
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"isAdmin": user.IsAdmin,
-		"sub":     user.Id.String(),
-		"exp":     time.Now().Add(time.Minute * 15).Unix(),
-		"type":    "access",
-	})
+}
 
-	t, err := token.SignedString([]byte(secretKey))
+var (
+	settingsMutex sync.RWMutex
+	settings      *TokenSettings
+)
+
+// SetTokenSettings installs the settings tokens are issued and validated with
+func SetTokenSettings(tokenSettings *TokenSettings) {
+	settingsMutex.Lock()
+	defer settingsMutex.Unlock()
+	settings = tokenSettings
+}
+
+// Settings returns the installed token settings, or reads them from the environment when none were installed
+func Settings() TokenSettings {
+	settingsMutex.RLock()
+	defer settingsMutex.RUnlock()
+	if settings != nil {
+		return *settings
+	}
+	return LoadTokenSettings()
+}
+
+// GenerateToken signs an access token and a refresh token whose jti is refreshTokenId,
+// the id the refresh token is stored under so it can be revoked
+func GenerateToken(user *domain.User, refreshTokenId uuid.UUID) (domain.LoginResponse, error) {
+	keys, err := Keys()
 	if err != nil {
 		return domain.LoginResponse{}, err
 	}
+	tokenSettings := Settings()
+	now := time.Now()
 
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"isAdmin": user.IsAdmin,
-		"sub":     user.Id.String(),
-		"exp":     time.Now().Add(time.Hour * 24).Unix(),
-		"type":    "refresh",
-	})
+	t, err := keys.Sign(newClaims(user, TokenTypeAccess, uuid.New(), now, AccessTokenTTL, tokenSettings))
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
 
-	rt, err := refreshToken.SignedString([]byte(secretKey))
+	rt, err := keys.Sign(newClaims(user, TokenTypeRefresh, refreshTokenId, now, RefreshTokenTTL, tokenSettings))
 	if err != nil {
 		return domain.LoginResponse{}, err
 	}
@@ -46,75 +109,95 @@ func GenerateToken(user *domain.User) (domain.LoginResponse, error) {
 	}, nil
 }
 
-func ValidateToken(token string) (domain.User, error) {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		return domain.User{}, errors.New("JWT_SECRET is not set")
+func newClaims(user *domain.User, tokenType TokenType, id uuid.UUID, now time.Time, ttl time.Duration, tokenSettings TokenSettings) *Claims {
+	return &Claims{
+		IsAdmin: user.IsAdmin,
+		Type:    tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    tokenSettings.Issuer,
+			Subject:   user.Id.String(),
+			Audience:  tokenSettings.Audiences,
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        id.String(),
+		},
 	}
+}
 
-	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-
+// ValidateToken validates an access token and returns the user it was issued to
+func ValidateToken(token string) (domain.User, error) {
+	claims, userId, err := parseToken(token, TokenTypeAccess)
 	if err != nil {
 		return domain.User{}, err
 	}
+	return domain.User{Id: userId, IsAdmin: claims.IsAdmin}, nil
+}
 
-	tokenType, ok := claims["type"].(string)
-
-	if !ok {
-		return domain.User{}, errors.New("invalid token")
-	}
-
-	if tokenType != "access" {
-		return domain.User{}, errors.New("invalid token type: access token required")
+// ValidateRefreshToken validates a refresh token and returns user information and the token's id
+func ValidateRefreshToken(token string) (domain.User, uuid.UUID, error) {
+	claims, userId, err := parseToken(token, TokenTypeRefresh)
+	if err != nil {
+		return domain.User{}, uuid.Nil, err
 	}
-
-	userId, ok := claims["sub"].(string)
-	isAdmin, ok := claims["isAdmin"].(bool)
-	if !ok {
-		return domain.User{}, errors.New("invalid token: user id required")
+	tokenId, err := uuid.Parse(claims.ID)
+	if err != nil {
+		return domain.User{}, uuid.Nil, fmt.Errorf("%w: token id required", ErrInvalidToken)
 	}
-
-	return domain.User{Id: uuid.MustParse(userId), IsAdmin: isAdmin}, nil
+	return domain.User{Id: userId, IsAdmin: claims.IsAdmin}, tokenId, nil
 }
 
-// ValidateRefreshToken validates a refresh token and returns user information
-func ValidateRefreshToken(token string) (domain.User, error) {
-	secretKey := os.Getenv("JWT_SECRET_KEY")
-	if secretKey == "" {
-		return domain.User{}, errors.New("JWT_SECRET is not set")
-	}
-
-	claims := jwt.MapClaims{}
-	_, err := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secretKey), nil
-	})
-
+/*
+parseToken
+Params: signed token, the type of token expected
+Returns: the token's claims, the subject's user id, error
+Description: Check the signature with the key named by kid, allowing only the algorithms of our keys,
+then check the claims: issuer, audience, subject and type must be ours, and exp, nbf and iat must be
+present and hold within the configured clock-skew leeway.
+*/
+func parseToken(token string, expected TokenType) (*Claims, uuid.UUID, error) {
+	keys, err := Keys()
 	if err != nil {
-		return domain.User{}, err
+		return nil, uuid.Nil, err
 	}
+	tokenSettings := Settings()
 
-	tokenType, ok := claims["type"].(string)
-
-	if !ok {
-		return domain.User{}, errors.New("invalid token")
+	claims := &Claims{}
+	parser := jwt.NewParser(jwt.WithValidMethods(keys.Algorithms()), jwt.WithoutClaimsValidation())
+	if _, err := parser.ParseWithClaims(token, claims, keys.keyFunc); err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
-
-	if tokenType != "refresh" {
-		return domain.User{}, errors.New("invalid token type: refresh token required")
+	if err := claims.validate(time.Now(), tokenSettings); err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
 	}
-
-	userId, ok := claims["sub"].(string)
-	if !ok {
-		return domain.User{}, errors.New("invalid token: user id required")
+	if claims.Type != expected {
+		return nil, uuid.Nil, fmt.Errorf("%w: %s token required", ErrInvalidToken, expected)
 	}
-
-	isAdmin, ok := claims["isAdmin"].(bool)
-	if !ok {
-		return domain.User{}, errors.New("invalid token: admin status required")
+	userId, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return nil, uuid.Nil, fmt.Errorf("%w: user id required", ErrInvalidToken)
 	}
+	return claims, userId, nil
+}
 
-	return domain.User{Id: uuid.MustParse(userId), IsAdmin: isAdmin}, nil
+func (c *Claims) validate(now time.Time, tokenSettings TokenSettings) error {
+	leeway := tokenSettings.Leeway
+	switch {
+	case c.ExpiresAt == nil || c.NotBefore == nil || c.IssuedAt == nil:
+		return errors.New("exp, nbf and iat are required")
+	case !c.VerifyExpiresAt(now.Add(-leeway), true):
+		return errors.New("token has expired")
+	case !c.VerifyNotBefore(now.Add(leeway), true):
+		return errors.New("token is not valid yet")
+	case !c.VerifyIssuedAt(now.Add(leeway), true):
+		return errors.New("token was issued in the future")
+	case !c.VerifyIssuer(tokenSettings.Issuer, true):
+		return errors.New("unexpected issuer")
+	}
+	for _, audience := range tokenSettings.Audiences {
+		if c.VerifyAudience(audience, true) {
+			return nil
+		}
+	}
+	return errors.New("token is not meant for this audience")
 }
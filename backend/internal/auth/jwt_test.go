@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"backend/internal/domain"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type jwtTestKeys struct {
+	rsa      *rsa.PrivateKey
+	ed25519  ed25519.PrivateKey
+	settings TokenSettings
+}
+
+// setupJWTTest installs an RSA signing key, an Ed25519 verification key and known token settings
+func setupJWTTest(t *testing.T) *jwtTestKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	keys, err := NewKeySet("rsa",
+		&SigningKey{Id: "rsa", Algorithm: AlgorithmRS256, signingKey: rsaKey, verifyKey: &rsaKey.PublicKey},
+		&SigningKey{Id: "ed", Algorithm: AlgorithmEdDSA, signingKey: edKey, verifyKey: edKey.Public()},
+	)
+	if err != nil {
+		t.Fatalf("Failed to build key set: %v", err)
+	}
+	settings := TokenSettings{Issuer: "hotel-booking-api", Audiences: []string{"hotel-booking-api", "reporting"}, Leeway: 30 * time.Second}
+
+	useKeySet(t, keys)
+	SetTokenSettings(&settings)
+	t.Cleanup(func() { SetTokenSettings(nil) })
+	return &jwtTestKeys{rsa: rsaKey, ed25519: edKey, settings: settings}
+}
+
+func validClaims(tokenType TokenType) *Claims {
+	now := time.Now()
+	return &Claims{
+		Type: tokenType,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    "hotel-booking-api",
+			Subject:   uuid.New().String(),
+			Audience:  jwt.ClaimStrings{"hotel-booking-api"},
+			ExpiresAt: jwt.NewNumericDate(now.Add(time.Minute)),
+			NotBefore: jwt.NewNumericDate(now),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ID:        uuid.New().String(),
+		},
+	}
+}
+
+func sign(t *testing.T, method jwt.SigningMethod, kid string, claims jwt.Claims, key interface{}) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestGenerateToken_IssuesRegisteredClaims(t *testing.T) {
+	setupJWTTest(t)
+	user := &domain.User{Id: uuid.New(), IsAdmin: true}
+	refreshTokenId := uuid.New()
+
+	tokens, err := GenerateToken(user, refreshTokenId)
+	assert.NoError(t, err)
+
+	claims := &Claims{}
+	_, _, err = jwt.NewParser().ParseUnverified(tokens.AccessToken, claims)
+	assert.NoError(t, err)
+	assert.Equal(t, "hotel-booking-api", claims.Issuer)
+	assert.Equal(t, jwt.ClaimStrings{"hotel-booking-api", "reporting"}, claims.Audience)
+	assert.Equal(t, user.Id.String(), claims.Subject)
+	assert.NotNil(t, claims.NotBefore)
+	assert.NotNil(t, claims.IssuedAt)
+	assert.NotEmpty(t, claims.ID)
+
+	verified, err := ValidateToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.User{Id: user.Id, IsAdmin: true}, verified)
+
+	_, tokenId, err := ValidateRefreshToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.Equal(t, refreshTokenId, tokenId)
+}
+
+func TestValidateToken_RejectsMaliciousTokens(t *testing.T) {
+	keys := setupJWTTest(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	modify := func(change func(claims *Claims)) *Claims {
+		claims := validClaims(TokenTypeAccess)
+		change(claims)
+		return claims
+	}
+	valid := sign(t, jwt.SigningMethodRS256, "rsa", validClaims(TokenTypeAccess), keys.rsa)
+	parts := strings.Split(valid, ".")
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{
+			name:  "alg none",
+			token: sign(t, jwt.SigningMethodNone, "rsa", validClaims(TokenTypeAccess), jwt.UnsafeAllowNoneSignatureType),
+		},
+		{
+			name:  "HS256 keyed with the RSA public modulus",
+			token: sign(t, jwt.SigningMethodHS256, "rsa", validClaims(TokenTypeAccess), keys.rsa.PublicKey.N.Bytes()),
+		},
+		{
+			name:  "RS384 is not an allowed algorithm",
+			token: sign(t, jwt.SigningMethodRS384, "rsa", validClaims(TokenTypeAccess), keys.rsa),
+		},
+		{
+			name:  "EdDSA token naming the RSA key",
+			token: sign(t, jwt.SigningMethodEdDSA, "rsa", validClaims(TokenTypeAccess), keys.ed25519),
+		},
+		{
+			name:  "signed by a key we do not hold",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", validClaims(TokenTypeAccess), otherKey),
+		},
+		{
+			name:  "unknown kid",
+			token: sign(t, jwt.SigningMethodRS256, "retired", validClaims(TokenTypeAccess), keys.rsa),
+		},
+		{
+			name:  "missing kid",
+			token: sign(t, jwt.SigningMethodRS256, "", validClaims(TokenTypeAccess), keys.rsa),
+		},
+		{
+			name:  "payload swapped under a valid signature",
+			token: parts[0] + "." + base64.RawURLEncoding.EncodeToString([]byte(`{"isAdmin":true,"type":"access"}`)) + "." + parts[2],
+		},
+		{
+			name:  "expired beyond the leeway",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-time.Minute)) }), keys.rsa),
+		},
+		{
+			name:  "not valid until after the leeway",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.NotBefore = jwt.NewNumericDate(time.Now().Add(time.Minute)) }), keys.rsa),
+		},
+		{
+			name:  "issued in the future",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.IssuedAt = jwt.NewNumericDate(time.Now().Add(time.Hour)) }), keys.rsa),
+		},
+		{
+			name:  "missing exp",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.ExpiresAt = nil }), keys.rsa),
+		},
+		{
+			name:  "missing iat",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.IssuedAt = nil }), keys.rsa),
+		},
+		{
+			name:  "wrong issuer",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Issuer = "evil" }), keys.rsa),
+		},
+		{
+			name:  "missing issuer",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Issuer = "" }), keys.rsa),
+		},
+		{
+			name:  "wrong audience",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Audience = jwt.ClaimStrings{"another-service"} }), keys.rsa),
+		},
+		{
+			name:  "missing audience",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Audience = nil }), keys.rsa),
+		},
+		{
+			name:  "malformed subject",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Subject = "not-a-uuid" }), keys.rsa),
+		},
+		{
+			name:  "missing subject",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Subject = "" }), keys.rsa),
+		},
+		{
+			name:  "refresh token used as access token",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", validClaims(TokenTypeRefresh), keys.rsa),
+		},
+		{
+			name:  "missing type",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", modify(func(c *Claims) { c.Type = "" }), keys.rsa),
+		},
+		{
+			name: "isAdmin of the wrong type",
+			token: sign(t, jwt.SigningMethodRS256, "rsa", jwt.MapClaims{
+				"isAdmin": "true", "type": "access", "sub": uuid.New().String(), "iss": "hotel-booking-api",
+				"aud": "hotel-booking-api", "exp": time.Now().Add(time.Minute).Unix(), "nbf": time.Now().Unix(), "iat": time.Now().Unix(),
+			}, keys.rsa),
+		},
+		{
+			name:  "not a JWT",
+			token: "garbage",
+		},
+		{
+			name:  "empty",
+			token: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.NotPanics(t, func() {
+				_, err := ValidateToken(tt.token)
+				assert.ErrorIs(t, err, ErrInvalidToken)
+			})
+		})
+	}
+}
+
+func TestValidateToken_AllowsClockSkewWithinLeeway(t *testing.T) {
+	keys := setupJWTTest(t)
+
+	claims := validClaims(TokenTypeAccess)
+	claims.ExpiresAt = jwt.NewNumericDate(time.Now().Add(-10 * time.Second))
+	claims.IssuedAt = jwt.NewNumericDate(time.Now().Add(10 * time.Second))
+	claims.NotBefore = jwt.NewNumericDate(time.Now().Add(10 * time.Second))
+	claims.Audience = jwt.ClaimStrings{"reporting"}
+	_, err := ValidateToken(sign(t, jwt.SigningMethodRS256, "rsa", claims, keys.rsa))
+	assert.NoError(t, err)
+
+	// Tokens from the Ed25519 verification key are accepted with their own algorithm
+	_, err = ValidateToken(sign(t, jwt.SigningMethodEdDSA, "ed", validClaims(TokenTypeAccess), keys.ed25519))
+	assert.NoError(t, err)
+}
+
+func TestValidateRefreshToken_RequiresTokenId(t *testing.T) {
+	keys := setupJWTTest(t)
+
+	claims := validClaims(TokenTypeRefresh)
+	claims.ID = "not-a-uuid"
+	_, _, err := ValidateRefreshToken(sign(t, jwt.SigningMethodRS256, "rsa", claims, keys.rsa))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	_, _, err = ValidateRefreshToken(sign(t, jwt.SigningMethodRS256, "rsa", validClaims(TokenTypeAccess), keys.rsa))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
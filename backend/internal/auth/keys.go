@@ -0,0 +1,253 @@
+package auth
+
+import (
+	"backend/internal/domain"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algorithms tokens can be signed with
+const (
+	AlgorithmRS256 = "RS256"
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmHS256 = "HS256"
+)
+
+// SigningKey is one key of the key set. Keys loaded from a public key file can only verify tokens.
+type SigningKey struct {
+	Id         string
+	Algorithm  string
+	signingKey interface{}
+	verifyKey  interface{}
+}
+
+// CanSign reports whether the private half of the key is available
+func (k *SigningKey) CanSign() bool {
+	return k.signingKey != nil
+}
+
+func (k *SigningKey) method() jwt.SigningMethod {
+	return jwt.GetSigningMethod(k.Algorithm)
+}
+
+// NewHMACKey wraps a shared secret as an HS256 key. HS256 keys are never published in the JWKS.
+func NewHMACKey(id string, secret []byte) *SigningKey {
+	return &SigningKey{Id: id, Algorithm: AlgorithmHS256, signingKey: secret, verifyKey: secret}
+}
+
+/*
+ParsePEMKey
+Params: key id, PEM file contents
+Returns: the key, error
+Description: Accept an RSA or Ed25519 private key (PKCS#1 or PKCS#8), which can sign and verify,
+or a public key (PKIX), which can only verify. The algorithm follows from the key type.
+*/
+func ParsePEMKey(id string, data []byte) (*SigningKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s: no PEM block found", id)
+	}
+
+	var parsed interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("key %s: unsupported PEM block %q", id, block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("key %s: %w", id, err)
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &SigningKey{Id: id, Algorithm: AlgorithmRS256, signingKey: key, verifyKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &SigningKey{Id: id, Algorithm: AlgorithmRS256, verifyKey: key}, nil
+	case ed25519.PrivateKey:
+		return &SigningKey{Id: id, Algorithm: AlgorithmEdDSA, signingKey: key, verifyKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &SigningKey{Id: id, Algorithm: AlgorithmEdDSA, verifyKey: key}, nil
+	default:
+		return nil, fmt.Errorf("key %s: unsupported key type %T", id, parsed)
+	}
+}
+
+// KeySet holds the key new tokens are signed with and every key tokens may still be verified with
+type KeySet struct {
+	active *SigningKey
+	keys   map[string]*SigningKey
+	order  []string
+}
+
+// NewKeySet builds a key set signing with the key named activeId. Key ids must be unique.
+func NewKeySet(activeId string, keys ...*SigningKey) (*KeySet, error) {
+	set := &KeySet{keys: map[string]*SigningKey{}}
+	for _, key := range keys {
+		if _, exists := set.keys[key.Id]; exists {
+			return nil, fmt.Errorf("duplicate key id %q", key.Id)
+		}
+		set.keys[key.Id] = key
+		set.order = append(set.order, key.Id)
+	}
+
+	active, ok := set.keys[activeId]
+	if !ok {
+		return nil, fmt.Errorf("active key %q is not in the key set", activeId)
+	}
+	if !active.CanSign() {
+		return nil, fmt.Errorf("active key %q has no private key", activeId)
+	}
+	set.active = active
+	return set, nil
+}
+
+// Sign signs the claims with the active key, naming it in the kid header
+func (s *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(s.active.method(), claims)
+	if s.active.Id != "" {
+		token.Header["kid"] = s.active.Id
+	}
+	return token.SignedString(s.active.signingKey)
+}
+
+// Algorithms lists the signing algorithms of the keys; tokens using any other algorithm are rejected
+func (s *KeySet) Algorithms() []string {
+	seen := map[string]bool{}
+	var algorithms []string
+	for _, id := range s.order {
+		if algorithm := s.keys[id].Algorithm; !seen[algorithm] {
+			seen[algorithm] = true
+			algorithms = append(algorithms, algorithm)
+		}
+	}
+	return algorithms
+}
+
+// keyFunc picks the verification key named by the token's kid. The token's algorithm must be the key's,
+// so a token cannot, for example, claim HS256 and be checked against an RSA public key used as a secret.
+func (s *KeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := s.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if token.Method.Alg() != key.Algorithm {
+		return nil, fmt.Errorf("unexpected signing algorithm %s", token.Method.Alg())
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS publishes the public halves of the asymmetric keys so other services can verify our tokens
+func (s *KeySet) JWKS() domain.JSONWebKeySet {
+	set := domain.JSONWebKeySet{Keys: []domain.JSONWebKey{}}
+	for _, id := range s.order {
+		key := s.keys[id]
+		switch public := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			set.Keys = append(set.Keys, domain.JSONWebKey{
+				KeyType:   "RSA",
+				KeyId:     key.Id,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				Modulus:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+				Exponent:  base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+			})
+		case ed25519.PublicKey:
+			set.Keys = append(set.Keys, domain.JSONWebKey{
+				KeyType:   "OKP",
+				KeyId:     key.Id,
+				Use:       "sig",
+				Algorithm: key.Algorithm,
+				Curve:     "Ed25519",
+				X:         base64.RawURLEncoding.EncodeToString(public),
+			})
+		}
+	}
+	return set
+}
+
+/*
+LoadKeySet
+Params: none, reads JWT_SIGNING_KEYS, JWT_ACTIVE_KEY_ID and JWT_SECRET_KEY
+Returns: the key set, error
+Description: JWT_SIGNING_KEYS lists kid=path pairs of PEM key files, and JWT_ACTIVE_KEY_ID names the one
+that signs (the first by default). To rotate, add the new key, make it active and keep the old one listed,
+as a private or public key, until the tokens it signed have expired. When JWT_SECRET_KEY is also set it
+stays a verification key for tokens signed before the move to asymmetric keys; with no key files it signs too.
+*/
+func LoadKeySet() (*KeySet, error) {
+	var keys []*SigningKey
+	for _, entry := range strings.Split(os.Getenv("JWT_SIGNING_KEYS"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		id, path, ok := strings.Cut(entry, "=")
+		if !ok || id == "" || path == "" {
+			return nil, fmt.Errorf("JWT_SIGNING_KEYS entry %q is not kid=path", entry)
+		}
+		data, err := os.ReadFile(strings.TrimSpace(path))
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", id, err)
+		}
+		key, err := ParsePEMKey(strings.TrimSpace(id), data)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	secret := os.Getenv("JWT_SECRET_KEY")
+	if secret != "" {
+		keys = append(keys, NewHMACKey("", []byte(secret)))
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("no signing keys configured: set JWT_SIGNING_KEYS or JWT_SECRET_KEY")
+	}
+
+	activeId := os.Getenv("JWT_ACTIVE_KEY_ID")
+	if activeId == "" {
+		activeId = keys[0].Id
+	}
+	return NewKeySet(activeId, keys...)
+}
+
+var (
+	keySetMutex sync.RWMutex
+	keySet      *KeySet
+)
+
+// SetKeySet installs the key set tokens are signed and verified with
+func SetKeySet(set *KeySet) {
+	keySetMutex.Lock()
+	defer keySetMutex.Unlock()
+	keySet = set
+}
+
+// Keys returns the installed key set, or loads one from the environment when none was installed
+func Keys() (*KeySet, error) {
+	keySetMutex.RLock()
+	set := keySet
+	keySetMutex.RUnlock()
+	if set != nil {
+		return set, nil
+	}
+	return LoadKeySet()
+}
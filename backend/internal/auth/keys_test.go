@@ -0,0 +1,170 @@
+package auth
+
+import (
+	"backend/internal/domain"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, dir string, name string, blockType string, der []byte) string {
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return path
+}
+
+func generateKeyFiles(t *testing.T) (rsaPath string, ed25519Path string, ed25519PublicPath string) {
+	dir := t.TempDir()
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	rsaPath = writePEM(t, dir, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 key: %v", err)
+	}
+	ed25519Path = writePEM(t, dir, "ed25519.pem", "PRIVATE KEY", der)
+	der, err = x509.MarshalPKIXPublicKey(public)
+	if err != nil {
+		t.Fatalf("Failed to marshal Ed25519 public key: %v", err)
+	}
+	ed25519PublicPath = writePEM(t, dir, "ed25519.pub.pem", "PUBLIC KEY", der)
+	return rsaPath, ed25519Path, ed25519PublicPath
+}
+
+func useKeySet(t *testing.T, set *KeySet) {
+	SetKeySet(set)
+	t.Cleanup(func() { SetKeySet(nil) })
+}
+
+func TestKeySet_SignsWithActiveKeyAndVerifiesOlderKeys(t *testing.T) {
+	rsaPath, ed25519Path, _ := generateKeyFiles(t)
+	user := &domain.User{Id: uuid.New(), IsAdmin: true}
+
+	t.Setenv("JWT_SECRET_KEY", "")
+	t.Setenv("JWT_SIGNING_KEYS", "old="+rsaPath)
+	oldKeys, err := LoadKeySet()
+	assert.NoError(t, err)
+	useKeySet(t, oldKeys)
+	oldTokens, err := GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+
+	// Rotate: the new Ed25519 key signs, the old RSA key still verifies
+	t.Setenv("JWT_SIGNING_KEYS", "old="+rsaPath+", new="+ed25519Path)
+	t.Setenv("JWT_ACTIVE_KEY_ID", "new")
+	newKeys, err := LoadKeySet()
+	assert.NoError(t, err)
+	useKeySet(t, newKeys)
+	newTokens, err := GenerateToken(user, uuid.New())
+	assert.NoError(t, err)
+
+	header := func(token string) jwt.MapClaims {
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, jwt.MapClaims{})
+		assert.NoError(t, err)
+		return parsed.Header
+	}
+	assert.Equal(t, "old", header(oldTokens.AccessToken)["kid"])
+	assert.Equal(t, "RS256", header(oldTokens.AccessToken)["alg"])
+	assert.Equal(t, "new", header(newTokens.AccessToken)["kid"])
+	assert.Equal(t, "EdDSA", header(newTokens.AccessToken)["alg"])
+
+	for _, token := range []string{oldTokens.AccessToken, newTokens.AccessToken} {
+		verified, err := ValidateToken(token)
+		assert.NoError(t, err)
+		assert.Equal(t, user.Id, verified.Id)
+	}
+
+	// Dropping the old key logs out only the tokens it signed
+	t.Setenv("JWT_SIGNING_KEYS", "new="+ed25519Path)
+	t.Setenv("JWT_ACTIVE_KEY_ID", "")
+	latestKeys, err := LoadKeySet()
+	assert.NoError(t, err)
+	useKeySet(t, latestKeys)
+	_, err = ValidateToken(oldTokens.AccessToken)
+	assert.Error(t, err)
+	_, err = ValidateToken(newTokens.AccessToken)
+	assert.NoError(t, err)
+}
+
+func TestKeySet_JWKSPublishesOnlyPublicAsymmetricKeys(t *testing.T) {
+	rsaPath, ed25519Path, ed25519PublicPath := generateKeyFiles(t)
+
+	t.Setenv("JWT_SECRET_KEY", "legacy-secret")
+	t.Setenv("JWT_SIGNING_KEYS", "rsa="+rsaPath+",ed="+ed25519Path+",retired="+ed25519PublicPath)
+	t.Setenv("JWT_ACTIVE_KEY_ID", "rsa")
+	keys, err := LoadKeySet()
+	assert.NoError(t, err)
+
+	jwks := keys.JWKS()
+	if assert.Len(t, jwks.Keys, 3) {
+		assert.Equal(t, "RSA", jwks.Keys[0].KeyType)
+		assert.Equal(t, "RS256", jwks.Keys[0].Algorithm)
+		assert.Equal(t, "AQAB", jwks.Keys[0].Exponent)
+		assert.NotEmpty(t, jwks.Keys[0].Modulus)
+		assert.Equal(t, "OKP", jwks.Keys[1].KeyType)
+		assert.Equal(t, "Ed25519", jwks.Keys[1].Curve)
+		assert.Equal(t, jwks.Keys[1].X, jwks.Keys[2].X)
+		assert.Equal(t, "retired", jwks.Keys[2].KeyId)
+	}
+	for _, key := range jwks.Keys {
+		assert.NotContains(t, key.X+key.Modulus, "legacy-secret")
+	}
+}
+
+func TestKeySet_RejectsBadConfiguration(t *testing.T) {
+	_, _, ed25519PublicPath := generateKeyFiles(t)
+
+	t.Setenv("JWT_SECRET_KEY", "")
+	t.Setenv("JWT_SIGNING_KEYS", "")
+	_, err := LoadKeySet()
+	assert.Error(t, err)
+
+	t.Setenv("JWT_SIGNING_KEYS", "public="+ed25519PublicPath)
+	_, err = LoadKeySet()
+	assert.ErrorContains(t, err, "no private key")
+
+	t.Setenv("JWT_SIGNING_KEYS", "missing-path")
+	_, err = LoadKeySet()
+	assert.ErrorContains(t, err, "kid=path")
+
+	t.Setenv("JWT_SIGNING_KEYS", "public="+ed25519PublicPath)
+	t.Setenv("JWT_SECRET_KEY", "secret")
+	t.Setenv("JWT_ACTIVE_KEY_ID", "unknown")
+	_, err = LoadKeySet()
+	assert.ErrorContains(t, err, "not in the key set")
+}
+
+func TestKeySet_RejectsAlgorithmMismatch(t *testing.T) {
+	rsaPath, _, _ := generateKeyFiles(t)
+	t.Setenv("JWT_SECRET_KEY", "")
+	t.Setenv("JWT_SIGNING_KEYS", "rsa="+rsaPath)
+	keys, err := LoadKeySet()
+	assert.NoError(t, err)
+	useKeySet(t, keys)
+
+	// An HS256 token keyed with the RSA modulus must not verify against the RSA key
+	forged := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": uuid.New().String(), "isAdmin": true, "type": "access", "exp": 9999999999})
+	forged.Header["kid"] = "rsa"
+	signed, err := forged.SignedString([]byte(keys.JWKS().Keys[0].Modulus))
+	assert.NoError(t, err)
+	_, err = ValidateToken(signed)
+	assert.Error(t, err)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfc6238Secret is the SHA1 seed of the RFC 6238 test vectors, "12345678901234567890"
+var rfc6238Secret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCode_MatchesRFC6238Vectors(t *testing.T) {
+	// The RFC lists eight digit codes; six digit codes are their last six digits
+	vectors := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, vector := range vectors {
+		code, err := TOTPCode(rfc6238Secret, TOTPStep(time.Unix(vector.unix, 0)))
+		assert.NoError(t, err)
+		assert.Equal(t, vector.code, code, "time %d", vector.unix)
+	}
+
+	_, err := TOTPCode("not base32!", 1)
+	assert.Error(t, err)
+}
+
+func TestMatchTOTP_AllowsOneStepOfSkew(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	assert.NoError(t, err)
+	now := time.Unix(1700000000, 0)
+	step := TOTPStep(now)
+
+	previous, _ := TOTPCode(secret, step-1)
+	matched, ok := MatchTOTP(secret, previous, now, 1)
+	assert.True(t, ok)
+	assert.Equal(t, step-1, matched)
+
+	tooOld, _ := TOTPCode(secret, step-2)
+	_, ok = MatchTOTP(secret, tooOld, now, 1)
+	assert.False(t, ok)
+
+	_, ok = MatchTOTP(secret, "12345", now, 1)
+	assert.False(t, ok)
+}
+
+func TestTOTPURI(t *testing.T) {
+	uri, err := url.Parse(TOTPURI("Hotel Booking", "admin@example.com", "JBSWY3DPEHPK3PXP"))
+	assert.NoError(t, err)
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Hotel Booking:admin@example.com", uri.Path)
+	assert.Equal(t, "JBSWY3DPEHPK3PXP", uri.Query().Get("secret"))
+	assert.Equal(t, "Hotel Booking", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+}
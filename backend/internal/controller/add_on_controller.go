@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddOnController struct {
+	addOnService service.AddOnService
+}
+
+func NewAddOnController(addOnService service.AddOnService) *AddOnController {
+	return &AddOnController{addOnService: addOnService}
+}
+
+// CreateAddOn godoc
+// @Summary      Create a hotel add-on
+// @Description  Add an extra such as breakfast or parking to a hotel's catalogue, priced per stay, per night or per guest (Admin only)
+// @Tags         Add-ons
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true  "Hotel ID"
+// @Param        request  body      domain.AddOnRequest  true  "Add-on information"
+// @Success      201      {object}  shared.ApiResponse{data=domain.AddOn}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/add-ons [post]
+func (c *AddOnController) CreateAddOn(ctx *gin.Context) {
+	var request domain.AddOnRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	addOn, err := c.addOnService.CreateAddOn(ctx.Param("id"), &request)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Add-on created successfully", addOn, ctx.Request.URL.Path))
+}
+
+// GetAddOns godoc
+// @Summary      Get hotel add-ons
+// @Description  List the add-ons a hotel currently sells
+// @Tags         Add-ons
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.AddOn}
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/add-ons [get]
+func (c *AddOnController) GetAddOns(ctx *gin.Context) {
+	addOns, err := c.addOnService.GetAddOnsByHotelId(ctx.Param("id"), false)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Add-ons fetched successfully", addOns, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// UpdateAddOn godoc
+// @Summary      Update a hotel add-on
+// @Description  Change an add-on's details or price. Existing bookings keep the price they were sold at (Admin only)
+// @Tags         Add-ons
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string               true  "Hotel ID"
+// @Param        addOnId   path      string               true  "Add-on ID"
+// @Param        request   body      domain.AddOnRequest  true  "Add-on information"
+// @Success      200       {object}  shared.ApiResponse{data=domain.AddOn}
+// @Failure      400       {object}  shared.ErrorResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/add-ons/{addOnId} [put]
+func (c *AddOnController) UpdateAddOn(ctx *gin.Context) {
+	var request domain.AddOnRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	addOn, err := c.addOnService.UpdateAddOn(ctx.Param("id"), ctx.Param("addOnId"), &request)
+	if err != nil {
+		if errors.Is(err, service.ErrAddOnNotFound) {
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Add-on updated successfully", addOn, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// DeactivateAddOn godoc
+// @Summary      Remove a hotel add-on from sale
+// @Description  Deactivate an add-on so it can no longer be selected (Admin only)
+// @Tags         Add-ons
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string  true  "Hotel ID"
+// @Param        addOnId   path      string  true  "Add-on ID"
+// @Success      200       {object}  shared.ApiResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/add-ons/{addOnId} [delete]
+func (c *AddOnController) DeactivateAddOn(ctx *gin.Context) {
+	if err := c.addOnService.DeactivateAddOn(ctx.Param("id"), ctx.Param("addOnId")); err != nil {
+		if errors.Is(err, service.ErrAddOnNotFound) {
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Add-on deactivated successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
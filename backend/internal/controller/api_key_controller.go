@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController struct {
+	apiKeyService service.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService service.APIKeyService) *APIKeyController {
+	return &APIKeyController{apiKeyService: apiKeyService}
+}
+
+// CreateAPIKey godoc
+// @Summary      Create an API key
+// @Description  Create an API key that acts as the user within its scopes, sent in the X-API-Key header. The key is only shown in this response. Admin only
+// @Tags         API Keys
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.APIKeyRequest  true  "Owner, scopes, rate limit and expiry"
+// @Success      201      {object}  shared.ApiResponse{data=domain.APIKeyCreatedResponse}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /api-keys [post]
+func (c *APIKeyController) CreateAPIKey(ctx *gin.Context) {
+	var request domain.APIKeyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	key, err := c.apiKeyService.CreateKey(currentUser(ctx), &request)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("API key created successfully, store it now as it will not be shown again", key, ctx.Request.URL.Path))
+}
+
+// GetAPIKeys godoc
+// @Summary      Get API keys
+// @Description  List API keys, newest first, optionally only a user's. Admin only
+// @Tags         API Keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        user_id  query     string  false  "Only this user's keys"
+// @Success      200      {object}  shared.ApiResponse{data=[]domain.APIKey}
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /api-keys [get]
+func (c *APIKeyController) GetAPIKeys(ctx *gin.Context) {
+	keys, err := c.apiKeyService.GetKeys(ctx.Query("user_id"))
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("API keys fetched successfully", keys, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetAPIKey godoc
+// @Summary      Get an API key
+// @Description  Get an API key, including when it was last used. Admin only
+// @Tags         API Keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.APIKey}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /api-keys/{id} [get]
+func (c *APIKeyController) GetAPIKey(ctx *gin.Context) {
+	key, err := c.apiKeyService.GetKey(ctx.Param("id"))
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("API key fetched successfully", key, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// RevokeAPIKey godoc
+// @Summary      Revoke an API key
+// @Description  Revoke an API key so it is no longer accepted. Admin only
+// @Tags         API Keys
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "API key ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.APIKey}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /api-keys/{id} [delete]
+func (c *APIKeyController) RevokeAPIKey(ctx *gin.Context) {
+	key, err := c.apiKeyService.RevokeKey(currentUser(ctx), ctx.Param("id"))
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("API key revoked successfully", key, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondAPIKeyError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidAPIKeyScope), errors.Is(err, service.ErrInvalidAPIKeyExpiry):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrAPIKeyNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrAPIKeyAlreadyRevoked):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
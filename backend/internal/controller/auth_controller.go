@@ -4,9 +4,13 @@ import (
 	"backend/internal/domain"
 	"backend/internal/service"
 	"backend/internal/shared"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 )
 
 type AuthController struct {
@@ -19,13 +23,15 @@ func NewAuthController(authService service.IAuthService) *AuthController {
 
 // Login godoc
 // @Summary      User login
-// @Description  Authenticate user and return JWT tokens
+// @Description  Authenticate user and return JWT tokens. When the account uses two-factor login, mfa_required is set and the mfa_token is exchanged for the tokens at /auth/mfa/verify
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
 // @Param        loginRequest  body      domain.LoginRequest  true  "Login credentials"
 // @Success      200           {object}  shared.ApiResponse{data=domain.LoginResponse}
 // @Failure      400           {object}  shared.ErrorResponse
+// @Failure      401           {object}  shared.ErrorResponse
+// @Failure      429           {object}  shared.ErrorResponse
 // @Failure      500           {object}  shared.ErrorResponse
 // @Router       /auth/login [post]
 func (c *AuthController) Login(ctx *gin.Context) {
@@ -35,9 +41,63 @@ func (c *AuthController) Login(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
 		return
 	}
-	loginResponse, err := c.authService.Login(loginRequest.Email, loginRequest.Password)
+	device := loginRequest.Device
+	if device == "" {
+		device = ctx.Request.UserAgent()
+	}
+	loginResponse, err := c.authService.Login(loginRequest.Email, loginRequest.Password, device, ctx.ClientIP())
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.As(err, &throttled):
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusTooManyRequests))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
+		return
+	}
+	if loginResponse.MFARequired {
+		ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Enter the code from your authenticator app", loginResponse, http.StatusOK, ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Login successful", loginResponse, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// VerifyMFA godoc
+// @Summary      Complete two-factor login
+// @Description  Exchange the mfa_token from /auth/login and a code from the authenticator app, or a recovery code, for JWT tokens
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      domain.MFAVerifyRequest  true  "Challenge token and code"
+// @Success      200      {object}  shared.ApiResponse{data=domain.LoginResponse}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      429      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/mfa/verify [post]
+func (c *AuthController) VerifyMFA(ctx *gin.Context) {
+	var request domain.MFAVerifyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	loginResponse, err := c.authService.VerifyMFA(&request, ctx.ClientIP())
+	if err != nil {
+		var throttled *service.LoginThrottledError
+		switch {
+		case errors.Is(err, service.ErrInvalidMFAChallenge), errors.Is(err, service.ErrInvalidMFACode):
+			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.As(err, &throttled):
+			ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter.Seconds()))))
+			ctx.JSON(http.StatusTooManyRequests, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusTooManyRequests))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
 		return
 	}
 	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Login successful", loginResponse, http.StatusOK, ctx.Request.URL.Path))
@@ -45,7 +105,7 @@ func (c *AuthController) Login(ctx *gin.Context) {
 
 // Register godoc
 // @Summary      User registration
-// @Description  Register a new user account
+// @Description  Register a new user account and email a link for verifying its address
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -72,7 +132,7 @@ func (c *AuthController) Register(ctx *gin.Context) {
 
 // RefreshToken godoc
 // @Summary      Refresh access token
-// @Description  Generate a new access token using a valid refresh token
+// @Description  Exchange a refresh token for a new access and refresh token. Each refresh token works once; presenting a used one ends the session
 // @Tags         Auth
 // @Accept       json
 // @Produce      json
@@ -92,9 +152,317 @@ func (c *AuthController) RefreshToken(ctx *gin.Context) {
 
 	loginResponse, err := c.authService.RefreshToken(refreshRequest.RefreshToken)
 	if err != nil {
+		if errors.Is(err, service.ErrRefreshTokenReused) {
+			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
 		ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Invalid or expired refresh token", ctx.Request.URL.Path))
 		return
 	}
 
 	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Token refreshed successfully", loginResponse, http.StatusOK, ctx.Request.URL.Path))
 }
+
+// Logout godoc
+// @Summary      Log out
+// @Description  Revoke a refresh token so it can no longer be used. Access tokens already issued stay valid until they expire
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        refreshRequest  body      domain.RefreshTokenRequest  true  "Refresh token"
+// @Success      200             {object}  shared.ApiResponse
+// @Failure      400             {object}  shared.ErrorResponse
+// @Failure      401             {object}  shared.ErrorResponse
+// @Failure      500             {object}  shared.ErrorResponse
+// @Router       /auth/logout [post]
+func (c *AuthController) Logout(ctx *gin.Context) {
+	var refreshRequest domain.RefreshTokenRequest
+	if err := ctx.ShouldBindJSON(&refreshRequest); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.authService.Logout(refreshRequest.RefreshToken); err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshToken) {
+			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Invalid or expired refresh token", ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Logout successful", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// LogoutAll godoc
+// @Summary      Log out everywhere
+// @Description  Revoke every refresh token of the signed-in user, ending their sessions on all devices
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse{data=domain.LogoutAllResponse}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /auth/logout-all [post]
+func (c *AuthController) LogoutAll(ctx *gin.Context) {
+	revoked, err := c.authService.LogoutAll(currentUser(ctx).Id)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Logged out of all sessions", domain.LogoutAllResponse{RevokedSessions: revoked}, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// JWKS godoc
+// @Summary      Get token signing keys
+// @Description  Publish the public keys access tokens are signed with as a JSON Web Key Set, so other services can verify them. Tokens name their key in the kid header
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  domain.JSONWebKeySet
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /.well-known/jwks.json [get]
+func (c *AuthController) JWKS(ctx *gin.Context) {
+	jwks, err := c.authService.GetJWKS()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	// Verifiers may cache the keys briefly; a rotated-in key is published before it starts signing
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, jwks)
+}
+
+// ForgotPassword godoc
+// @Summary      Request a password reset
+// @Description  Email a single-use link for choosing a new password. The response is the same whether or not the address has an account
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      domain.ForgotPasswordRequest  true  "Account email address"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/forgot-password [post]
+func (c *AuthController) ForgotPassword(ctx *gin.Context) {
+	var request domain.ForgotPasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.authService.ForgotPassword(request.Email); err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("If an account uses this email address, a password reset link has been sent to it", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ResetPassword godoc
+// @Summary      Reset password
+// @Description  Set a new password with the token from a password reset email. Every session of the account is signed out
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        request  body      domain.ResetPasswordRequest  true  "Reset token and new password"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/reset-password [post]
+func (c *AuthController) ResetPassword(ctx *gin.Context) {
+	var request domain.ResetPasswordRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.authService.ResetPassword(&request); err != nil {
+		if errors.Is(err, service.ErrInvalidResetToken) {
+			ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Password reset successfully, please log in again", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// VerifyEmail godoc
+// @Summary      Verify email address
+// @Description  Confirm the account's email address with the token from the link in the verification email
+// @Tags         Auth
+// @Produce      json
+// @Param        token  query     string  true  "Verification token"
+// @Success      200    {object}  shared.ApiResponse
+// @Failure      400    {object}  shared.ErrorResponse
+// @Failure      500    {object}  shared.ErrorResponse
+// @Router       /auth/verify [get]
+func (c *AuthController) VerifyEmail(ctx *gin.Context) {
+	token := ctx.Query("token")
+	if token == "" {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse("token is required", ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.authService.VerifyEmail(token); err != nil {
+		if errors.Is(err, service.ErrInvalidVerificationToken) {
+			ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Email address verified", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ResendVerification godoc
+// @Summary      Resend verification email
+// @Description  Send the signed-in user a new email verification link. Only one email is sent per resend interval
+// @Tags         Auth
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      429  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /auth/resend-verification [post]
+func (c *AuthController) ResendVerification(ctx *gin.Context) {
+	if err := c.authService.ResendVerification(currentUser(ctx).Id); err != nil {
+		switch {
+		case errors.Is(err, service.ErrEmailAlreadyVerified):
+			ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.Is(err, service.ErrVerificationThrottled):
+			ctx.JSON(http.StatusTooManyRequests, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusTooManyRequests))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Verification email sent", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// UnlockAccount godoc
+// @Summary      Unlock a user's logins
+// @Description  Lift a lockout caused by failed logins to the user's account and forget the failures. Admin only
+// @Tags         Auth
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  shared.ApiResponse
+// @Failure      400  {object}  shared.ErrorResponse
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /users/{id}/unlock [post]
+func (c *AuthController) UnlockAccount(ctx *gin.Context) {
+	userId, err := uuid.Parse(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse("invalid user id", ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.authService.UnlockAccount(userId); err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Account unlocked", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetIdentityProviders godoc
+// @Summary      List identity providers
+// @Description  List the OpenID Connect providers users can sign in with
+// @Tags         Auth
+// @Produce      json
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.IdentityProviderInfo}
+// @Router       /auth/providers [get]
+func (c *AuthController) GetIdentityProviders(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Identity providers fetched successfully", c.authService.GetIdentityProviders(), http.StatusOK, ctx.Request.URL.Path))
+}
+
+// StartExternalLogin godoc
+// @Summary      Start signing in with an identity provider
+// @Description  Start an authorization-code login with PKCE. Send the user to authorization_url; the provider redirects back with a code and the state
+// @Tags         Auth
+// @Produce      json
+// @Param        provider  path      string  true  "Identity provider name"
+// @Success      200       {object}  shared.ApiResponse{data=domain.ExternalLoginStartResponse}
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /auth/oidc/{provider}/start [post]
+func (c *AuthController) StartExternalLogin(ctx *gin.Context) {
+	response, err := c.authService.StartExternalLogin(ctx.Param("provider"))
+	if err != nil {
+		respondExternalLoginError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Continue at the identity provider", response, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// CompleteExternalLogin godoc
+// @Summary      Finish signing in with an identity provider
+// @Description  Exchange the code and state the identity provider redirected back with for JWT tokens. A new identity is linked to the account with the same email address, or to a new account, when the provider has verified the address; an existing account must have verified it too. When the account uses two-factor login, mfa_required is set as for /auth/login
+// @Tags         Auth
+// @Accept       json
+// @Produce      json
+// @Param        provider  path      string                               true  "Identity provider name"
+// @Param        request   body      domain.ExternalLoginCallbackRequest  true  "Code and state from the provider"
+// @Success      200       {object}  shared.ApiResponse{data=domain.LoginResponse}
+// @Failure      400       {object}  shared.ErrorResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      409       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /auth/oidc/{provider}/callback [post]
+func (c *AuthController) CompleteExternalLogin(ctx *gin.Context) {
+	var request domain.ExternalLoginCallbackRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	device := request.Device
+	if device == "" {
+		device = ctx.Request.UserAgent()
+	}
+
+	loginResponse, err := c.authService.CompleteExternalLogin(ctx.Param("provider"), &request, device)
+	if err != nil {
+		respondExternalLoginError(ctx, err)
+		return
+	}
+	if loginResponse.MFARequired {
+		ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Enter the code from your authenticator app", loginResponse, http.StatusOK, ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Login successful", loginResponse, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondExternalLoginError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidExternalLoginState):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrExternalLoginFailed):
+		ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrExternalEmailNotVerified):
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrExternalAccountNotVerified):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrUnknownIdentityProvider):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
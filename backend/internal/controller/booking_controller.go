@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain"
 	"backend/internal/service"
 	"backend/internal/shared"
+	"errors"
 	"net/http"
 	"time"
 
@@ -20,22 +21,41 @@ func NewBookingController(bookingService service.BookingService) *BookingControl
 
 // CreateBooking godoc
 // @Summary      Create a new booking
-// @Description  Create a new hotel booking (Requires authentication)
+// @Description  Create a new hotel booking for the signed-in user, or the user the API key acts as
 // @Tags         Bookings
 // @Accept       json
 // @Produce      json
 // @Security     BearerAuth
+// @Security     ApiKeyAuth
 // @Param        booking  body      domain.CreateBookingRequest  true  "Booking information"
 // @Success      201      {object}  shared.ApiResponse{data=domain.Booking}
 // @Failure      400      {object}  shared.ErrorResponse
 // @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
 // @Failure      500      {object}  shared.ErrorResponse
 // @Router       /bookings [post]
 func (c *BookingController) CreateBooking(ctx *gin.Context) {
 	var booking domain.CreateBookingRequest
-	ctx.ShouldBindJSON(&booking)
+	if err := ctx.ShouldBindJSON(&booking); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	booking.UserId = currentUser(ctx).Id
 	err := c.bookingService.CreateBooking(&booking)
 	if err != nil {
+		if errors.Is(err, service.ErrStayRestricted) || errors.Is(err, service.ErrAddOnNotFound) || errors.Is(err, service.ErrInvalidGuestDetails) {
+			ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		if errors.Is(err, service.ErrRoomUnavailable) {
+			ctx.JSON(http.StatusConflict, shared.NewConflictResponse("Room is fully booked for the selected dates. Join the waitlist with POST /waitlist to be offered it if it frees up", ctx.Request.URL.Path))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, shared.ErrorResponse{Message: err.Error(), Path: ctx.Request.URL.Path, Status: http.StatusInternalServerError, Timestamp: time.Now().Format(time.RFC3339)})
 		return
 	}
@@ -44,11 +64,14 @@ func (c *BookingController) CreateBooking(ctx *gin.Context) {
 
 // GetAllBookings godoc
 // @Summary      Get all bookings
-// @Description  Retrieve a list of all bookings
+// @Description  Retrieve a list of all bookings (Admin only)
 // @Tags         Bookings
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Success      200  {object}  shared.ApiResponse{data=[]domain.Booking}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
 // @Failure      500  {object}  shared.ErrorResponse
 // @Router       /bookings [get]
 func (c *BookingController) GetAllBookings(ctx *gin.Context) {
@@ -62,13 +85,16 @@ func (c *BookingController) GetAllBookings(ctx *gin.Context) {
 
 // GetBookingById godoc
 // @Summary      Get booking by ID
-// @Description  Retrieve a specific booking by its ID
+// @Description  Retrieve a specific booking by its ID. Only the guest who made it and the hotel's staff with bookings:read may see it
 // @Tags         Bookings
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        id   path      string  true  "Booking ID"
 // @Success      200  {object}  shared.ApiResponse{data=domain.Booking}
-// @Failure      400  {object}  shared.ErrorResponse
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
 // @Failure      500  {object}  shared.ErrorResponse
 // @Router       /bookings/{id} [get]
 func (c *BookingController) GetBookingById(ctx *gin.Context) {
@@ -83,13 +109,15 @@ func (c *BookingController) GetBookingById(ctx *gin.Context) {
 
 // GetBookingsByUserId godoc
 // @Summary      Get bookings by user ID
-// @Description  Retrieve all bookings for a specific user
+// @Description  Retrieve all bookings for a specific user. Only the user and admins may list them
 // @Tags         Bookings
 // @Accept       json
 // @Produce      json
+// @Security     BearerAuth
 // @Param        user_id  path      string  true  "User ID"
 // @Success      200      {object}  shared.ApiResponse{data=[]domain.Booking}
-// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
 // @Failure      500      {object}  shared.ErrorResponse
 // @Router       /bookings/user/{user_id} [get]
 func (c *BookingController) GetBookingsByUserId(ctx *gin.Context) {
@@ -101,3 +129,228 @@ func (c *BookingController) GetBookingsByUserId(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, shared.ApiResponse{Message: "Bookings fetched successfully", Data: bookings})
 }
+
+// CancelBooking godoc
+// @Summary      Cancel a booking
+// @Description  Cancel one of the current user's bookings (admins may cancel any) and release the room (Requires authentication)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  shared.ApiResponse
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/cancel [post]
+func (c *BookingController) CancelBooking(ctx *gin.Context) {
+	err := c.bookingService.CancelBooking(ctx.Param("id"), currentUser(ctx))
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Booking cancelled successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// AddAddOn godoc
+// @Summary      Add an add-on to a booking
+// @Description  Attach a hotel add-on to an active booking before check-in; the booking total is raised accordingly (Requires authentication)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id         path      string                 true  "Booking ID"
+// @Param        selection  body      domain.AddOnSelection  true  "Add-on to attach"
+// @Success      201        {object}  shared.ApiResponse{data=domain.BookingAddOn}
+// @Failure      400        {object}  shared.ErrorResponse
+// @Failure      401        {object}  shared.ErrorResponse
+// @Failure      403        {object}  shared.ErrorResponse
+// @Failure      404        {object}  shared.ErrorResponse
+// @Failure      409        {object}  shared.ErrorResponse
+// @Failure      500        {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/add-ons [post]
+func (c *BookingController) AddAddOn(ctx *gin.Context) {
+	var selection domain.AddOnSelection
+	if err := ctx.ShouldBindJSON(&selection); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	line, err := c.bookingService.AddAddOn(ctx.Param("id"), currentUser(ctx), &selection)
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Add-on added successfully", line, ctx.Request.URL.Path))
+}
+
+// RemoveAddOn godoc
+// @Summary      Remove an add-on from a booking
+// @Description  Detach an add-on from an active booking before check-in; the booking total is lowered accordingly (Requires authentication)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Booking ID"
+// @Param        addOnId  path      string  true  "Booking add-on ID"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/add-ons/{addOnId} [delete]
+func (c *BookingController) RemoveAddOn(ctx *gin.Context) {
+	err := c.bookingService.RemoveAddOn(ctx.Param("id"), ctx.Param("addOnId"), currentUser(ctx))
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Add-on removed successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetInvoice godoc
+// @Summary      Get booking invoice
+// @Description  Itemised invoice of a booking: the room stay and each add-on (Requires authentication)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Security     ApiKeyAuth
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.Invoice}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/invoice [get]
+func (c *BookingController) GetInvoice(ctx *gin.Context) {
+	invoice, err := c.bookingService.GetInvoice(ctx.Param("id"), currentUser(ctx))
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Invoice fetched successfully", invoice, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// UpdateGuestDetails godoc
+// @Summary      Update guest details
+// @Description  Set the staying guest's name, contact, arrival time and special requests on an active booking before check-in (Requires authentication)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string               true  "Booking ID"
+// @Param        guest    body      domain.GuestDetails  true  "Guest details"
+// @Success      200      {object}  shared.ApiResponse{data=domain.Booking}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/guest [put]
+func (c *BookingController) UpdateGuestDetails(ctx *gin.Context) {
+	var details domain.GuestDetails
+	if err := ctx.ShouldBindJSON(&details); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	booking, err := c.bookingService.UpdateGuestDetails(ctx.Param("id"), currentUser(ctx), &details)
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Guest details updated successfully", booking, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetHotelBookings godoc
+// @Summary      Get a hotel's bookings
+// @Description  List a hotel's bookings by arrival date with guest details and special requests, for front desk staff (Admin only)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.Booking}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/bookings [get]
+func (c *BookingController) GetHotelBookings(ctx *gin.Context) {
+	bookings, err := c.bookingService.GetBookingsByHotelId(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Bookings fetched successfully", bookings, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// CheckIn godoc
+// @Summary      Check in a booking
+// @Description  Mark a confirmed booking as arrived on or after its arrival date. Unarrived bookings become no-shows after the hotel's cutoff (Admin only)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.Booking}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/check-in [post]
+func (c *BookingController) CheckIn(ctx *gin.Context) {
+	booking, err := c.bookingService.CheckIn(ctx.Param("id"), time.Now())
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Booking checked in successfully", booking, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// CheckOut godoc
+// @Summary      Check out a booking
+// @Description  Mark a checked-in booking as departed (Admin only)
+// @Tags         Bookings
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Booking ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.Booking}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /bookings/{id}/check-out [post]
+func (c *BookingController) CheckOut(ctx *gin.Context) {
+	booking, err := c.bookingService.CheckOut(ctx.Param("id"))
+	if err != nil {
+		respondBookingChangeError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Booking checked out successfully", booking, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// respondBookingChangeError maps booking service errors to HTTP responses
+func respondBookingChangeError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidGuestDetails):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrBookingNotFound), errors.Is(err, service.ErrAddOnNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrBookingNotOwnedByUser):
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrBookingAlreadyClosed), errors.Is(err, service.ErrBookingChangesClosed),
+		errors.Is(err, service.ErrBookingStatusConflict), errors.Is(err, service.ErrCheckInNotOpen):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
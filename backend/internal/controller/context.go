@@ -0,0 +1,17 @@
+package controller
+
+import (
+	"backend/internal/domain"
+
+	"github.com/gin-gonic/gin"
+)
+
+// currentUser returns the user set on the context by the auth middleware, or nil
+func currentUser(ctx *gin.Context) *domain.User {
+	value, exists := ctx.Get("user")
+	if !exists {
+		return nil
+	}
+	user, _ := value.(*domain.User)
+	return user
+}
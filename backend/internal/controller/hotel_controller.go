@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain"
 	"backend/internal/service"
 	"backend/internal/shared"
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -29,6 +30,7 @@ func NewHotelController(hotelService service.HotelService) *HotelController {
 // @Failure      400    {object}  shared.ErrorResponse
 // @Failure      401    {object}  shared.ErrorResponse
 // @Failure      403    {object}  shared.ErrorResponse
+// @Failure      404    {object}  shared.ErrorResponse
 // @Failure      500    {object}  shared.ErrorResponse
 // @Router       /hotels [post]
 func (c *HotelController) CreateHotel(ctx *gin.Context) {
@@ -36,6 +38,10 @@ func (c *HotelController) CreateHotel(ctx *gin.Context) {
 	ctx.ShouldBindJSON(&hotel)
 	err := c.hotelService.CreateHotel(&hotel)
 	if err != nil {
+		if errors.Is(err, service.ErrOrganizationNotFound) {
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
 		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
 		return
 	}
@@ -44,17 +50,39 @@ func (c *HotelController) CreateHotel(ctx *gin.Context) {
 
 // GetAllHotels godoc
 // @Summary      Get all hotels
-// @Description  Retrieve a list of all hotels
+// @Description  Retrieve a list of hotels, optionally filtered by a text query, city, country, a bounding box (min_lat, min_lng, max_lat, max_lng) or a radius around lat/lng. A text query matches the name, description, address and facilities, tolerates typos and sorts the hotels by relevance; giving lat/lng sorts them by distance instead and includes distance_km
 // @Tags         Hotels
 // @Accept       json
 // @Produce      json
+// @Param        q          query     string  false  "Text to search for"
+// @Param        lat        query     number  false  "Latitude to measure distance from"
+// @Param        lng        query     number  false  "Longitude to measure distance from"
+// @Param        radius_km  query     number  false  "Only hotels within this many km of lat/lng (max 1000)"
+// @Param        min_lat    query     number  false  "Bounding box south edge"
+// @Param        min_lng    query     number  false  "Bounding box west edge"
+// @Param        max_lat    query     number  false  "Bounding box north edge"
+// @Param        max_lng    query     number  false  "Bounding box east edge; less than min_lng when the box crosses the antimeridian"
+// @Param        city       query     string  false  "City"
+// @Param        country    query     string  false  "Country"
+// @Param        organization_id  query  string  false  "Only hotels of this organization"
+// @Param        facility   query     []string  false  "Facility every hotel must offer; repeat for several"  collectionFormat(multi)
+// @Param        min_rating query     number  false  "Minimum rating"
+// @Param        min_price  query     number  false  "Minimum price of the cheapest room"
+// @Param        max_price  query     number  false  "Cheapest room costs less than this"
 // @Success      200  {object}  shared.ApiResponse{data=[]domain.Hotel}
+// @Failure      400  {object}  shared.ErrorResponse
 // @Failure      500  {object}  shared.ErrorResponse
 // @Router       /hotels [get]
 func (c *HotelController) GetAllHotels(ctx *gin.Context) {
-	hotels, err := c.hotelService.GetAllHotels()
+	search, err := parseHotelSearch(ctx)
 	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	hotels, err := c.hotelService.SearchHotels(search)
+	if err != nil {
+		respondHotelSearchError(ctx, err)
 		return
 	}
 	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Hotels fetched successfully", hotels, http.StatusOK, ctx.Request.URL.Path))
@@ -80,3 +108,109 @@ func (c *HotelController) GetHotelById(ctx *gin.Context) {
 	}
 	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Hotel fetched successfully", hotel, http.StatusOK, ctx.Request.URL.Path))
 }
+
+// GetPriceCalendar godoc
+// @Summary      Get a hotel's lowest-price calendar
+// @Description  For each date from from to to, the cheapest nightly rate among the hotel's rooms that can be booked for an arrival that day, how many rooms can and whether anything is sellable. A room counts when its shortest allowed stay is free, not held for a waitlist offer and allowed by the stay restrictions. Spans at most 366 days
+// @Tags         Hotels
+// @Accept       json
+// @Produce      json
+// @Param        id    path      string  true  "Hotel ID"
+// @Param        from  query     string  true  "First date (YYYY-MM-DD)"
+// @Param        to    query     string  true  "Last date (YYYY-MM-DD)"
+// @Success      200   {object}  shared.ApiResponse{data=[]domain.PriceCalendarDay}
+// @Failure      400   {object}  shared.ErrorResponse
+// @Failure      404   {object}  shared.ErrorResponse
+// @Failure      500   {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/price-calendar [get]
+func (c *HotelController) GetPriceCalendar(ctx *gin.Context) {
+	from, err := parseDateQuery(ctx, "from")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	to, err := parseDateQuery(ctx, "to")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	calendar, err := c.hotelService.GetPriceCalendar(ctx.Param("id"), from, to)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCalendarRange):
+			ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.Is(err, service.ErrHotelNotFound):
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Price calendar fetched successfully", calendar, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondHotelSearchError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidLocationSearch),
+		errors.Is(err, service.ErrInvalidSearchQuery),
+		errors.Is(err, service.ErrInvalidHotelFilter):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
+
+// parseHotelSearch reads the text, location, place, facility, rating and price filters of the hotel list
+func parseHotelSearch(ctx *gin.Context) (*domain.HotelSearch, error) {
+	search := &domain.HotelSearch{
+		Query:      ctx.Query("q"),
+		City:       ctx.Query("city"),
+		Country:    ctx.Query("country"),
+		Facilities: ctx.QueryArray("facility"),
+	}
+
+	var err error
+	if search.OrganizationId, err = parseOptionalUUIDQuery(ctx, "organization_id"); err != nil {
+		return nil, err
+	}
+	if search.MinRating, err = parseOptionalFloatQuery(ctx, "min_rating"); err != nil {
+		return nil, err
+	}
+	if search.MinPrice, err = parseOptionalFloatQuery(ctx, "min_price"); err != nil {
+		return nil, err
+	}
+	if search.MaxPrice, err = parseOptionalFloatQuery(ctx, "max_price"); err != nil {
+		return nil, err
+	}
+	if search.Lat, err = parseOptionalFloatQuery(ctx, "lat"); err != nil {
+		return nil, err
+	}
+	if search.Lng, err = parseOptionalFloatQuery(ctx, "lng"); err != nil {
+		return nil, err
+	}
+	if search.RadiusKm, err = parseOptionalFloatQuery(ctx, "radius_km"); err != nil {
+		return nil, err
+	}
+
+	edges := make([]*float64, 4)
+	for i, key := range []string{"min_lat", "min_lng", "max_lat", "max_lng"} {
+		if edges[i], err = parseOptionalFloatQuery(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	given := 0
+	for _, edge := range edges {
+		if edge != nil {
+			given++
+		}
+	}
+	switch given {
+	case 0:
+	case 4:
+		search.Bounds = &domain.GeoBounds{MinLat: *edges[0], MinLng: *edges[1], MaxLat: *edges[2], MaxLng: *edges[3]}
+	default:
+		return nil, errors.New("min_lat, min_lng, max_lat and max_lng must be given together")
+	}
+	return search, nil
+}
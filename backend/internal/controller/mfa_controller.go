@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MFAController struct {
+	mfaService service.MFAService
+}
+
+func NewMFAController(mfaService service.MFAService) *MFAController {
+	return &MFAController{mfaService: mfaService}
+}
+
+// GetStatus godoc
+// @Summary      Get two-factor status
+// @Description  Say whether two-factor login is on for the signed-in user and how many recovery codes are left
+// @Tags         MFA
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse{data=domain.MFAStatus}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /auth/mfa [get]
+func (c *MFAController) GetStatus(ctx *gin.Context) {
+	status, err := c.mfaService.GetStatus(currentUser(ctx).Id)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Two-factor status fetched successfully", status, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// Enroll godoc
+// @Summary      Start two-factor enrollment
+// @Description  Create a TOTP secret for the signed-in user. Add it to an authenticator app, usually by showing the otpauth URI as a QR code, then confirm with a code from the app
+// @Tags         MFA
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse{data=domain.MFAEnrollmentResponse}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /auth/mfa/enroll [post]
+func (c *MFAController) Enroll(ctx *gin.Context) {
+	enrollment, err := c.mfaService.Enroll(currentUser(ctx).Id)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Add the secret to your authenticator app and confirm with a code", enrollment, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// Confirm godoc
+// @Summary      Confirm two-factor enrollment
+// @Description  Turn two-factor login on with a code from the authenticator app. The response holds the recovery codes, which are not shown again
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.MFACodeRequest  true  "Code from the authenticator app"
+// @Success      200      {object}  shared.ApiResponse{data=domain.MFARecoveryCodesResponse}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/mfa/confirm [post]
+func (c *MFAController) Confirm(ctx *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	codes, err := c.mfaService.Confirm(currentUser(ctx).Id, request.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Two-factor authentication enabled, keep the recovery codes somewhere safe", codes, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// RegenerateRecoveryCodes godoc
+// @Summary      Regenerate recovery codes
+// @Description  Replace the signed-in user's recovery codes after checking a code from the authenticator app. The old codes stop working
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.MFACodeRequest  true  "Code from the authenticator app"
+// @Success      200      {object}  shared.ApiResponse{data=domain.MFARecoveryCodesResponse}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/mfa/recovery-codes [post]
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	codes, err := c.mfaService.RegenerateRecoveryCodes(currentUser(ctx).Id, request.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Recovery codes regenerated", codes, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// Disable godoc
+// @Summary      Turn two-factor login off
+// @Description  Remove the signed-in user's TOTP secret and recovery codes after checking a code from the authenticator app or a recovery code
+// @Tags         MFA
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.MFACodeRequest  true  "Code from the authenticator app or a recovery code"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /auth/mfa/disable [post]
+func (c *MFAController) Disable(ctx *gin.Context) {
+	var request domain.MFACodeRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.mfaService.Disable(currentUser(ctx).Id, request.Code); err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Two-factor authentication disabled", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondMFAError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidMFACode):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrMFAAlreadyEnabled), errors.Is(err, service.ErrMFANotEnrolled), errors.Is(err, service.ErrMFANotEnabled):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
@@ -0,0 +1,248 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type OrganizationController struct {
+	organizationService service.OrganizationService
+}
+
+func NewOrganizationController(organizationService service.OrganizationService) *OrganizationController {
+	return &OrganizationController{organizationService: organizationService}
+}
+
+// CreateOrganization godoc
+// @Summary      Create an organization
+// @Description  Create a hotel chain or brand with its settings. Currency defaults to USD (Admin only)
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.OrganizationRequest  true  "Organization information"
+// @Success      201      {object}  shared.ApiResponse{data=domain.Organization}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /organizations [post]
+func (c *OrganizationController) CreateOrganization(ctx *gin.Context) {
+	var request domain.OrganizationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	organization, err := c.organizationService.CreateOrganization(&request)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Organization created successfully", organization, ctx.Request.URL.Path))
+}
+
+// GetOrganizations godoc
+// @Summary      Get organizations
+// @Description  List all organizations
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.Organization}
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /organizations [get]
+func (c *OrganizationController) GetOrganizations(ctx *gin.Context) {
+	organizations, err := c.organizationService.GetAllOrganizations()
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Organizations fetched successfully", organizations, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetOrganization godoc
+// @Summary      Get an organization
+// @Description  Retrieve an organization and its settings
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param        orgId  path      string  true  "Organization ID"
+// @Success      200    {object}  shared.ApiResponse{data=domain.Organization}
+// @Failure      404    {object}  shared.ErrorResponse
+// @Failure      500    {object}  shared.ErrorResponse
+// @Router       /organizations/{orgId} [get]
+func (c *OrganizationController) GetOrganization(ctx *gin.Context) {
+	organization, err := c.organizationService.GetOrganizationById(ctx.Param("orgId"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Organization fetched successfully", organization, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// UpdateOrganization godoc
+// @Summary      Update an organization
+// @Description  Change an organization's name and settings: default policies, currency and branding (Admin only)
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId    path      string                      true  "Organization ID"
+// @Param        request  body      domain.OrganizationRequest  true  "Organization information"
+// @Success      200      {object}  shared.ApiResponse{data=domain.Organization}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /organizations/{orgId} [put]
+func (c *OrganizationController) UpdateOrganization(ctx *gin.Context) {
+	var request domain.OrganizationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	organization, err := c.organizationService.UpdateOrganization(ctx.Param("orgId"), &request)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Organization updated successfully", organization, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetOrganizationHotels godoc
+// @Summary      Get an organization's hotels
+// @Description  List the hotels an organization currently owns
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Param        orgId  path      string  true  "Organization ID"
+// @Success      200    {object}  shared.ApiResponse{data=[]domain.Hotel}
+// @Failure      404    {object}  shared.ErrorResponse
+// @Failure      500    {object}  shared.ErrorResponse
+// @Router       /organizations/{orgId}/hotels [get]
+func (c *OrganizationController) GetOrganizationHotels(ctx *gin.Context) {
+	hotels, err := c.organizationService.GetOrganizationHotels(ctx.Param("orgId"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Hotels fetched successfully", hotels, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetReport godoc
+// @Summary      Get an organization report
+// @Description  Bookings, cancellations, no-shows and revenue per hotel for stays checking in between from and to, with totals. Bookings count for the organization that owned the hotel when they were made. Spans at most 366 days (Admin only)
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        orgId  path      string  true  "Organization ID"
+// @Param        from   query     string  true  "First check-in date (YYYY-MM-DD)"
+// @Param        to     query     string  true  "Last check-in date (YYYY-MM-DD)"
+// @Success      200    {object}  shared.ApiResponse{data=domain.OrganizationReport}
+// @Failure      400    {object}  shared.ErrorResponse
+// @Failure      401    {object}  shared.ErrorResponse
+// @Failure      403    {object}  shared.ErrorResponse
+// @Failure      404    {object}  shared.ErrorResponse
+// @Failure      500    {object}  shared.ErrorResponse
+// @Router       /organizations/{orgId}/reports [get]
+func (c *OrganizationController) GetReport(ctx *gin.Context) {
+	from, err := parseDateQuery(ctx, "from")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	to, err := parseDateQuery(ctx, "to")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	report, err := c.organizationService.GetReport(ctx.Param("orgId"), from, to)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Report fetched successfully", report, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// MoveHotel godoc
+// @Summary      Move a hotel to another organization
+// @Description  Assign a hotel to an organization, or make it independent with a null organization_id. Past bookings stay with the previous organization and the move is kept in the hotel's history (Admin only)
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "Hotel ID"
+// @Param        request  body      domain.MoveHotelRequest  true  "Target organization"
+// @Success      200      {object}  shared.ApiResponse{data=domain.HotelOrganizationChange}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/organization [put]
+func (c *OrganizationController) MoveHotel(ctx *gin.Context) {
+	var request domain.MoveHotelRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	var actorId *uuid.UUID
+	if user := currentUser(ctx); user != nil {
+		actorId = &user.Id
+	}
+	change, err := c.organizationService.MoveHotel(ctx.Param("id"), &request, actorId)
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Hotel moved successfully", change, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetHotelOrganizationHistory godoc
+// @Summary      Get a hotel's organization history
+// @Description  List every move of a hotel between organizations, oldest first (Admin only)
+// @Tags         Organizations
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.HotelOrganizationChange}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/organization/history [get]
+func (c *OrganizationController) GetHotelOrganizationHistory(ctx *gin.Context) {
+	changes, err := c.organizationService.GetHotelOrganizationHistory(ctx.Param("id"))
+	if err != nil {
+		respondOrganizationError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Organization history fetched successfully", changes, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondOrganizationError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidReportRange):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrHotelNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrOrganizationNameTaken), errors.Is(err, service.ErrHotelAlreadyInPlace):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
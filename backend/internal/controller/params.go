@@ -0,0 +1,52 @@
+package controller
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const dateQueryLayout = "2006-01-02"
+
+// parseDateQuery reads a required YYYY-MM-DD query parameter
+func parseDateQuery(ctx *gin.Context, key string) (time.Time, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("%s is required", key)
+	}
+	date, err := time.Parse(dateQueryLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be a date in YYYY-MM-DD format", key)
+	}
+	return date, nil
+}
+
+// parseOptionalUUIDQuery reads an optional UUID query parameter, returning nil when it is absent
+func parseOptionalUUIDQuery(ctx *gin.Context, key string) (*uuid.UUID, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be a valid UUID", key)
+	}
+	return &id, nil
+}
+
+// parseOptionalFloatQuery reads an optional number query parameter, returning nil when it is absent
+func parseOptionalFloatQuery(ctx *gin.Context, key string) (*float64, error) {
+	value := ctx.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return nil, fmt.Errorf("%s must be a number", key)
+	}
+	return &number, nil
+}
@@ -0,0 +1,190 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+type PhotoController struct {
+	photoService service.PhotoService
+}
+
+func NewPhotoController(photoService service.PhotoService) *PhotoController {
+	return &PhotoController{photoService: photoService}
+}
+
+// UploadHotelPhoto godoc
+// @Summary      Upload a hotel photo
+// @Description  Upload a JPEG, PNG or GIF image of up to 10 MB as multipart field "file". Thumbnails are generated and the first photo becomes the cover (Admin only)
+// @Tags         Photos
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id    path      string  true  "Hotel ID"
+// @Param        file  formData  file    true  "Image file"
+// @Success      201   {object}  shared.ApiResponse{data=domain.Photo}
+// @Failure      400   {object}  shared.ErrorResponse
+// @Failure      401   {object}  shared.ErrorResponse
+// @Failure      403   {object}  shared.ErrorResponse
+// @Failure      404   {object}  shared.ErrorResponse
+// @Failure      413   {object}  shared.ErrorResponse
+// @Failure      500   {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/photos [post]
+func (c *PhotoController) UploadHotelPhoto(ctx *gin.Context) {
+	c.upload(ctx, nil)
+}
+
+// UploadRoomPhoto godoc
+// @Summary      Upload a room photo
+// @Description  Upload a JPEG, PNG or GIF image of up to 10 MB of one of the hotel's rooms as multipart field "file" (Admin only)
+// @Tags         Photos
+// @Accept       multipart/form-data
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id      path      string  true  "Hotel ID"
+// @Param        roomId  path      string  true  "Room ID"
+// @Param        file    formData  file    true  "Image file"
+// @Success      201     {object}  shared.ApiResponse{data=domain.Photo}
+// @Failure      400     {object}  shared.ErrorResponse
+// @Failure      401     {object}  shared.ErrorResponse
+// @Failure      403     {object}  shared.ErrorResponse
+// @Failure      413     {object}  shared.ErrorResponse
+// @Failure      500     {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/rooms/{roomId}/photos [post]
+func (c *PhotoController) UploadRoomPhoto(ctx *gin.Context) {
+	roomId, err := uuid.Parse(ctx.Param("roomId"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse("roomId must be a valid UUID", ctx.Request.URL.Path))
+		return
+	}
+	c.upload(ctx, &roomId)
+}
+
+func (c *PhotoController) upload(ctx *gin.Context, roomId *uuid.UUID) {
+	header, err := ctx.FormFile("file")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse("file is required", ctx.Request.URL.Path))
+		return
+	}
+	if header.Size > service.MaxPhotoBytes {
+		ctx.JSON(http.StatusRequestEntityTooLarge, shared.NewErrorResponse(service.ErrImageTooLarge.Error(), ctx.Request.URL.Path, http.StatusRequestEntityTooLarge))
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, service.MaxPhotoBytes+1))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	photo, err := c.photoService.UploadPhoto(ctx.Param("id"), roomId, data)
+	if err != nil {
+		respondPhotoError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Photo uploaded successfully", photo, ctx.Request.URL.Path))
+}
+
+// ReorderPhotos godoc
+// @Summary      Reorder photos
+// @Description  Set the display order of the hotel's own photos, or of one room's photos when room_id is given. Every photo must be listed once (Admin only)
+// @Tags         Photos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                       true  "Hotel ID"
+// @Param        request  body      domain.ReorderPhotosRequest  true  "Photo order"
+// @Success      200      {object}  shared.ApiResponse{data=[]domain.Photo}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/photos/order [put]
+func (c *PhotoController) ReorderPhotos(ctx *gin.Context) {
+	var request domain.ReorderPhotosRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	photos, err := c.photoService.ReorderPhotos(ctx.Param("id"), &request)
+	if err != nil {
+		respondPhotoError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Photos reordered successfully", photos, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// SetCoverPhoto godoc
+// @Summary      Set the cover photo
+// @Description  Make a photo the cover of its hotel or room (Admin only)
+// @Tags         Photos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Hotel ID"
+// @Param        photoId  path      string  true  "Photo ID"
+// @Success      200      {object}  shared.ApiResponse{data=domain.Photo}
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/photos/{photoId}/cover [put]
+func (c *PhotoController) SetCoverPhoto(ctx *gin.Context) {
+	photo, err := c.photoService.SetCoverPhoto(ctx.Param("id"), ctx.Param("photoId"))
+	if err != nil {
+		respondPhotoError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Cover photo updated successfully", photo, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// DeletePhoto godoc
+// @Summary      Delete a photo
+// @Description  Delete a photo and its thumbnails (Admin only)
+// @Tags         Photos
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true  "Hotel ID"
+// @Param        photoId  path      string  true  "Photo ID"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/photos/{photoId} [delete]
+func (c *PhotoController) DeletePhoto(ctx *gin.Context) {
+	if err := c.photoService.DeletePhoto(ctx.Param("id"), ctx.Param("photoId")); err != nil {
+		respondPhotoError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Photo deleted successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// respondPhotoError maps photo service errors to HTTP responses
+func respondPhotoError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidImage), errors.Is(err, service.ErrPhotoOrderMismatch):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrImageTooLarge):
+		ctx.JSON(http.StatusRequestEntityTooLarge, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusRequestEntityTooLarge))
+	case errors.Is(err, service.ErrPhotoNotFound), errors.Is(err, service.ErrHotelNotFound), errors.Is(err, service.ErrRoomNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
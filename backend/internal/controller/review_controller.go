@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewController struct {
+	reviewService service.ReviewService
+}
+
+func NewReviewController(reviewService service.ReviewService) *ReviewController {
+	return &ReviewController{reviewService: reviewService}
+}
+
+// CreateReview godoc
+// @Summary      Review a hotel
+// @Description  Rate cleanliness, location and service for a checked-out stay at the hotel. Each stay can be reviewed once (Requires authentication)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                      true  "Hotel ID"
+// @Param        request  body      domain.CreateReviewRequest  true  "Review"
+// @Success      201      {object}  shared.ApiResponse{data=domain.Review}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/reviews [post]
+func (c *ReviewController) CreateReview(ctx *gin.Context) {
+	var request domain.CreateReviewRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	review, err := c.reviewService.CreateReview(ctx.Param("id"), currentUser(ctx), &request)
+	if err != nil {
+		respondReviewError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Review created successfully", review, ctx.Request.URL.Path))
+}
+
+// GetReviews godoc
+// @Summary      Get hotel reviews
+// @Description  List a hotel's published reviews, newest first
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.Review}
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/reviews [get]
+func (c *ReviewController) GetReviews(ctx *gin.Context) {
+	reviews, err := c.reviewService.GetReviewsByHotelId(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Reviews fetched successfully", reviews, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ReplyToReview godoc
+// @Summary      Reply to a review
+// @Description  Publish the hotel management's reply under a review (Admin only)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id        path      string                     true  "Hotel ID"
+// @Param        reviewId  path      string                     true  "Review ID"
+// @Param        request   body      domain.ReviewReplyRequest  true  "Reply"
+// @Success      200       {object}  shared.ApiResponse{data=domain.Review}
+// @Failure      400       {object}  shared.ErrorResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/reviews/{reviewId}/reply [put]
+func (c *ReviewController) ReplyToReview(ctx *gin.Context) {
+	var request domain.ReviewReplyRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	review, err := c.reviewService.ReplyToReview(ctx.Param("id"), ctx.Param("reviewId"), request.Reply)
+	if err != nil {
+		respondReviewError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Reply saved successfully", review, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ReportReview godoc
+// @Summary      Report a review
+// @Description  Report a published review as abusive or misleading; it returns to the moderation queue (Requires authentication)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        reviewId  path      string                      true  "Review ID"
+// @Param        request   body      domain.ReportReviewRequest  true  "Report"
+// @Success      201       {object}  shared.ApiResponse
+// @Failure      400       {object}  shared.ErrorResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      409       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /reviews/{reviewId}/reports [post]
+func (c *ReviewController) ReportReview(ctx *gin.Context) {
+	var request domain.ReportReviewRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.reviewService.ReportReview(ctx.Param("reviewId"), currentUser(ctx), request.Reason); err != nil {
+		respondReviewError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Review reported successfully", nil, ctx.Request.URL.Path))
+}
+
+// GetReports godoc
+// @Summary      Get review reports
+// @Description  List the reports users have filed against a review (Admin only)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        reviewId  path      string  true  "Review ID"
+// @Success      200       {object}  shared.ApiResponse{data=[]domain.ReviewReport}
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /reviews/{reviewId}/reports [get]
+func (c *ReviewController) GetReports(ctx *gin.Context) {
+	reports, err := c.reviewService.GetReports(ctx.Param("reviewId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Reports fetched successfully", reports, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetModerationQueue godoc
+// @Summary      Get the review moderation queue
+// @Description  List reviews awaiting a decision and published reviews with open reports. Reviews flagged by automatic screening come first (Admin only)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.Review}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /reviews/moderation [get]
+func (c *ReviewController) GetModerationQueue(ctx *gin.Context) {
+	reviews, err := c.reviewService.GetModerationQueue()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Moderation queue fetched successfully", reviews, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ModerateReview godoc
+// @Summary      Moderate a review
+// @Description  Approve, reject or hide a review. Rejecting or hiding requires a reason, which is recorded in the audit trail (Admin only)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        reviewId  path      string                        true  "Review ID"
+// @Param        request   body      domain.ModerateReviewRequest  true  "Moderation decision"
+// @Success      200       {object}  shared.ApiResponse{data=domain.Review}
+// @Failure      400       {object}  shared.ErrorResponse
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      404       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /reviews/{reviewId}/moderation [put]
+func (c *ReviewController) ModerateReview(ctx *gin.Context) {
+	var request domain.ModerateReviewRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	review, err := c.reviewService.ModerateReview(ctx.Param("reviewId"), currentUser(ctx), &request)
+	if err != nil {
+		respondReviewError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Review moderated successfully", review, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetModerationHistory godoc
+// @Summary      Get a review's moderation history
+// @Description  List the moderation decisions taken on a review, with the moderator and reason (Admin only)
+// @Tags         Reviews
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        reviewId  path      string  true  "Review ID"
+// @Success      200       {object}  shared.ApiResponse{data=[]domain.AuditEntry}
+// @Failure      401       {object}  shared.ErrorResponse
+// @Failure      403       {object}  shared.ErrorResponse
+// @Failure      500       {object}  shared.ErrorResponse
+// @Router       /reviews/{reviewId}/moderation [get]
+func (c *ReviewController) GetModerationHistory(ctx *gin.Context) {
+	entries, err := c.reviewService.GetModerationHistory(ctx.Param("reviewId"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Moderation history fetched successfully", entries, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// respondReviewError maps review service errors to HTTP responses
+func respondReviewError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrModerationReasonRequired):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrReviewNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrReviewNotAllowed):
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrAlreadyReviewed), errors.Is(err, service.ErrAlreadyReported):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleController struct {
+	roleService service.RoleService
+}
+
+func NewRoleController(roleService service.RoleService) *RoleController {
+	return &RoleController{roleService: roleService}
+}
+
+// GetRoles godoc
+// @Summary      Get roles
+// @Description  List the roles that can be granted and the permissions each carries
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.RoleDefinition}
+// @Router       /roles [get]
+func (c *RoleController) GetRoles(ctx *gin.Context) {
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Roles fetched successfully", c.roleService.GetRoleDefinitions(), http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetUserRoles godoc
+// @Summary      Get a user's roles
+// @Description  List a user's staff roles and whether they are a super admin. Users may see their own roles; super admins anyone's
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "User ID"
+// @Success      200  {object}  shared.ApiResponse{data=domain.UserRoles}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /users/{id}/roles [get]
+func (c *RoleController) GetUserRoles(ctx *gin.Context) {
+	roles, err := c.roleService.GetUserRoles(currentUser(ctx), ctx.Param("id"))
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Roles fetched successfully", roles, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GrantRole godoc
+// @Summary      Grant a role
+// @Description  Give a user a role at a hotel or across an organization, or make them a super admin. Super admins may grant any role; users who manage roles at a hotel may add managers and front desk staff there
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                   true  "User ID"
+// @Param        request  body      domain.RoleGrantRequest  true  "Role and scope"
+// @Success      200      {object}  shared.ApiResponse{data=domain.UserRoles}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /users/{id}/roles [post]
+func (c *RoleController) GrantRole(ctx *gin.Context) {
+	var request domain.RoleGrantRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	roles, err := c.roleService.GrantRole(currentUser(ctx), ctx.Param("id"), &request)
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Role granted successfully", roles, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// RevokeRole godoc
+// @Summary      Revoke a role
+// @Description  Remove a role with exactly the given scope from a user, under the same rules as granting it
+// @Tags         Roles
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id               path      string  true   "User ID"
+// @Param        role             query     string  true   "Role"
+// @Param        hotel_id         query     string  false  "Hotel the role applies to"
+// @Param        organization_id  query     string  false  "Organization the role applies to"
+// @Success      200              {object}  shared.ApiResponse{data=domain.UserRoles}
+// @Failure      400              {object}  shared.ErrorResponse
+// @Failure      401              {object}  shared.ErrorResponse
+// @Failure      403              {object}  shared.ErrorResponse
+// @Failure      404              {object}  shared.ErrorResponse
+// @Failure      500              {object}  shared.ErrorResponse
+// @Router       /users/{id}/roles [delete]
+func (c *RoleController) RevokeRole(ctx *gin.Context) {
+	request := domain.RoleGrantRequest{Role: domain.Role(ctx.Query("role"))}
+	var err error
+	if request.HotelId, err = parseOptionalUUIDQuery(ctx, "hotel_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	if request.OrganizationId, err = parseOptionalUUIDQuery(ctx, "organization_id"); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	roles, err := c.roleService.RevokeRole(currentUser(ctx), ctx.Param("id"), &request)
+	if err != nil {
+		respondRoleError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Role revoked successfully", roles, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondRoleError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrPermissionDenied):
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrHotelNotFound),
+		errors.Is(err, service.ErrOrganizationNotFound), errors.Is(err, service.ErrRoleAssignmentNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrRoleAlreadyGranted):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"backend/internal/service"
+	"backend/internal/shared"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchController struct {
+	searchService service.SearchService
+	hotelService  service.HotelService
+}
+
+func NewSearchController(searchService service.SearchService, hotelService service.HotelService) *SearchController {
+	return &SearchController{searchService: searchService, hotelService: hotelService}
+}
+
+// SearchHotels godoc
+// @Summary      Search hotels with facet counts
+// @Description  Search hotels with the same filters as GET /hotels and return, alongside the results, how many matching hotels offer each facility, fall in each rating band and in each price bucket of their cheapest room. Facility counts apply every filter; rating and price counts ignore their own filter so other bands stay selectable
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        q          query     string    false  "Text to search for"
+// @Param        lat        query     number    false  "Latitude to measure distance from"
+// @Param        lng        query     number    false  "Longitude to measure distance from"
+// @Param        radius_km  query     number    false  "Only hotels within this many km of lat/lng (max 1000)"
+// @Param        min_lat    query     number    false  "Bounding box south edge"
+// @Param        min_lng    query     number    false  "Bounding box west edge"
+// @Param        max_lat    query     number    false  "Bounding box north edge"
+// @Param        max_lng    query     number    false  "Bounding box east edge"
+// @Param        city       query     string    false  "City"
+// @Param        country    query     string    false  "Country"
+// @Param        organization_id  query  string    false  "Only hotels of this organization"
+// @Param        facility   query     []string  false  "Facility every hotel must offer; repeat for several"  collectionFormat(multi)
+// @Param        min_rating query     number    false  "Minimum rating"
+// @Param        min_price  query     number    false  "Minimum price of the cheapest room"
+// @Param        max_price  query     number    false  "Cheapest room costs less than this"
+// @Success      200  {object}  shared.ApiResponse{data=domain.HotelSearchResult}
+// @Failure      400  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /search/hotels [get]
+func (c *SearchController) SearchHotels(ctx *gin.Context) {
+	search, err := parseHotelSearch(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	result, err := c.hotelService.SearchHotelsWithFacets(search)
+	if err != nil {
+		respondHotelSearchError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Hotels fetched successfully", result, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// Suggest godoc
+// @Summary      Autocomplete the search box
+// @Description  Suggest cities and hotel names whose text, or a word of it, starts with q. Cities come first
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Param        q    query     string  true  "What the user has typed so far"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.SearchSuggestion}
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /search/suggest [get]
+func (c *SearchController) Suggest(ctx *gin.Context) {
+	suggestions, err := c.searchService.Suggest(ctx.Query("q"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Suggestions fetched successfully", suggestions, http.StatusOK, ctx.Request.URL.Path))
+}
@@ -0,0 +1,128 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StayRestrictionController struct {
+	stayRestrictionService service.StayRestrictionService
+}
+
+func NewStayRestrictionController(stayRestrictionService service.StayRestrictionService) *StayRestrictionController {
+	return &StayRestrictionController{stayRestrictionService: stayRestrictionService}
+}
+
+// SetRestrictions godoc
+// @Summary      Set stay restrictions over a date range
+// @Description  Apply min/max stay, closed-to-arrival/departure and advance booking rules to every date in the range, for the whole hotel or one room (Admin only)
+// @Tags         Stay Restrictions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                             true  "Hotel ID"
+// @Param        request  body      domain.SetStayRestrictionsRequest  true  "Restrictions to apply"
+// @Success      200      {object}  shared.ApiResponse{data=[]domain.StayRestriction}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/restrictions [put]
+func (c *StayRestrictionController) SetRestrictions(ctx *gin.Context) {
+	var request domain.SetStayRestrictionsRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	restrictions, err := c.stayRestrictionService.SetRestrictions(ctx.Param("id"), &request)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Stay restrictions updated successfully", restrictions, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetRestrictions godoc
+// @Summary      Get stay restrictions
+// @Description  List the stay restrictions of a hotel between two dates, optionally for one room
+// @Tags         Stay Restrictions
+// @Accept       json
+// @Produce      json
+// @Param        id       path      string  true   "Hotel ID"
+// @Param        from     query     string  true   "First date (YYYY-MM-DD)"
+// @Param        to       query     string  true   "Last date (YYYY-MM-DD)"
+// @Param        room_id  query     string  false  "Room ID"
+// @Success      200      {object}  shared.ApiResponse{data=[]domain.StayRestriction}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/restrictions [get]
+func (c *StayRestrictionController) GetRestrictions(ctx *gin.Context) {
+	from, err := parseDateQuery(ctx, "from")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	to, err := parseDateQuery(ctx, "to")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	roomId, err := parseOptionalUUIDQuery(ctx, "room_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	restrictions, err := c.stayRestrictionService.GetRestrictions(ctx.Param("id"), roomId, from, to)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Stay restrictions fetched successfully", restrictions, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ClearRestrictions godoc
+// @Summary      Clear stay restrictions
+// @Description  Remove the hotel-wide restrictions, or one room's when room_id is given, between two dates (Admin only)
+// @Tags         Stay Restrictions
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string  true   "Hotel ID"
+// @Param        from     query     string  true   "First date (YYYY-MM-DD)"
+// @Param        to       query     string  true   "Last date (YYYY-MM-DD)"
+// @Param        room_id  query     string  false  "Room ID"
+// @Success      200      {object}  shared.ApiResponse
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/restrictions [delete]
+func (c *StayRestrictionController) ClearRestrictions(ctx *gin.Context) {
+	from, err := parseDateQuery(ctx, "from")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	to, err := parseDateQuery(ctx, "to")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	roomId, err := parseOptionalUUIDQuery(ctx, "room_id")
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	if err := c.stayRestrictionService.ClearRestrictions(ctx.Param("id"), roomId, from, to); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Stay restrictions cleared successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
@@ -0,0 +1,168 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TeamController struct {
+	teamService service.TeamService
+}
+
+func NewTeamController(teamService service.TeamService) *TeamController {
+	return &TeamController{teamService: teamService}
+}
+
+// InviteStaff godoc
+// @Summary      Invite a staff member
+// @Description  Email an invitation to a staff role at the hotel. The link in the email is the only copy of the invitation token
+// @Tags         Team
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id       path      string                    true  "Hotel ID"
+// @Param        request  body      domain.InvitationRequest  true  "Email address and role"
+// @Success      201      {object}  shared.ApiResponse{data=domain.StaffInvitation}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      403      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/invitations [post]
+func (c *TeamController) InviteStaff(ctx *gin.Context) {
+	var request domain.InvitationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	invitation, err := c.teamService.InviteStaff(currentUser(ctx), ctx.Param("id"), &request)
+	if err != nil {
+		respondTeamError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Invitation sent successfully", invitation, ctx.Request.URL.Path))
+}
+
+// GetInvitations godoc
+// @Summary      Get a hotel's invitations
+// @Description  List every invitation sent for the hotel with its status, newest first
+// @Tags         Team
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.StaffInvitation}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/invitations [get]
+func (c *TeamController) GetInvitations(ctx *gin.Context) {
+	invitations, err := c.teamService.GetInvitations(ctx.Param("id"))
+	if err != nil {
+		respondTeamError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Invitations fetched successfully", invitations, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// RevokeInvitation godoc
+// @Summary      Revoke an invitation
+// @Description  Revoke an invitation that has not been accepted, so its token no longer works
+// @Tags         Team
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id            path      string  true  "Hotel ID"
+// @Param        invitationId  path      string  true  "Invitation ID"
+// @Success      200           {object}  shared.ApiResponse{data=domain.StaffInvitation}
+// @Failure      401           {object}  shared.ErrorResponse
+// @Failure      403           {object}  shared.ErrorResponse
+// @Failure      404           {object}  shared.ErrorResponse
+// @Failure      409           {object}  shared.ErrorResponse
+// @Failure      500           {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/invitations/{invitationId} [delete]
+func (c *TeamController) RevokeInvitation(ctx *gin.Context) {
+	invitation, err := c.teamService.RevokeInvitation(currentUser(ctx), ctx.Param("id"), ctx.Param("invitationId"))
+	if err != nil {
+		respondTeamError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Invitation revoked successfully", invitation, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// AcceptInvitation godoc
+// @Summary      Accept an invitation
+// @Description  Join a hotel's team with an invitation token. An account is created for the invited email address when none exists, which needs a username and password
+// @Tags         Team
+// @Accept       json
+// @Produce      json
+// @Param        request  body      domain.AcceptInvitationRequest  true  "Invitation token and new account details"
+// @Success      200      {object}  shared.ApiResponse{data=domain.StaffInvitation}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      404      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      410      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /invitations/accept [post]
+func (c *TeamController) AcceptInvitation(ctx *gin.Context) {
+	var request domain.AcceptInvitationRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	invitation, err := c.teamService.AcceptInvitation(&request)
+	if err != nil {
+		respondTeamError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Invitation accepted successfully", invitation, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// GetTeam godoc
+// @Summary      Get a hotel's team
+// @Description  List the users holding a staff role at the hotel, including organization-wide roles that cover it
+// @Tags         Team
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Hotel ID"
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.TeamMember}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      403  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /hotels/{id}/team [get]
+func (c *TeamController) GetTeam(ctx *gin.Context) {
+	members, err := c.teamService.GetTeam(ctx.Param("id"))
+	if err != nil {
+		respondTeamError(ctx, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Team fetched successfully", members, http.StatusOK, ctx.Request.URL.Path))
+}
+
+func respondTeamError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole), errors.Is(err, service.ErrAccountDetailsMissing):
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrPermissionDenied):
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrHotelNotFound), errors.Is(err, service.ErrInvitationNotFound):
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrInvitationClosed), errors.Is(err, service.ErrEmailAlreadyOnTeam):
+		ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+	case errors.Is(err, service.ErrInvitationExpired):
+		ctx.JSON(http.StatusGone, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusGone))
+	default:
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+}
@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"backend/internal/domain"
+	"backend/internal/service"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WaitlistController struct {
+	waitlistService service.WaitlistService
+	bookingService  service.BookingService
+}
+
+func NewWaitlistController(waitlistService service.WaitlistService, bookingService service.BookingService) *WaitlistController {
+	return &WaitlistController{waitlistService: waitlistService, bookingService: bookingService}
+}
+
+// JoinWaitlist godoc
+// @Summary      Join the waitlist for a fully booked room
+// @Description  Queue the current user for a room that is taken over the requested dates (Requires authentication)
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        request  body      domain.JoinWaitlistRequest  true  "Room and dates"
+// @Success      201      {object}  shared.ApiResponse{data=domain.WaitlistEntry}
+// @Failure      400      {object}  shared.ErrorResponse
+// @Failure      401      {object}  shared.ErrorResponse
+// @Failure      409      {object}  shared.ErrorResponse
+// @Failure      500      {object}  shared.ErrorResponse
+// @Router       /waitlist [post]
+func (c *WaitlistController) JoinWaitlist(ctx *gin.Context) {
+	var request domain.JoinWaitlistRequest
+	if err := ctx.ShouldBindJSON(&request); err != nil {
+		ctx.JSON(http.StatusBadRequest, shared.NewBadRequestResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+
+	user := currentUser(ctx)
+	entry, err := c.waitlistService.JoinWaitlist(user.Id, &request)
+	if err != nil {
+		if errors.Is(err, service.ErrRoomStillAvailable) || errors.Is(err, service.ErrAlreadyOnWaitlist) {
+			ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+			return
+		}
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Joined waitlist successfully", entry, ctx.Request.URL.Path))
+}
+
+// GetMyWaitlist godoc
+// @Summary      Get my waitlist entries
+// @Description  List the current user's waitlist entries. Open offers are claimed with the link in the offer email (Requires authentication)
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Success      200  {object}  shared.ApiResponse{data=[]domain.WaitlistEntry}
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /waitlist/me [get]
+func (c *WaitlistController) GetMyWaitlist(ctx *gin.Context) {
+	user := currentUser(ctx)
+	entries, err := c.waitlistService.GetEntriesByUserId(user.Id.String())
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Waitlist fetched successfully", entries, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// LeaveWaitlist godoc
+// @Summary      Leave the waitlist
+// @Description  Remove a waitlist entry or decline its open offer (Requires authentication)
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        id   path      string  true  "Waitlist entry ID"
+// @Success      200  {object}  shared.ApiResponse
+// @Failure      401  {object}  shared.ErrorResponse
+// @Failure      404  {object}  shared.ErrorResponse
+// @Failure      409  {object}  shared.ErrorResponse
+// @Failure      500  {object}  shared.ErrorResponse
+// @Router       /waitlist/{id} [delete]
+func (c *WaitlistController) LeaveWaitlist(ctx *gin.Context) {
+	user := currentUser(ctx)
+	err := c.waitlistService.LeaveWaitlist(ctx.Param("id"), user.Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrWaitlistEntryNotFound):
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.Is(err, service.ErrWaitlistEntryClosed):
+			ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
+		return
+	}
+	ctx.JSON(http.StatusOK, shared.NewSuccessResponse("Left waitlist successfully", nil, http.StatusOK, ctx.Request.URL.Path))
+}
+
+// ClaimOffer godoc
+// @Summary      Claim a waitlist offer
+// @Description  Book the room held by an open waitlist offer before it expires (Requires authentication)
+// @Tags         Waitlist
+// @Accept       json
+// @Produce      json
+// @Security     BearerAuth
+// @Param        token  path      string  true  "Offer token from the claim link"
+// @Success      201    {object}  shared.ApiResponse{data=domain.Booking}
+// @Failure      401    {object}  shared.ErrorResponse
+// @Failure      403    {object}  shared.ErrorResponse
+// @Failure      404    {object}  shared.ErrorResponse
+// @Failure      409    {object}  shared.ErrorResponse
+// @Failure      410    {object}  shared.ErrorResponse
+// @Failure      500    {object}  shared.ErrorResponse
+// @Router       /waitlist/claim/{token} [post]
+func (c *WaitlistController) ClaimOffer(ctx *gin.Context) {
+	booking, err := c.bookingService.ClaimWaitlistOffer(ctx.Param("token"), currentUser(ctx).Id)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrOfferNotFound):
+			ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.Is(err, service.ErrEmailNotVerified):
+			ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse(err.Error(), ctx.Request.URL.Path))
+		case errors.Is(err, service.ErrOfferExpired):
+			ctx.JSON(http.StatusGone, shared.NewErrorResponse(err.Error(), ctx.Request.URL.Path, http.StatusGone))
+		case errors.Is(err, service.ErrWaitlistEntryClosed), errors.Is(err, service.ErrRoomUnavailable):
+			ctx.JSON(http.StatusConflict, shared.NewConflictResponse(err.Error(), ctx.Request.URL.Path))
+		default:
+			ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		}
+		return
+	}
+	ctx.JSON(http.StatusCreated, shared.NewCreatedResponse("Waitlist offer claimed successfully", booking, ctx.Request.URL.Path))
+}
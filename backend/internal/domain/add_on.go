@@ -0,0 +1,71 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AddOnPricing string
+
+const (
+	AddOnPricingPerStay  AddOnPricing = "per_stay"
+	AddOnPricingPerNight AddOnPricing = "per_night"
+	AddOnPricingPerGuest AddOnPricing = "per_guest"
+)
+
+// AddOn is an extra a hotel sells on top of the room, such as breakfast or parking
+type AddOn struct {
+	Id          uuid.UUID    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	HotelId     uuid.UUID    `gorm:"type:uuid;index" json:"hotel_id"`
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Price       float64      `json:"price"`
+	Pricing     AddOnPricing `json:"pricing"`
+	Active      bool         `gorm:"default:true" json:"active"`
+}
+
+type AddOnRequest struct {
+	Name        string       `json:"name" binding:"required" example:"Breakfast"`
+	Description string       `json:"description" example:"Continental breakfast buffet"`
+	Price       float64      `json:"price" binding:"gte=0" example:"15"`
+	Pricing     AddOnPricing `json:"pricing" binding:"required,oneof=per_stay per_night per_guest" example:"per_guest"`
+	Active      *bool        `json:"active"`
+}
+
+// AddOnSelection picks a catalogue add-on for a booking
+type AddOnSelection struct {
+	AddOnId  uuid.UUID `json:"add_on_id" binding:"required"`
+	Quantity int       `json:"quantity" example:"1"`
+}
+
+// BookingAddOn is an add-on attached to a booking. Name, pricing and unit price are
+// copied from the catalogue so later price changes do not alter existing bookings.
+type BookingAddOn struct {
+	Id         uuid.UUID    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	BookingId  uuid.UUID    `gorm:"type:uuid;index" json:"booking_id"`
+	AddOnId    uuid.UUID    `gorm:"type:uuid" json:"add_on_id"`
+	Name       string       `json:"name"`
+	Pricing    AddOnPricing `json:"pricing"`
+	UnitPrice  float64      `json:"unit_price"`
+	Quantity   int          `json:"quantity"`
+	TotalPrice float64      `json:"total_price"`
+	CreatedAt  time.Time    `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type InvoiceLine struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+	Amount      float64 `json:"amount"`
+}
+
+// Invoice itemises a booking: the room stay followed by each add-on
+type Invoice struct {
+	BookingId uuid.UUID     `json:"booking_id"`
+	HotelId   uuid.UUID     `json:"hotel_id"`
+	UserId    uuid.UUID     `json:"user_id"`
+	Lines     []InvoiceLine `json:"lines"`
+	Total     float64       `json:"total"`
+	IssuedAt  time.Time     `json:"issued_at"`
+}
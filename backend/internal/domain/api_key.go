@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyScope string
+
+const (
+	APIKeyScopeSearch        APIKeyScope = "search"
+	APIKeyScopeBookingsRead  APIKeyScope = "bookings:read"
+	APIKeyScopeBookingsWrite APIKeyScope = "bookings:write"
+)
+
+// APIKeyScopes lists every scope a key can be given
+var APIKeyScopes = []APIKeyScope{APIKeyScopeSearch, APIKeyScopeBookingsRead, APIKeyScopeBookingsWrite}
+
+// APIKey lets a partner's systems act as a user without their password, within the key's scopes.
+// Only a hash of the key is stored; the prefix identifies it in listings.
+type APIKey struct {
+	Id      uuid.UUID     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name    string        `json:"name" example:"Sunny Travel booking engine"`
+	UserId  uuid.UUID     `gorm:"type:uuid;index" json:"user_id"`
+	Prefix  string        `json:"prefix" example:"hbk_3f9a1c"`
+	KeyHash string        `gorm:"uniqueIndex" json:"-"`
+	Scopes  []APIKeyScope `gorm:"serializer:json" json:"scopes" example:"search,bookings:write"`
+	// RateLimit is how many requests the key may make per minute, 0 for no limit
+	RateLimit int `json:"rate_limit" example:"120"`
+	// RateWindow is the minute, counted from the Unix epoch, that RateCount counts requests in
+	RateWindow int64      `json:"-"`
+	RateCount  int        `json:"-"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedBy  uuid.UUID  `gorm:"type:uuid" json:"created_by"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// HasScope reports whether the key may be used for the scope
+func (k *APIKey) HasScope(scope APIKeyScope) bool {
+	for _, s := range k.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// APIKeyRequest creates an API key for a user
+type APIKeyRequest struct {
+	Name      string        `json:"name" binding:"required" example:"Sunny Travel booking engine"`
+	UserId    uuid.UUID     `json:"user_id" binding:"required"`
+	Scopes    []APIKeyScope `json:"scopes" binding:"required,min=1" example:"search,bookings:write"`
+	RateLimit int           `json:"rate_limit" binding:"min=0" example:"120"`
+	ExpiresAt *time.Time    `json:"expires_at"`
+}
+
+// APIKeyCreatedResponse holds a new key. The key itself is only ever shown here.
+type APIKeyCreatedResponse struct {
+	APIKey
+	Key string `json:"key" example:"hbk_3f9a1c0d5e7b2a4c6e8f0a1b3c5d7e9f"`
+}
@@ -0,0 +1,18 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AuditEntry records an action taken on an entity. ActorId is nil for actions taken by background jobs.
+type AuditEntry struct {
+	Id         uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ActorId    *uuid.UUID `gorm:"type:uuid" json:"actor_id"`
+	Action     string     `gorm:"index" json:"action"`
+	EntityType string     `gorm:"index:idx_audit_entity" json:"entity_type"`
+	EntityId   uuid.UUID  `gorm:"type:uuid;index:idx_audit_entity" json:"entity_id"`
+	Details    string     `json:"details"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
@@ -1,9 +1,17 @@
 package domain
 
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
 // LoginRequest represents user login credentials
 type LoginRequest struct {
 	Email    string `json:"email" binding:"required" example:"user@example.com"`
 	Password string `json:"password" binding:"required" example:"password123"`
+	// Device names the client signing in; the User-Agent header is used when it is empty
+	Device string `json:"device" example:"Front desk iPad"`
 }
 
 // RegisterRequest represents user registration data
@@ -13,13 +21,101 @@ type RegisterRequest struct {
 	Password string `json:"password" binding:"required" example:"password123"`
 }
 
-// LoginResponse represents the response after successful login
+// LoginResponse represents the response after successful login. When the account uses two-factor
+// authentication no tokens are issued yet: MFARequired is set and MFAToken is exchanged for them at
+// POST /auth/mfa/verify along with a code.
 type LoginResponse struct {
-	AccessToken  string `json:"access_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
-	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	AccessToken  string `json:"access_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	RefreshToken string `json:"refresh_token,omitempty" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
+	// MFAEnrollmentRequired tells an admin who has not set up two-factor authentication that admin
+	// rights are withheld until they do
+	MFAEnrollmentRequired bool `json:"mfa_enrollment_required,omitempty"`
 }
 
 // RefreshTokenRequest represents a refresh token request
 type RefreshTokenRequest struct {
 	RefreshToken string `json:"refresh_token" binding:"required" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9..."`
 }
+
+// ForgotPasswordRequest asks for a password reset link to be emailed
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email" example:"user@example.com"`
+}
+
+// ResetPasswordRequest sets a new password using the token from a reset email
+type ResetPasswordRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required" example:"newpassword123"`
+}
+
+// LogoutAllResponse reports how many signed-in sessions were ended
+type LogoutAllResponse struct {
+	RevokedSessions int64 `json:"revoked_sessions" example:"3"`
+}
+
+// RefreshToken is the server-side record of an issued refresh token. Its id is the token's jti
+// and only a hash of the token itself is kept. Every refresh rotates the token: the old one is marked
+// rotated and its replacement joins the same family, which stands for one signed-in session.
+type RefreshToken struct {
+	Id           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	FamilyId     uuid.UUID  `gorm:"type:uuid;index" json:"family_id"`
+	UserId       uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	TokenHash    string     `gorm:"uniqueIndex" json:"-"`
+	Device       string     `json:"device"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RotatedAt    *time.Time `json:"rotated_at,omitempty"`
+	ReplacedById *uuid.UUID `gorm:"type:uuid" json:"replaced_by_id,omitempty"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	// MFAVerified records that the session was started with a second factor
+	MFAVerified bool      `json:"mfa_verified"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// JSONWebKey is the public half of a token signing key, as published in the JWKS (RFC 7517)
+type JSONWebKey struct {
+	KeyType   string `json:"kty" example:"RSA"`
+	KeyId     string `json:"kid" example:"2026-10"`
+	Use       string `json:"use" example:"sig"`
+	Algorithm string `json:"alg" example:"RS256"`
+	Modulus   string `json:"n,omitempty"`
+	Exponent  string `json:"e,omitempty" example:"AQAB"`
+	Curve     string `json:"crv,omitempty" example:"Ed25519"`
+	X         string `json:"x,omitempty"`
+}
+
+// JSONWebKeySet lists the keys that tokens issued by this service may be signed with
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// PasswordResetToken lets the holder of a reset email set a new password once before it expires.
+// Only a hash of the token is kept.
+type PasswordResetToken struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// EmailVerificationToken confirms a user's email address when the link sent to it is followed
+type EmailVerificationToken struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// LoginThrottle counts recent failed logins for an account or a client IP. Its id is the email
+// address or IP it counts for, prefixed with "account:" or "ip:".
+type LoginThrottle struct {
+	Id            string     `gorm:"primaryKey" json:"id"`
+	Failures      int        `json:"failures"`
+	LastFailureAt time.Time  `json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+}
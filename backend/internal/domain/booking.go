@@ -6,32 +6,78 @@ import (
 	"github.com/google/uuid"
 )
 
+type BookingStatus string
+
+const (
+	BookingStatusConfirmed  BookingStatus = "confirmed"
+	BookingStatusCheckedIn  BookingStatus = "checked_in"
+	BookingStatusCheckedOut BookingStatus = "checked_out"
+	BookingStatusNoShow     BookingStatus = "no_show"
+)
+
+// Booking is a room reservation. OrganizationId is the hotel's organization when the booking was made,
+// kept so reports stay with that organization if the hotel moves.
 type Booking struct {
-	Id           uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id" binding:"required"`
-	UserId       uuid.UUID `gorm:"type:uuid" json:"user_id" binding:"required"`
-	HotelId      uuid.UUID `gorm:"type:uuid" json:"hotel_id" binding:"required"`
-	RoomId       uuid.UUID `gorm:"type:uuid" json:"room_id" binding:"required"`
-	CheckInDate  time.Time `json:"check_in_date" binding:"required"`
-	CheckOutDate time.Time `json:"check_out_date" binding:"required"`
-	TotalPrice   float64   `json:"total_price" binding:"required"`
-	IsCancelled  bool      `gorm:"default:false" json:"is_cancelled" binding:"required"`
+	Id             uuid.UUID       `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id" binding:"required"`
+	UserId         uuid.UUID       `gorm:"type:uuid" json:"user_id" binding:"required"`
+	HotelId        uuid.UUID       `gorm:"type:uuid" json:"hotel_id" binding:"required"`
+	OrganizationId *uuid.UUID      `gorm:"type:uuid;index" json:"organization_id,omitempty"`
+	RoomId         uuid.UUID       `gorm:"type:uuid" json:"room_id" binding:"required"`
+	CheckInDate    time.Time       `json:"check_in_date" binding:"required"`
+	CheckOutDate   time.Time       `json:"check_out_date" binding:"required"`
+	Guests         int             `gorm:"default:1" json:"guests"`
+	TotalPrice     float64         `json:"total_price" binding:"required"`
+	IsCancelled    bool            `gorm:"default:false" json:"is_cancelled" binding:"required"`
+	Status         BookingStatus   `gorm:"default:confirmed;index" json:"status"`
+	Guest          GuestDetails    `gorm:"embedded;embeddedPrefix:guest_" json:"guest"`
+	AddOns         []*BookingAddOn `gorm:"foreignKey:BookingId" json:"add_ons,omitempty"`
+}
+
+type SpecialRequestType string
+
+const (
+	SpecialRequestHighFloor      SpecialRequestType = "high_floor"
+	SpecialRequestLowFloor       SpecialRequestType = "low_floor"
+	SpecialRequestQuietRoom      SpecialRequestType = "quiet_room"
+	SpecialRequestCrib           SpecialRequestType = "crib"
+	SpecialRequestExtraBed       SpecialRequestType = "extra_bed"
+	SpecialRequestAccessibleRoom SpecialRequestType = "accessible_room"
+	SpecialRequestEarlyCheckIn   SpecialRequestType = "early_check_in"
+	SpecialRequestLateCheckOut   SpecialRequestType = "late_check_out"
+)
+
+// GuestDetails describes the person actually staying, who may differ from the booking user
+type GuestDetails struct {
+	Name               string               `json:"name" example:"Jane Doe"`
+	Email              string               `json:"email" example:"jane@example.com"`
+	Phone              string               `json:"phone" example:"+6281234567890"`
+	ArrivalTime        string               `json:"arrival_time" example:"15:30"`
+	SpecialRequests    string               `json:"special_requests" example:"Celebrating an anniversary"`
+	StructuredRequests []SpecialRequestType `gorm:"serializer:json" json:"structured_requests" example:"high_floor,crib"`
 }
 
+// CreateBookingRequest books a room. UserId is the signed-in user, or the user an API key acts as,
+// and is never read from the request body.
 type CreateBookingRequest struct {
-	HotelId      uuid.UUID `json:"hotel_id" binding:"required"`
-	UserId       uuid.UUID `json:"user_id" binding:"required"`
-	RoomId       uuid.UUID `json:"room_id" binding:"required"`
-	CheckInDate  time.Time `json:"check_in_date" binding:"required"`
-	CheckOutDate time.Time `json:"check_out_date" binding:"required"`
+	HotelId      uuid.UUID        `json:"hotel_id" binding:"required"`
+	UserId       uuid.UUID        `json:"-"`
+	RoomId       uuid.UUID        `json:"room_id" binding:"required"`
+	CheckInDate  time.Time        `json:"check_in_date" binding:"required"`
+	CheckOutDate time.Time        `json:"check_out_date" binding:"required"`
+	Guests       int              `json:"guests" example:"2"`
+	Guest        *GuestDetails    `json:"guest"`
+	AddOns       []AddOnSelection `json:"add_ons"`
 }
 
 type BookingResponse struct {
-	Id           uuid.UUID `json:"id" binding:"required"`
-	UserId       uuid.UUID `json:"user_id" binding:"required"`
-	HotelId      uuid.UUID `json:"hotel_id" binding:"required"`
-	RoomId       uuid.UUID `json:"room_id" binding:"required"`
-	CheckInDate  time.Time `json:"check_in_date" binding:"required"`
-	CheckOutDate time.Time `json:"check_out_date" binding:"required"`
-	TotalPrice   float64   `json:"total_price" binding:"required"`
-	IsCancelled  bool      `json:"is_cancelled" binding:"required"`
+	Id           uuid.UUID     `json:"id" binding:"required"`
+	UserId       uuid.UUID     `json:"user_id" binding:"required"`
+	HotelId      uuid.UUID     `json:"hotel_id" binding:"required"`
+	RoomId       uuid.UUID     `json:"room_id" binding:"required"`
+	CheckInDate  time.Time     `json:"check_in_date" binding:"required"`
+	CheckOutDate time.Time     `json:"check_out_date" binding:"required"`
+	Guests       int           `json:"guests"`
+	TotalPrice   float64       `json:"total_price" binding:"required"`
+	IsCancelled  bool          `json:"is_cancelled" binding:"required"`
+	Status       BookingStatus `json:"status"`
 }
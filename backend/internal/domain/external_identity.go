@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ExternalIdentity links an account at an identity provider to a user, so signing in there signs them in here
+type ExternalIdentity struct {
+	Id     uuid.UUID `gorm:"type:uuid;primaryKey" json:"id"`
+	UserId uuid.UUID `gorm:"type:uuid;index" json:"user_id"`
+	// Provider and Subject, the provider's id for the account, identify it
+	Provider  string    `gorm:"uniqueIndex:idx_external_identity" json:"provider"`
+	Subject   string    `gorm:"uniqueIndex:idx_external_identity" json:"subject"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// ExternalLoginState remembers a sign-in started at an identity provider until the user comes back with
+// a code. Only a hash of the state is kept; the PKCE verifier and nonce never leave the server.
+type ExternalLoginState struct {
+	Id           uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	Provider     string     `json:"provider"`
+	StateHash    string     `gorm:"uniqueIndex" json:"-"`
+	CodeVerifier string     `json:"-"`
+	Nonce        string     `json:"-"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	UsedAt       *time.Time `json:"used_at,omitempty"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// IdentityProviderInfo describes an identity provider users can sign in with
+type IdentityProviderInfo struct {
+	Name string `json:"name" example:"google"`
+}
+
+// ExternalLoginStartResponse says where to send the user to sign in at the identity provider
+type ExternalLoginStartResponse struct {
+	AuthorizationURL string `json:"authorization_url" example:"https://accounts.example.com/authorize?response_type=code&client_id=hotel-booking"`
+	// State comes back with the code; the client checks it matches before completing the login
+	State string `json:"state"`
+}
+
+// ExternalLoginCallbackRequest completes a sign-in with the code and state the identity provider redirected back with
+type ExternalLoginCallbackRequest struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+	// Device names the client signing in; the User-Agent header is used when it is empty
+	Device string `json:"device" example:"Guest laptop"`
+}
@@ -1,14 +1,129 @@
 package domain
 
-import "github.com/google/uuid"
+import (
+	"time"
 
+	"github.com/google/uuid"
+)
+
+// Hotel is a property with rooms, optionally owned by an organization. Rating and ReviewCount are
+// maintained from approved reviews. DistanceKm and Relevance are only filled in by location and text searches.
 type Hotel struct {
-	Id          uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id" binding:"required"`
-	Name        string    `json:"name" binding:"required"`
-	Description string    `json:"description" binding:"required"`
-	Rooms       []*Room   `gorm:"foreignKey:HotelId" json:"rooms"`
-	Address     string    `json:"address" binding:"required"`
-	Rating      float64   `json:"rating" binding:"required"`
+	Id             uuid.UUID     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id" binding:"required"`
+	OrganizationId *uuid.UUID    `gorm:"type:uuid;index" json:"organization_id"`
+	Organization   *Organization `gorm:"foreignKey:OrganizationId" json:"organization,omitempty"`
+	Name           string        `json:"name" binding:"required"`
+	Description    string        `json:"description" binding:"required"`
+	Rooms          []*Room       `gorm:"foreignKey:HotelId" json:"rooms"`
+	Photos         []*Photo      `gorm:"foreignKey:HotelId" json:"photos"`
+	Address        string        `json:"address" binding:"required"`
+	City           string        `gorm:"index" json:"city" example:"Denpasar"`
+	Country        string        `gorm:"index" json:"country" example:"Indonesia"`
+	Latitude       *float64      `gorm:"index:idx_hotel_location" json:"latitude" example:"-8.6705"`
+	Longitude      *float64      `gorm:"index:idx_hotel_location" json:"longitude" example:"115.2126"`
+	Rating         float64       `json:"rating"`
+	ReviewCount    int           `json:"review_count"`
+	NoShow         NoShowPolicy  `gorm:"embedded;embeddedPrefix:no_show_" json:"no_show_policy"`
+	DistanceKm     *float64      `gorm:"-" json:"distance_km,omitempty"`
+	Relevance      *float64      `gorm:"-" json:"relevance,omitempty"`
+}
+
+// GeoBounds is a latitude/longitude box. MinLng greater than MaxLng describes a box crossing the antimeridian.
+type GeoBounds struct {
+	MinLat float64
+	MinLng float64
+	MaxLat float64
+	MaxLng float64
+}
+
+// HotelFilter narrows the hotels loaded from the database. A non-nil Ids restricts the result to those hotels.
+// Facilities must all be offered by some room; the price range applies to the hotel's cheapest room.
+type HotelFilter struct {
+	Ids            []uuid.UUID
+	OrganizationId *uuid.UUID
+	Bounds         *GeoBounds
+	City           string
+	Country        string
+	Facilities     []string
+	MinRating      *float64
+	MinPrice       *float64
+	MaxPrice       *float64
+}
+
+// HotelSearch is a hotel list query. Query ranks the results by text relevance; Lat and Lng sort
+// them by distance instead, and with RadiusKm only hotels within that distance are returned.
+type HotelSearch struct {
+	OrganizationId *uuid.UUID
+	Query          string
+	Lat            *float64
+	Lng            *float64
+	RadiusKm       *float64
+	Bounds         *GeoBounds
+	City           string
+	Country        string
+	Facilities     []string
+	MinRating      *float64
+	MinPrice       *float64
+	MaxPrice       *float64
+}
+
+// HotelSearchResult is a page of search results with the facet counts for the same filters
+type HotelSearchResult struct {
+	Hotels []Hotel      `json:"hotels"`
+	Facets *HotelFacets `json:"facets"`
+}
+
+// HotelFacets count the hotels matching a search by facility, rating band and price bucket.
+// Facility counts apply every filter, showing how many hotels remain when that facility is added;
+// rating and price counts ignore their own filter so the other bands stay selectable.
+type HotelFacets struct {
+	Facilities []FacetCount  `json:"facilities"`
+	Ratings    []RatingFacet `json:"ratings"`
+	Prices     []PriceFacet  `json:"prices"`
+}
+
+type FacetCount struct {
+	Value string `json:"value" example:"Pool"`
+	Count int64  `json:"count" example:"12"`
+}
+
+// RatingFacet counts hotels rated at least MinRating
+type RatingFacet struct {
+	MinRating float64 `json:"min_rating" example:"4"`
+	Count     int64   `json:"count" example:"7"`
+}
+
+// PriceFacet counts hotels whose cheapest room costs at least MinPrice and less than MaxPrice;
+// the last bucket has no MaxPrice
+type PriceFacet struct {
+	MinPrice float64  `json:"min_price" example:"50"`
+	MaxPrice *float64 `json:"max_price,omitempty" example:"100"`
+	Count    int64    `json:"count" example:"4"`
+}
+
+// PriceCalendarDay is the cheapest nightly rate of a hotel for a stay arriving on Date.
+// Price is nil and Sellable false when no room can be booked for an arrival that day.
+type PriceCalendarDay struct {
+	Date           time.Time `json:"date" example:"2030-01-01T00:00:00Z"`
+	Price          *float64  `json:"price" example:"120"`
+	AvailableRooms int       `json:"available_rooms" example:"3"`
+	Sellable       bool      `json:"sellable"`
+}
+
+// RoomNight is a room that is free for sale on the night starting on Date
+type RoomNight struct {
+	RoomId uuid.UUID
+	Date   time.Time
+	Price  float64
+}
+
+// NoShowPolicy controls when an unarrived booking becomes a no-show and what the guest is charged.
+// Unset values use the organization's default.
+type NoShowPolicy struct {
+	// CutoffHours after the booked check-in time; unset everywhere means 24 hours
+	CutoffHours *int `json:"cutoff_hours,omitempty" binding:"omitempty,min=0" example:"24"`
+	// PenaltyNights of the room rate charged to the guest; 0 charges nothing, as does leaving it unset everywhere
+	PenaltyNights *int `json:"penalty_nights,omitempty" binding:"omitempty,min=0" example:"1"`
 }
 
 type Room struct {
@@ -18,6 +133,7 @@ type Room struct {
 	Description string      `json:"description" binding:"required"`
 	Available   bool        `json:"available" binding:"required"`
 	Facilities  []*Facility `gorm:"many2many:room_facilities;" json:"facilities"`
+	Photos      []*Photo    `gorm:"foreignKey:RoomId" json:"photos"`
 	HotelId     uuid.UUID   `gorm:"type:uuid" json:"hotel_id" binding:"required"`
 	Hotel       *Hotel      `gorm:"foreignKey:HotelId" json:"-"`
 }
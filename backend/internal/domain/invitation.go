@@ -0,0 +1,58 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type InvitationStatus string
+
+const (
+	InvitationPending  InvitationStatus = "pending"
+	InvitationAccepted InvitationStatus = "accepted"
+	InvitationRevoked  InvitationStatus = "revoked"
+	InvitationExpired  InvitationStatus = "expired"
+)
+
+// StaffInvitation offers a staff role at a hotel to whoever controls an email address.
+// The token is emailed to the address and only its hash is stored.
+type StaffInvitation struct {
+	Id         uuid.UUID        `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Email      string           `gorm:"index" json:"email" example:"desk@example.com"`
+	Role       Role             `json:"role" example:"front_desk"`
+	HotelId    uuid.UUID        `gorm:"type:uuid;index" json:"hotel_id"`
+	TokenHash  string           `gorm:"uniqueIndex" json:"-"`
+	InvitedBy  uuid.UUID        `gorm:"type:uuid" json:"invited_by"`
+	ExpiresAt  time.Time        `json:"expires_at"`
+	AcceptedAt *time.Time       `json:"accepted_at,omitempty"`
+	AcceptedBy *uuid.UUID       `gorm:"type:uuid" json:"accepted_by,omitempty"`
+	RevokedAt  *time.Time       `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time        `gorm:"autoCreateTime" json:"created_at"`
+	Status     InvitationStatus `gorm:"-" json:"status" example:"pending"`
+}
+
+// InvitationRequest invites an email address to join a hotel's team
+type InvitationRequest struct {
+	Email string `json:"email" binding:"required,email" example:"desk@example.com"`
+	Role  Role   `json:"role" binding:"required" example:"front_desk"`
+}
+
+// AcceptInvitationRequest accepts an invitation. Username and password are needed only when
+// no account exists yet for the invited email address.
+type AcceptInvitationRequest struct {
+	Token    string `json:"token" binding:"required"`
+	Username string `json:"username" example:"johndoe"`
+	Password string `json:"password" example:"password123"`
+}
+
+// TeamMember is a user holding a staff role at a hotel, directly or through its organization
+type TeamMember struct {
+	UserId         uuid.UUID  `json:"user_id"`
+	Username       string     `json:"username"`
+	Email          string     `json:"email"`
+	Role           Role       `json:"role" example:"front_desk"`
+	HotelId        *uuid.UUID `json:"hotel_id,omitempty"`
+	OrganizationId *uuid.UUID `json:"organization_id,omitempty"`
+	Since          time.Time  `json:"since"`
+}
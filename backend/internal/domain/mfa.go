@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFAEnrollment holds a user's TOTP secret. Two-factor login is on once the enrollment is confirmed
+// with a code from the authenticator app.
+type MFAEnrollment struct {
+	UserId      uuid.UUID  `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Secret      string     `json:"-"`
+	ConfirmedAt *time.Time `json:"confirmed_at,omitempty"`
+	// LastUsedStep is the time step of the last accepted code, so a code cannot be used twice
+	LastUsedStep int64     `json:"-"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt    time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// MFARecoveryCode is a single-use code that stands in for a TOTP code when the authenticator is lost.
+// Only a hash of the code is kept.
+type MFARecoveryCode struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	CodeHash  string     `gorm:"index" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// MFAChallenge is the first step of a two-factor login: the password was right and the holder of
+// the challenge token has a few attempts to send a code before it expires.
+type MFAChallenge struct {
+	Id        uuid.UUID  `gorm:"type:uuid;primaryKey" json:"id"`
+	UserId    uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"`
+	Device    string     `json:"device"`
+	Attempts  int        `json:"attempts"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// MFAEnrollmentResponse is what the user adds to their authenticator app
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret" example:"JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP"`
+	OTPAuthURI string `json:"otpauth_uri" example:"otpauth://totp/Hotel%20Booking:admin@example.com?secret=JBSWY3DPEHPK3PXPJBSWY3DPEHPK3PXP&issuer=Hotel+Booking"`
+}
+
+// MFAStatus says whether two-factor login is on for the user
+type MFAStatus struct {
+	Enabled bool `json:"enabled"`
+	// Pending is set when an enrollment was started but not confirmed yet
+	Pending           bool  `json:"pending"`
+	RecoveryCodesLeft int64 `json:"recovery_codes_left"`
+}
+
+// MFACodeRequest carries a code from the authenticator app, or a recovery code where one is accepted
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required" example:"123456"`
+}
+
+// MFARecoveryCodesResponse lists new recovery codes. They are only ever shown once.
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes" example:"k7q2m-x9vtp,3hd8w-pq2rz"`
+}
+
+// MFAVerifyRequest completes a two-factor login
+type MFAVerifyRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	// Code is a code from the authenticator app or an unused recovery code
+	Code string `json:"code" binding:"required" example:"123456"`
+}
@@ -0,0 +1,73 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Organization is a chain or brand that owns hotels
+type Organization struct {
+	Id        uuid.UUID            `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	Name      string               `gorm:"uniqueIndex" json:"name" example:"Seaside Hotels"`
+	Settings  OrganizationSettings `gorm:"embedded" json:"settings"`
+	CreatedAt time.Time            `json:"created_at"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// OrganizationSettings apply to every hotel of an organization. DefaultNoShow is used for
+// hotels that leave their own no-show policy unset.
+type OrganizationSettings struct {
+	Currency      string               `json:"currency" binding:"omitempty,len=3,alpha" example:"EUR"`
+	DefaultNoShow NoShowPolicy         `gorm:"embedded;embeddedPrefix:default_no_show_" json:"default_no_show_policy"`
+	Branding      OrganizationBranding `gorm:"embedded;embeddedPrefix:brand_" json:"branding"`
+}
+
+type OrganizationBranding struct {
+	DisplayName  string `json:"display_name" example:"Seaside"`
+	LogoUrl      string `json:"logo_url" binding:"omitempty,url" example:"https://cdn.example.com/seaside.png"`
+	PrimaryColor string `json:"primary_color" binding:"omitempty,hexcolor" example:"#0077cc"`
+}
+
+type OrganizationRequest struct {
+	Name     string               `json:"name" binding:"required" example:"Seaside Hotels"`
+	Settings OrganizationSettings `json:"settings"`
+}
+
+// MoveHotelRequest assigns a hotel to an organization; a nil OrganizationId makes it independent
+type MoveHotelRequest struct {
+	OrganizationId *uuid.UUID `json:"organization_id"`
+	Reason         string     `json:"reason" example:"Acquired by Seaside Hotels"`
+}
+
+// HotelOrganizationChange records a hotel moving between organizations. A nil side means independent.
+type HotelOrganizationChange struct {
+	Id                 uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	HotelId            uuid.UUID  `gorm:"type:uuid;index" json:"hotel_id"`
+	FromOrganizationId *uuid.UUID `gorm:"type:uuid" json:"from_organization_id"`
+	ToOrganizationId   *uuid.UUID `gorm:"type:uuid" json:"to_organization_id"`
+	ChangedBy          *uuid.UUID `gorm:"type:uuid" json:"changed_by"`
+	Reason             string     `json:"reason"`
+	CreatedAt          time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// OrganizationReport sums the bookings made with an organization's hotels that check in between From and To.
+// Bookings stay with the organization that owned the hotel when they were made, so moving a hotel keeps past reports intact.
+type OrganizationReport struct {
+	OrganizationId uuid.UUID     `json:"organization_id"`
+	Currency       string        `json:"currency" example:"EUR"`
+	From           time.Time     `json:"from"`
+	To             time.Time     `json:"to"`
+	Hotels         []HotelReport `json:"hotels"`
+	Totals         HotelReport   `json:"totals"`
+}
+
+// HotelReport counts a hotel's bookings by outcome. Revenue is the total price of bookings that were not cancelled.
+type HotelReport struct {
+	HotelId   *uuid.UUID `json:"hotel_id,omitempty"`
+	HotelName string     `json:"hotel_name,omitempty"`
+	Bookings  int64      `json:"bookings"`
+	Cancelled int64      `json:"cancelled"`
+	NoShows   int64      `json:"no_shows"`
+	Revenue   float64    `json:"revenue"`
+}
@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type PaymentKind string
+
+const (
+	PaymentKindNoShowPenalty PaymentKind = "no_show_penalty"
+)
+
+type PaymentStatus string
+
+const (
+	// PaymentStatusPending is recorded when no processor is configured and the front desk collects the charge
+	PaymentStatusPending  PaymentStatus = "pending"
+	PaymentStatusCaptured PaymentStatus = "captured"
+	PaymentStatusFailed   PaymentStatus = "failed"
+)
+
+// Payment is a charge raised against a booking's guest
+type Payment struct {
+	Id          uuid.UUID     `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	BookingId   uuid.UUID     `gorm:"type:uuid;index" json:"booking_id"`
+	UserId      uuid.UUID     `gorm:"type:uuid;index" json:"user_id"`
+	Kind        PaymentKind   `json:"kind"`
+	Amount      float64       `json:"amount"`
+	Status      PaymentStatus `json:"status"`
+	Reference   string        `json:"reference,omitempty"`
+	Description string        `json:"description"`
+	CreatedAt   time.Time     `gorm:"autoCreateTime" json:"created_at"`
+}
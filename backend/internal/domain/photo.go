@@ -0,0 +1,31 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Photo is an image of a hotel, or of one of its rooms when RoomId is set.
+// Photos are shown by Position; each hotel and each room has at most one cover photo.
+type Photo struct {
+	Id          uuid.UUID         `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	HotelId     uuid.UUID         `gorm:"type:uuid;index" json:"hotel_id"`
+	RoomId      *uuid.UUID        `gorm:"type:uuid;index" json:"room_id,omitempty"`
+	Position    int               `json:"position"`
+	IsCover     bool              `gorm:"default:false" json:"is_cover"`
+	ContentType string            `json:"content_type"`
+	Width       int               `json:"width"`
+	Height      int               `json:"height"`
+	Size        int               `json:"size"`
+	StorageKey  string            `json:"-"`
+	Url         string            `json:"url"`
+	Thumbnails  map[string]string `gorm:"serializer:json" json:"thumbnails"`
+	CreatedAt   time.Time         `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type ReorderPhotosRequest struct {
+	// RoomId selects the room's photos; leave empty to reorder the hotel's own photos
+	RoomId   *uuid.UUID  `json:"room_id"`
+	PhotoIds []uuid.UUID `json:"photo_ids" binding:"required,min=1"`
+}
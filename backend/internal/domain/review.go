@@ -0,0 +1,90 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+	ReviewStatusHidden   ReviewStatus = "hidden"
+)
+
+type ReviewFlag string
+
+const (
+	ReviewFlagBannedWords   ReviewFlag = "banned_words"
+	ReviewFlagContainsLink  ReviewFlag = "contains_link"
+	ReviewFlagDuplicateText ReviewFlag = "duplicate_text"
+)
+
+type ModerationAction string
+
+const (
+	ModerationActionApprove ModerationAction = "approve"
+	ModerationActionReject  ModerationAction = "reject"
+	ModerationActionHide    ModerationAction = "hide"
+)
+
+// Review is a guest's rating of a hotel, written from a checked-out booking.
+// New reviews wait in the moderation queue with any flags raised by automatic screening;
+// only approved reviews are public and count towards the hotel's rating.
+type Review struct {
+	Id               uuid.UUID    `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	HotelId          uuid.UUID    `gorm:"type:uuid;index" json:"hotel_id"`
+	UserId           uuid.UUID    `gorm:"type:uuid;index" json:"user_id"`
+	BookingId        uuid.UUID    `gorm:"type:uuid;uniqueIndex" json:"booking_id"`
+	Cleanliness      int          `json:"cleanliness"`
+	Location         int          `json:"location"`
+	Service          int          `json:"service"`
+	Rating           float64      `json:"rating"`
+	Title            string       `json:"title"`
+	Comment          string       `json:"comment"`
+	Status           ReviewStatus `gorm:"index" json:"status"`
+	Flags            []ReviewFlag `gorm:"serializer:json" json:"flags,omitempty"`
+	TextFingerprint  string       `gorm:"index" json:"-"`
+	ReportCount      int          `gorm:"default:0" json:"report_count"`
+	ModerationReason string       `json:"moderation_reason,omitempty"`
+	ModeratedBy      *uuid.UUID   `gorm:"type:uuid" json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time   `json:"moderated_at,omitempty"`
+	Reply            string       `json:"reply,omitempty"`
+	RepliedAt        *time.Time   `json:"replied_at,omitempty"`
+	CreatedAt        time.Time    `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt        time.Time    `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type CreateReviewRequest struct {
+	BookingId   uuid.UUID `json:"booking_id" binding:"required"`
+	Cleanliness int       `json:"cleanliness" binding:"required,min=1,max=5" example:"5"`
+	Location    int       `json:"location" binding:"required,min=1,max=5" example:"4"`
+	Service     int       `json:"service" binding:"required,min=1,max=5" example:"5"`
+	Title       string    `json:"title" binding:"max=120" example:"Lovely stay"`
+	Comment     string    `json:"comment" binding:"max=4000" example:"Spotless room and friendly staff."`
+}
+
+type ReviewReplyRequest struct {
+	Reply string `json:"reply" binding:"required,max=4000" example:"Thank you for staying with us!"`
+}
+
+type ModerateReviewRequest struct {
+	Action ModerationAction `json:"action" binding:"required,oneof=approve reject hide" example:"hide"`
+	Reason string           `json:"reason" binding:"max=1000" example:"Contains personal information"`
+}
+
+// ReviewReport is a user's complaint about a published review. Reported reviews return to the moderation queue.
+type ReviewReport struct {
+	Id        uuid.UUID `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	ReviewId  uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_review_report_user" json:"review_id"`
+	UserId    uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_review_report_user" json:"user_id"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+type ReportReviewRequest struct {
+	Reason string `json:"reason" binding:"required,max=1000" example:"Offensive language"`
+}
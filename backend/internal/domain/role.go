@@ -0,0 +1,81 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Role string
+
+const (
+	// RoleSuperAdmin is held by users flagged IsAdmin and may do anything anywhere
+	RoleSuperAdmin   Role = "super_admin"
+	RoleHotelOwner   Role = "hotel_owner"
+	RoleHotelManager Role = "hotel_manager"
+	RoleFrontDesk    Role = "front_desk"
+	// RoleGuest is every signed-in user and carries no staff permissions
+	RoleGuest Role = "guest"
+)
+
+type Permission string
+
+const (
+	PermissionBookingsRead     Permission = "bookings:read"
+	PermissionBookingsCheckIn  Permission = "bookings:checkin"
+	PermissionBookingsCheckOut Permission = "bookings:checkout"
+	PermissionRatesManage      Permission = "rates:manage"
+	PermissionPhotosManage     Permission = "photos:manage"
+	PermissionReviewsReply     Permission = "reviews:reply"
+	PermissionRolesManage      Permission = "roles:manage"
+	PermissionTeamRead         Permission = "team:read"
+)
+
+// RolePermissions lists what each role may do within its scope. Super admins are not listed; they may do everything.
+var RolePermissions = map[Role][]Permission{
+	RoleHotelOwner: {
+		PermissionBookingsRead, PermissionBookingsCheckIn, PermissionBookingsCheckOut,
+		PermissionRatesManage, PermissionPhotosManage, PermissionReviewsReply, PermissionRolesManage,
+		PermissionTeamRead,
+	},
+	RoleHotelManager: {
+		PermissionBookingsRead, PermissionBookingsCheckIn, PermissionBookingsCheckOut,
+		PermissionRatesManage, PermissionPhotosManage, PermissionReviewsReply, PermissionTeamRead,
+	},
+	RoleFrontDesk: {
+		PermissionBookingsRead, PermissionBookingsCheckIn, PermissionBookingsCheckOut,
+	},
+	RoleGuest: {},
+}
+
+// RoleAssignment grants a staff role to a user for one hotel, or for every hotel of an organization
+type RoleAssignment struct {
+	Id             uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserId         uuid.UUID  `gorm:"type:uuid;index" json:"user_id"`
+	Role           Role       `json:"role" example:"front_desk"`
+	HotelId        *uuid.UUID `gorm:"type:uuid;index" json:"hotel_id,omitempty"`
+	OrganizationId *uuid.UUID `gorm:"type:uuid;index" json:"organization_id,omitempty"`
+	GrantedBy      *uuid.UUID `gorm:"type:uuid" json:"granted_by"`
+	CreatedAt      time.Time  `gorm:"autoCreateTime" json:"created_at"`
+}
+
+// RoleGrantRequest names a role and its scope. Staff roles need exactly one of HotelId and OrganizationId;
+// super_admin takes neither.
+type RoleGrantRequest struct {
+	Role           Role       `json:"role" binding:"required" example:"front_desk"`
+	HotelId        *uuid.UUID `json:"hotel_id"`
+	OrganizationId *uuid.UUID `json:"organization_id"`
+}
+
+// UserRoles is everything a user may do beyond being a guest
+type UserRoles struct {
+	UserId      uuid.UUID        `json:"user_id"`
+	SuperAdmin  bool             `json:"super_admin"`
+	Assignments []RoleAssignment `json:"assignments"`
+}
+
+// RoleDefinition describes a role and its permissions for the admin API
+type RoleDefinition struct {
+	Role        Role         `json:"role" example:"front_desk"`
+	Permissions []Permission `json:"permissions"`
+}
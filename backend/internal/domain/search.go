@@ -0,0 +1,38 @@
+package domain
+
+import "github.com/google/uuid"
+
+// HotelSearchDocument is the text indexed for full-text hotel search: the hotel's name, description,
+// address and the facility names of its rooms. On Postgres the weighted tsvector is kept alongside.
+type HotelSearchDocument struct {
+	HotelId      uuid.UUID `gorm:"type:uuid;primaryKey"`
+	Name         string
+	Description  string
+	Address      string
+	Facilities   string
+	SearchVector string `gorm:"type:tsvector;index:idx_hotel_search_vector,type:gin;->:false;<-:false"`
+}
+
+// SearchTerm is a word that appears in the search index, used to correct typos in queries
+type SearchTerm struct {
+	Term string `gorm:"primaryKey"`
+}
+
+// SearchMatch is a hotel found by a text query with its relevance; higher ranks are better
+type SearchMatch struct {
+	HotelId uuid.UUID
+	Rank    float64
+}
+
+type SearchSuggestionType string
+
+const (
+	SearchSuggestionHotel SearchSuggestionType = "hotel"
+	SearchSuggestionCity  SearchSuggestionType = "city"
+)
+
+type SearchSuggestion struct {
+	Type    SearchSuggestionType `json:"type" example:"hotel"`
+	Text    string               `json:"text" example:"Oceanview Resort"`
+	HotelId *uuid.UUID           `json:"hotel_id,omitempty"`
+}
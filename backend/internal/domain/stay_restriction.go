@@ -0,0 +1,36 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StayRestriction holds the revenue rules for one hotel date. A row with a RoomId
+// applies to that room only and takes precedence over the hotel-wide row (RoomId nil).
+// Zero values mean "no restriction".
+type StayRestriction struct {
+	Id                uuid.UUID  `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	HotelId           uuid.UUID  `gorm:"type:uuid;index" json:"hotel_id"`
+	RoomId            *uuid.UUID `gorm:"type:uuid;index" json:"room_id,omitempty"`
+	Date              time.Time  `gorm:"index" json:"date"`
+	MinStay           int        `json:"min_stay"`
+	MaxStay           int        `json:"max_stay"`
+	ClosedToArrival   bool       `json:"closed_to_arrival"`
+	ClosedToDeparture bool       `json:"closed_to_departure"`
+	MinAdvanceDays    int        `json:"min_advance_days"`
+	MaxAdvanceDays    int        `json:"max_advance_days"`
+}
+
+// SetStayRestrictionsRequest applies the same rules to every date from From to To inclusive
+type SetStayRestrictionsRequest struct {
+	RoomId            *uuid.UUID `json:"room_id"`
+	From              time.Time  `json:"from" binding:"required" example:"2030-01-01T00:00:00Z"`
+	To                time.Time  `json:"to" binding:"required" example:"2030-01-31T00:00:00Z"`
+	MinStay           int        `json:"min_stay" binding:"gte=0" example:"2"`
+	MaxStay           int        `json:"max_stay" binding:"gte=0" example:"14"`
+	ClosedToArrival   bool       `json:"closed_to_arrival"`
+	ClosedToDeparture bool       `json:"closed_to_departure"`
+	MinAdvanceDays    int        `json:"min_advance_days" binding:"gte=0"`
+	MaxAdvanceDays    int        `json:"max_advance_days" binding:"gte=0" example:"365"`
+}
@@ -14,4 +14,11 @@ type User struct {
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
 	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
 	IsAdmin   bool      `json:"is_admin" binding:"required"`
+	// EmailVerifiedAt is when the user proved they control Email; nil while unverified
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+}
+
+// IsEmailVerified reports whether the user has confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
 }
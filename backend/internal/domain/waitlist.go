@@ -0,0 +1,40 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type WaitlistStatus string
+
+const (
+	WaitlistStatusWaiting   WaitlistStatus = "waiting"
+	WaitlistStatusOffered   WaitlistStatus = "offered"
+	WaitlistStatusClaimed   WaitlistStatus = "claimed"
+	WaitlistStatusExpired   WaitlistStatus = "expired"
+	WaitlistStatusCancelled WaitlistStatus = "cancelled"
+)
+
+// WaitlistEntry is a guest queued for a room that was fully booked for the requested dates.
+// When inventory frees up the first matching entry receives a time-limited offer.
+type WaitlistEntry struct {
+	Id             uuid.UUID      `gorm:"type:uuid;primaryKey;default:gen_random_uuid()" json:"id"`
+	UserId         uuid.UUID      `gorm:"type:uuid;index" json:"user_id"`
+	HotelId        uuid.UUID      `gorm:"type:uuid" json:"hotel_id"`
+	RoomId         uuid.UUID      `gorm:"type:uuid;index" json:"room_id"`
+	CheckInDate    time.Time      `json:"check_in_date"`
+	CheckOutDate   time.Time      `json:"check_out_date"`
+	Status         WaitlistStatus `gorm:"index" json:"status"`
+	OfferTokenHash string         `gorm:"index" json:"-"`
+	OfferExpiresAt *time.Time     `json:"offer_expires_at,omitempty"`
+	CreatedAt      time.Time      `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt      time.Time      `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+type JoinWaitlistRequest struct {
+	HotelId      uuid.UUID `json:"hotel_id" binding:"required"`
+	RoomId       uuid.UUID `json:"room_id" binding:"required"`
+	CheckInDate  time.Time `json:"check_in_date" binding:"required"`
+	CheckOutDate time.Time `json:"check_out_date" binding:"required"`
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartBookingJobs marks bookings that were never checked in as no-shows once their hotel's cutoff passes
+func StartBookingJobs(db *gorm.DB) {
+	hotelRepository := repository.NewHotelRepository(db)
+	bookingRepository := repository.NewBookingRepository(db)
+	waitlistService := service.NewWaitlistService(
+		repository.NewWaitlistRepository(db),
+		hotelRepository,
+		bookingRepository,
+		repository.NewUserRepository(db),
+		mail.NewFileMailer(os.Getenv("MAIL_OUTBOX_DIR")),
+		config.GetEnv("APP_URL", "http://localhost:5173"),
+		config.GetEnvDuration("WAITLIST_OFFER_TTL", 30*time.Minute),
+	)
+	noShowService := service.NewNoShowService(
+		bookingRepository,
+		hotelRepository,
+		service.NewPaymentService(repository.NewPaymentRepository(db), nil),
+		service.NewAuditService(repository.NewAuditRepository(db)),
+		waitlistService,
+	)
+
+	RunEvery("no-show-detection", config.GetEnvDuration("NO_SHOW_SWEEP_INTERVAL", 15*time.Minute), func() error {
+		return noShowService.ProcessNoShows(time.Now())
+	})
+}
@@ -0,0 +1,20 @@
+package jobs
+
+import (
+	"log"
+	"time"
+)
+
+// RunEvery runs task in the background on a fixed interval, logging failures instead of stopping
+func RunEvery(name string, interval time.Duration, task func() error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := task(); err != nil {
+				log.Printf("Job %s failed: %v", name, err)
+			}
+		}
+	}()
+	log.Printf("Scheduled job %s every %s", name, interval)
+}
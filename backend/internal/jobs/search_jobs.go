@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartSearchJobs builds the hotel search index on startup and rebuilds it periodically,
+// picking up room and facility changes that are not indexed as they happen
+func StartSearchJobs(db *gorm.DB) {
+	searchService := service.NewSearchService(repository.NewSearchRepository(db), repository.NewHotelRepository(db))
+
+	go func() {
+		if err := searchService.RebuildIndex(); err != nil {
+			log.Printf("Job search-index-rebuild failed: %v", err)
+		}
+	}()
+	RunEvery("search-index-rebuild", config.GetEnvDuration("SEARCH_REINDEX_INTERVAL", time.Hour), searchService.RebuildIndex)
+}
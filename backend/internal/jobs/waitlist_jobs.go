@@ -0,0 +1,29 @@
+package jobs
+
+import (
+	"backend/config"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"os"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StartWaitlistJobs expires unclaimed waitlist offers and passes the room to the next guest in line
+func StartWaitlistJobs(db *gorm.DB) {
+	waitlistService := service.NewWaitlistService(
+		repository.NewWaitlistRepository(db),
+		repository.NewHotelRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewUserRepository(db),
+		mail.NewFileMailer(os.Getenv("MAIL_OUTBOX_DIR")),
+		config.GetEnv("APP_URL", "http://localhost:5173"),
+		config.GetEnvDuration("WAITLIST_OFFER_TTL", 30*time.Minute),
+	)
+
+	RunEvery("waitlist-offer-expiry", config.GetEnvDuration("WAITLIST_SWEEP_INTERVAL", time.Minute), func() error {
+		return waitlistService.ExpireOffers(time.Now())
+	})
+}
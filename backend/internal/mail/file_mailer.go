@@ -0,0 +1,41 @@
+package mail
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]+`)
+
+// FileMailer stands in for a real mail provider during development. It logs each message and,
+// when given a directory, also writes it there as a text file so links in it can be followed.
+type FileMailer struct {
+	dir string
+}
+
+func NewFileMailer(dir string) *FileMailer {
+	return &FileMailer{dir: dir}
+}
+
+func (m *FileMailer) Send(message *Message) error {
+	if m.dir == "" {
+		log.Printf("mail to %s: %s\n%s", message.To, message.Subject, message.Body)
+		return nil
+	}
+	if err := os.MkdirAll(m.dir, 0o755); err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%s-%s.txt", time.Now().UTC().Format("20060102T150405.000000000"), unsafeFileChars.ReplaceAllString(message.To, "_"))
+	target := filepath.Join(m.dir, name)
+	content := fmt.Sprintf("To: %s\nSubject: %s\n\n%s\n", message.To, message.Subject, message.Body)
+	if err := os.WriteFile(target, []byte(content), 0o600); err != nil {
+		return err
+	}
+	log.Printf("mail to %s: %s (saved to %s)", message.To, message.Subject, target)
+	return nil
+}
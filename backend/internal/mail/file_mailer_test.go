@@ -0,0 +1,30 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileMailer_WritesMessageToOutbox(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer := NewFileMailer(dir)
+
+	err := mailer.Send(&Message{To: "guest/../x@example.com", Subject: "Hello", Body: "Line one\nLine two"})
+	assert.NoError(t, err)
+
+	files, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	if assert.Len(t, files, 1) {
+		assert.NotContains(t, files[0].Name(), "/")
+		content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+		assert.NoError(t, err)
+		assert.Equal(t, "To: guest/../x@example.com\nSubject: Hello\n\nLine one\nLine two\n", string(content))
+	}
+}
+
+func TestFileMailer_LogsOnlyWithoutDirectory(t *testing.T) {
+	assert.NoError(t, NewFileMailer("").Send(&Message{To: "guest@example.com", Subject: "Hello"}))
+}
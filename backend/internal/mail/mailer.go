@@ -0,0 +1,13 @@
+package mail
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email to users
+type Mailer interface {
+	Send(message *Message) error
+}
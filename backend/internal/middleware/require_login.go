@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"backend/internal/auth"
+	"backend/internal/domain"
 	"backend/internal/shared"
 	"net/http"
 	"strings"
@@ -11,29 +12,38 @@ import (
 
 func RequireLogin() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
-		authHeader := ctx.GetHeader("Authorization")
-		if authHeader == "" {
-			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
-			ctx.Abort()
-			return
-		}
-		const bearerPrefix = "Bearer "
-		if len(authHeader) < len(bearerPrefix) || !strings.HasPrefix(authHeader, bearerPrefix) {
-			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
-			ctx.Abort()
+		user, ok := authenticate(ctx)
+		if !ok {
 			return
 		}
 
-		token := strings.TrimPrefix(authHeader, bearerPrefix)
+		ctx.Set("user", user)
+		ctx.Next()
+	}
+}
 
-		user, err := auth.ValidateToken(token)
-		if err != nil {
-			ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
-			ctx.Abort()
-			return
-		}
+// authenticate reads the bearer access token. When it is missing or invalid the request is aborted with 401.
+func authenticate(ctx *gin.Context) (*domain.User, bool) {
+	authHeader := ctx.GetHeader("Authorization")
+	if authHeader == "" {
+		ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
+		ctx.Abort()
+		return nil, false
+	}
+	const bearerPrefix = "Bearer "
+	if len(authHeader) < len(bearerPrefix) || !strings.HasPrefix(authHeader, bearerPrefix) {
+		ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
+		ctx.Abort()
+		return nil, false
+	}
 
-		ctx.Set("user", &user)
-		ctx.Next()
+	token := strings.TrimPrefix(authHeader, bearerPrefix)
+
+	user, err := auth.ValidateToken(token)
+	if err != nil {
+		ctx.JSON(http.StatusUnauthorized, shared.NewUnauthorizedResponse("Unauthorized", ctx.Request.URL.Path))
+		ctx.Abort()
+		return nil, false
 	}
+	return &user, true
 }
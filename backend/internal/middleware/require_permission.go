@@ -0,0 +1,120 @@
+package middleware
+
+import (
+	"backend/internal/domain"
+	"backend/internal/shared"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ErrScopeNotFound is returned by a HotelScope when the resource the request names does not exist
+var ErrScopeNotFound = errors.New("resource not found")
+
+// Authorizer decides whether a user holds a permission at a hotel, or is a super admin
+type Authorizer interface {
+	HasPermission(user *domain.User, permission domain.Permission, hotelId *uuid.UUID) (bool, error)
+	IsSuperAdmin(user *domain.User) (bool, error)
+}
+
+// HotelScope finds the hotel a request acts on. A nil hotel checks for the permission without a hotel,
+// which only super admins pass.
+type HotelScope func(ctx *gin.Context) (*uuid.UUID, error)
+
+// HotelParam scopes the permission to the hotel whose id is in the named path parameter
+func HotelParam(name string) HotelScope {
+	return func(ctx *gin.Context) (*uuid.UUID, error) {
+		hotelId, err := uuid.Parse(ctx.Param(name))
+		if err != nil {
+			return nil, ErrScopeNotFound
+		}
+		return &hotelId, nil
+	}
+}
+
+type PermissionMiddleware struct {
+	authorizer Authorizer
+}
+
+func NewPermissionMiddleware(authorizer Authorizer) *PermissionMiddleware {
+	return &PermissionMiddleware{authorizer: authorizer}
+}
+
+// OwnerCheck says whether the signed-in user owns the resource the request names. It returns
+// ErrScopeNotFound when the resource does not exist.
+type OwnerCheck func(ctx *gin.Context, user *domain.User) (bool, error)
+
+// RequirePermission lets the request through when the signed-in user holds the permission at the scoped hotel
+func (m *PermissionMiddleware) RequirePermission(permission domain.Permission, scope HotelScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := authenticate(ctx)
+		if !ok {
+			return
+		}
+		if !m.authorize(ctx, user, permission, scope) {
+			return
+		}
+
+		ctx.Set("user", user)
+		ctx.Next()
+	}
+}
+
+// RequireOwnerOrPermission lets the owner of the resource through, and otherwise users holding the permission at the scoped hotel
+func (m *PermissionMiddleware) RequireOwnerOrPermission(owner OwnerCheck, permission domain.Permission, scope HotelScope) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		user, ok := authenticate(ctx)
+		if !ok {
+			return
+		}
+
+		owns, err := owner(ctx, user)
+		if err != nil {
+			abortWithScopeError(ctx, err)
+			return
+		}
+		if !owns && !m.authorize(ctx, user, permission, scope) {
+			return
+		}
+
+		ctx.Set("user", user)
+		ctx.Next()
+	}
+}
+
+// authorize checks the user holds the permission at the scoped hotel, aborting the request when they do not
+func (m *PermissionMiddleware) authorize(ctx *gin.Context, user *domain.User, permission domain.Permission, scope HotelScope) bool {
+	var hotelId *uuid.UUID
+	if scope != nil {
+		var err error
+		hotelId, err = scope(ctx)
+		if err != nil {
+			abortWithScopeError(ctx, err)
+			return false
+		}
+	}
+
+	allowed, err := m.authorizer.HasPermission(user, permission, hotelId)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+		ctx.Abort()
+		return false
+	}
+	if !allowed {
+		ctx.JSON(http.StatusForbidden, shared.NewForbiddenResponse("Forbidden: missing permission "+string(permission), ctx.Request.URL.Path))
+		ctx.Abort()
+		return false
+	}
+	return true
+}
+
+func abortWithScopeError(ctx *gin.Context, err error) {
+	if errors.Is(err, ErrScopeNotFound) {
+		ctx.JSON(http.StatusNotFound, shared.NewNotFoundResponse(err.Error(), ctx.Request.URL.Path))
+	} else {
+		ctx.JSON(http.StatusInternalServerError, shared.NewInternalServerErrorResponse(err.Error(), ctx.Request.URL.Path))
+	}
+	ctx.Abort()
+}
@@ -0,0 +1,45 @@
+package oauth
+
+import (
+	"backend/config"
+	"log"
+	"regexp"
+	"strings"
+)
+
+var providerNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+/*
+LoadProviders
+Params: frontend address, which the default redirect URLs point to
+Returns: the configured providers by name
+Description: Read the OpenID Connect providers named in OIDC_PROVIDERS (comma-separated, e.g. "google,keycloak").
+A provider named keycloak is configured with OIDC_KEYCLOAK_ISSUER, OIDC_KEYCLOAK_CLIENT_ID and
+OIDC_KEYCLOAK_CLIENT_SECRET, and optionally OIDC_KEYCLOAK_SCOPES and OIDC_KEYCLOAK_REDIRECT_URL, which
+defaults to the app's /auth/callback/keycloak page. Providers missing an issuer or client id are skipped.
+*/
+func LoadProviders(appURL string) map[string]IdentityProvider {
+	providers := map[string]IdentityProvider{}
+	for _, name := range config.GetEnvList("OIDC_PROVIDERS", nil) {
+		name = strings.ToLower(name)
+		if !providerNamePattern.MatchString(name) {
+			log.Printf("Warning: skipping OIDC provider %q, names may only hold letters, digits and dashes", name)
+			continue
+		}
+		prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+		providerConfig := OIDCConfig{
+			Name:         name,
+			Issuer:       config.GetEnv(prefix+"ISSUER", ""),
+			ClientId:     config.GetEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: config.GetEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  config.GetEnv(prefix+"REDIRECT_URL", strings.TrimSuffix(appURL, "/")+"/auth/callback/"+name),
+			Scopes:       config.GetEnvList(prefix+"SCOPES", nil),
+		}
+		if providerConfig.Issuer == "" || providerConfig.ClientId == "" {
+			log.Printf("Warning: skipping OIDC provider %q, %sISSUER and %sCLIENT_ID are required", name, prefix, prefix)
+			continue
+		}
+		providers[name] = NewOIDCProvider(providerConfig, nil)
+	}
+	return providers
+}
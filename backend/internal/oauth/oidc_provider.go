@@ -0,0 +1,344 @@
+package oauth
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// ErrIdentityRejected is returned, wrapped with the reason, when the provider's answer cannot be trusted
+var ErrIdentityRejected = errors.New("identity provider response rejected")
+
+// OIDCConfig configures a generic OpenID Connect provider
+type OIDCConfig struct {
+	Name string
+	// Issuer is the provider's issuer URL; its endpoints are discovered from Issuer/.well-known/openid-configuration
+	Issuer       string
+	ClientId     string
+	ClientSecret string
+	// RedirectURL is where the provider sends the user back to, as registered with the provider
+	RedirectURL string
+	Scopes      []string
+}
+
+// OIDCProvider signs users in with any OpenID Connect provider. Endpoints and signing keys are
+// fetched when first needed, so the provider does not have to be reachable at startup.
+type OIDCProvider struct {
+	config OIDCConfig
+	client *http.Client
+
+	mutex     sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]interface{}
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// idTokenClaims are the ID token claims we use (OpenID Connect Core 1.0, section 2)
+type idTokenClaims struct {
+	Nonce           string       `json:"nonce"`
+	Email           string       `json:"email"`
+	EmailVerified   flexibleBool `json:"email_verified"`
+	Name            string       `json:"name"`
+	AuthorizedParty string       `json:"azp"`
+	jwt.RegisteredClaims
+}
+
+// flexibleBool accepts true and "true", as some providers send email_verified as a string
+type flexibleBool bool
+
+func (b *flexibleBool) UnmarshalJSON(data []byte) error {
+	*b = flexibleBool(strings.Trim(string(data), `"`) == "true")
+	return nil
+}
+
+func NewOIDCProvider(config OIDCConfig, client *http.Client) *OIDCProvider {
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &OIDCProvider{config: config, client: client}
+}
+
+func (p *OIDCProvider) Name() string {
+	return p.config.Name
+}
+
+func (p *OIDCProvider) AuthorizationURL(request AuthorizationRequest) (string, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{}
+	query.Set("response_type", "code")
+	query.Set("client_id", p.config.ClientId)
+	query.Set("redirect_uri", p.config.RedirectURL)
+	query.Set("scope", strings.Join(p.config.Scopes, " "))
+	query.Set("state", request.State)
+	query.Set("nonce", request.Nonce)
+	query.Set("code_challenge", request.CodeChallenge)
+	query.Set("code_challenge_method", "S256")
+
+	separator := "?"
+	if strings.Contains(discovery.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return discovery.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+/*
+Exchange
+Params: authorization code, PKCE code verifier, nonce sent in the authorization request
+Returns: the signed-in identity, error
+Description: Redeem the code at the token endpoint and verify the ID token in the response: it must be
+signed by one of the provider's published keys and name the provider as issuer, this client as
+audience and the login's nonce, and must not have expired.
+*/
+func (p *OIDCProvider) Exchange(code string, codeVerifier string, nonce string) (*Identity, error) {
+	discovery, err := p.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", p.config.RedirectURL)
+	form.Set("code_verifier", codeVerifier)
+	form.Set("client_id", p.config.ClientId)
+	request, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	request.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		request.SetBasicAuth(url.QueryEscape(p.config.ClientId), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	var tokens struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	status, err := p.doJSON(request, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK || tokens.Error != "" {
+		return nil, fmt.Errorf("%w: token endpoint answered %d %s %s", ErrIdentityRejected, status, tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IdToken == "" {
+		return nil, fmt.Errorf("%w: no id_token in token response", ErrIdentityRejected)
+	}
+	return p.verifyIdToken(tokens.IdToken, nonce)
+}
+
+func (p *OIDCProvider) verifyIdToken(idToken string, nonce string) (*Identity, error) {
+	claims := &idTokenClaims{}
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{"RS256", "RS384", "RS512", "ES256", "ES384", "EdDSA"}))
+	if _, err := parser.ParseWithClaims(idToken, claims, p.keyFunc); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrIdentityRejected, err)
+	}
+
+	switch {
+	case !claims.VerifyIssuer(p.config.Issuer, true):
+		return nil, fmt.Errorf("%w: unexpected issuer", ErrIdentityRejected)
+	case !claims.VerifyAudience(p.config.ClientId, true):
+		return nil, fmt.Errorf("%w: token is not meant for this client", ErrIdentityRejected)
+	case len(claims.Audience) > 1 && claims.AuthorizedParty != p.config.ClientId:
+		return nil, fmt.Errorf("%w: token was issued to another client", ErrIdentityRejected)
+	case claims.ExpiresAt == nil:
+		return nil, fmt.Errorf("%w: exp is required", ErrIdentityRejected)
+	case claims.Nonce == "" || claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce does not match", ErrIdentityRejected)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: sub is required", ErrIdentityRejected)
+	}
+	return &Identity{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          claims.Name,
+	}, nil
+}
+
+// keyFunc finds the provider key named by the token's kid, fetching the keys again once when it is unknown
+func (p *OIDCProvider) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	p.mutex.Lock()
+	key, ok := p.keys[kid]
+	p.mutex.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	// The provider may have rotated its keys since we fetched them
+	if err := p.fetchKeys(); err != nil {
+		return nil, err
+	}
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if key, ok := p.keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+func (p *OIDCProvider) discover() (*discoveryDocument, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	request, err := http.NewRequest(http.MethodGet, strings.TrimSuffix(p.config.Issuer, "/")+"/.well-known/openid-configuration", nil)
+	if err != nil {
+		return nil, err
+	}
+	var discovery discoveryDocument
+	status, err := p.doJSON(request, &discovery)
+	if err != nil {
+		return nil, err
+	}
+	if status != http.StatusOK {
+		return nil, fmt.Errorf("OIDC discovery for %s answered %d", p.config.Name, status)
+	}
+	if discovery.Issuer != p.config.Issuer {
+		return nil, fmt.Errorf("OIDC discovery for %s names issuer %q, expected %q", p.config.Name, discovery.Issuer, p.config.Issuer)
+	}
+	if discovery.AuthorizationEndpoint == "" || discovery.TokenEndpoint == "" || discovery.JWKSURI == "" {
+		return nil, fmt.Errorf("OIDC discovery for %s is missing endpoints", p.config.Name)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) fetchKeys() error {
+	discovery, err := p.discover()
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodGet, discovery.JWKSURI, nil)
+	if err != nil {
+		return err
+	}
+	var jwks struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	status, err := p.doJSON(request, &jwks)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK {
+		return fmt.Errorf("JWKS of %s answered %d", p.config.Name, status)
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		// Keys of types we do not support are skipped; tokens signed with them are rejected
+		if key, err := jwk.publicKey(); err == nil {
+			keys[jwk.KeyId] = key
+		}
+	}
+	p.mutex.Lock()
+	p.keys = keys
+	p.mutex.Unlock()
+	return nil
+}
+
+func (p *OIDCProvider) doJSON(request *http.Request, target interface{}) (int, error) {
+	response, err := p.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return 0, err
+	}
+	if err := json.Unmarshal(body, target); err != nil && response.StatusCode == http.StatusOK {
+		return response.StatusCode, fmt.Errorf("invalid JSON from %s: %w", request.URL.Host, err)
+	}
+	return response.StatusCode, nil
+}
+
+// jsonWebKey is a public key as published in a JWKS (RFC 7517)
+type jsonWebKey struct {
+	KeyType string `json:"kty"`
+	KeyId   string `json:"kid"`
+	Use     string `json:"use"`
+	N       string `json:"n"`
+	E       string `json:"e"`
+	Curve   string `json:"crv"`
+	X       string `json:"x"`
+	Y       string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.KeyType {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Curve {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	case "OKP":
+		if k.Curve != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", k.Curve)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key")
+		}
+		return ed25519.PublicKey(x), nil
+	}
+	return nil, fmt.Errorf("unsupported key type %q", k.KeyType)
+}
@@ -0,0 +1,293 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	mockClientId     = "hotel-booking"
+	mockClientSecret = "client-secret"
+	mockRedirectURL  = "http://app.test/auth/callback/mock"
+)
+
+type mockGrant struct {
+	redirectURI   string
+	codeChallenge string
+	nonce         string
+}
+
+// mockOIDCServer is a small OpenID Connect provider. Its authorization endpoint signs the user in straight
+// away and redirects back with a code; its token endpoint checks the PKCE verifier before issuing an ID token.
+type mockOIDCServer struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+	// claims are added to, or override, the claims of issued ID tokens
+	claims jwt.MapClaims
+	// signingKey signs ID tokens instead of key when set
+	signingKey *rsa.PrivateKey
+
+	mutex  sync.Mutex
+	grants map[string]mockGrant
+}
+
+func generateRSAKey(t *testing.T) *rsa.PrivateKey {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	return key
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	mock := &mockOIDCServer{key: generateRSAKey(t), kid: "mock-1", claims: jwt.MapClaims{}, grants: map[string]mockGrant{}}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 mock.server.URL,
+			"authorization_endpoint": mock.server.URL + "/authorize",
+			"token_endpoint":         mock.server.URL + "/token",
+			"jwks_uri":               mock.server.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/authorize", mock.authorize)
+	mux.HandleFunc("/token", mock.token)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		mock.mutex.Lock()
+		defer mock.mutex.Unlock()
+		public := mock.key.PublicKey
+		writeJSON(w, http.StatusOK, map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": mock.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}}})
+	})
+	mock.server = httptest.NewServer(mux)
+	t.Cleanup(mock.server.Close)
+	return mock
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func (m *mockOIDCServer) authorize(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	if query.Get("client_id") != mockClientId || query.Get("response_type") != "code" || query.Get("code_challenge_method") != "S256" {
+		http.Error(w, "bad authorization request", http.StatusBadRequest)
+		return
+	}
+	code := "code-" + query.Get("state")
+	m.mutex.Lock()
+	m.grants[code] = mockGrant{redirectURI: query.Get("redirect_uri"), codeChallenge: query.Get("code_challenge"), nonce: query.Get("nonce")}
+	m.mutex.Unlock()
+	http.Redirect(w, r, query.Get("redirect_uri")+"?"+url.Values{"code": {code}, "state": {query.Get("state")}}.Encode(), http.StatusFound)
+}
+
+func (m *mockOIDCServer) token(w http.ResponseWriter, r *http.Request) {
+	clientId, secret, ok := r.BasicAuth()
+	if !ok || clientId != mockClientId || secret != mockClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	m.mutex.Lock()
+	grant, ok := m.grants[r.PostFormValue("code")]
+	delete(m.grants, r.PostFormValue("code"))
+	signingKey, kid := m.key, m.kid
+	m.mutex.Unlock()
+	if !ok || r.PostFormValue("grant_type") != "authorization_code" || r.PostFormValue("redirect_uri") != grant.redirectURI ||
+		CodeChallenge(r.PostFormValue("code_verifier")) != grant.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.server.URL,
+		"aud":            mockClientId,
+		"sub":            "mock-user-1",
+		"email":          "guest@example.com",
+		"email_verified": true,
+		"name":           "Guest User",
+		"nonce":          grant.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+	for name, value := range m.claims {
+		if value == nil {
+			delete(claims, name)
+		} else {
+			claims[name] = value
+		}
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	if m.signingKey != nil {
+		signingKey = m.signingKey
+	}
+	idToken, err := token.SignedString(signingKey)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "opaque", "token_type": "Bearer", "id_token": idToken})
+}
+
+func newMockProvider(mock *mockOIDCServer) *OIDCProvider {
+	return NewOIDCProvider(OIDCConfig{
+		Name:         "mock",
+		Issuer:       mock.server.URL,
+		ClientId:     mockClientId,
+		ClientSecret: mockClientSecret,
+		RedirectURL:  mockRedirectURL,
+	}, mock.server.Client())
+}
+
+// signIn follows the provider's authorization URL like a browser would and returns the code it redirects back with
+func signIn(t *testing.T, provider *OIDCProvider, state string, nonce string, verifier string) string {
+	authorizationURL, err := provider.AuthorizationURL(AuthorizationRequest{State: state, Nonce: nonce, CodeChallenge: CodeChallenge(verifier)})
+	if err != nil {
+		t.Fatalf("Failed to build authorization URL: %v", err)
+	}
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+	response, err := client.Get(authorizationURL)
+	if err != nil {
+		t.Fatalf("Failed to sign in: %v", err)
+	}
+	response.Body.Close()
+	location, err := url.Parse(response.Header.Get("Location"))
+	if err != nil || response.StatusCode != http.StatusFound {
+		t.Fatalf("Sign in did not redirect back: %d %v", response.StatusCode, err)
+	}
+	assert.Equal(t, state, location.Query().Get("state"))
+	return location.Query().Get("code")
+}
+
+func TestOIDCProvider_AuthorizationCodeFlowWithPKCE(t *testing.T) {
+	mock := newMockOIDCServer(t)
+	provider := newMockProvider(mock)
+	verifier, err := NewCodeVerifier()
+	assert.NoError(t, err)
+
+	authorizationURL, err := provider.AuthorizationURL(AuthorizationRequest{State: "state-1", Nonce: "nonce-1", CodeChallenge: CodeChallenge(verifier)})
+	assert.NoError(t, err)
+	parsed, err := url.Parse(authorizationURL)
+	assert.NoError(t, err)
+	assert.Equal(t, "/authorize", parsed.Path)
+	assert.Equal(t, "openid email profile", parsed.Query().Get("scope"))
+	assert.Equal(t, mockRedirectURL, parsed.Query().Get("redirect_uri"))
+	assert.Equal(t, CodeChallenge(verifier), parsed.Query().Get("code_challenge"))
+
+	code := signIn(t, provider, "state-1", "nonce-1", verifier)
+	identity, err := provider.Exchange(code, verifier, "nonce-1")
+	assert.NoError(t, err)
+	assert.Equal(t, &Identity{Subject: "mock-user-1", Email: "guest@example.com", EmailVerified: true, Name: "Guest User"}, identity)
+
+	// Codes are single use
+	_, err = provider.Exchange(code, verifier, "nonce-1")
+	assert.ErrorIs(t, err, ErrIdentityRejected)
+}
+
+func TestOIDCProvider_RejectsWrongCodeVerifier(t *testing.T) {
+	mock := newMockOIDCServer(t)
+	provider := newMockProvider(mock)
+	verifier, _ := NewCodeVerifier()
+	other, _ := NewCodeVerifier()
+
+	code := signIn(t, provider, "state-1", "nonce-1", verifier)
+	_, err := provider.Exchange(code, other, "nonce-1")
+	assert.ErrorIs(t, err, ErrIdentityRejected)
+}
+
+func TestOIDCProvider_RejectsUntrustworthyIdTokens(t *testing.T) {
+	tests := []struct {
+		name       string
+		claims     jwt.MapClaims
+		nonce      string
+		signingKey bool
+	}{
+		{name: "nonce of another login", nonce: "other-nonce"},
+		{name: "no nonce", claims: jwt.MapClaims{"nonce": nil}},
+		{name: "another issuer", claims: jwt.MapClaims{"iss": "https://evil.example.com"}},
+		{name: "another audience", claims: jwt.MapClaims{"aud": "someone-else"}},
+		{name: "issued to another party", claims: jwt.MapClaims{"aud": []string{mockClientId, "someone-else"}, "azp": "someone-else"}},
+		{name: "expired", claims: jwt.MapClaims{"exp": time.Now().Add(-time.Minute).Unix()}},
+		{name: "no expiry", claims: jwt.MapClaims{"exp": nil}},
+		{name: "no subject", claims: jwt.MapClaims{"sub": nil}},
+		{name: "signed with an unpublished key", signingKey: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mock := newMockOIDCServer(t)
+			if test.claims != nil {
+				mock.claims = test.claims
+			}
+			if test.signingKey {
+				mock.signingKey = generateRSAKey(t)
+			}
+			provider := newMockProvider(mock)
+			verifier, _ := NewCodeVerifier()
+			nonce := test.nonce
+			if nonce == "" {
+				nonce = "nonce-1"
+			}
+
+			code := signIn(t, provider, "state-1", "nonce-1", verifier)
+			_, err := provider.Exchange(code, verifier, nonce)
+			assert.ErrorIs(t, err, ErrIdentityRejected)
+		})
+	}
+}
+
+func TestOIDCProvider_RefetchesRotatedKeys(t *testing.T) {
+	mock := newMockOIDCServer(t)
+	provider := newMockProvider(mock)
+	verifier, _ := NewCodeVerifier()
+
+	_, err := provider.Exchange(signIn(t, provider, "state-1", "nonce-1", verifier), verifier, "nonce-1")
+	assert.NoError(t, err)
+
+	mock.mutex.Lock()
+	mock.key, mock.kid = generateRSAKey(t), "mock-2"
+	mock.mutex.Unlock()
+	identity, err := provider.Exchange(signIn(t, provider, "state-2", "nonce-2", verifier), verifier, "nonce-2")
+	assert.NoError(t, err)
+	assert.Equal(t, "mock-user-1", identity.Subject)
+}
+
+func TestOIDCProvider_EmailVerifiedAsString(t *testing.T) {
+	mock := newMockOIDCServer(t)
+	mock.claims = jwt.MapClaims{"email_verified": "false"}
+	provider := newMockProvider(mock)
+	verifier, _ := NewCodeVerifier()
+
+	identity, err := provider.Exchange(signIn(t, provider, "state-1", "nonce-1", verifier), verifier, "nonce-1")
+	assert.NoError(t, err)
+	assert.False(t, identity.EmailVerified)
+}
+
+func TestOIDCProvider_DiscoveryMustNameTheIssuer(t *testing.T) {
+	mock := newMockOIDCServer(t)
+	provider := NewOIDCProvider(OIDCConfig{Name: "mock", Issuer: mock.server.URL + "/", ClientId: mockClientId}, mock.server.Client())
+
+	_, err := provider.AuthorizationURL(AuthorizationRequest{State: "state-1", Nonce: "nonce-1"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type AddOnRepository interface {
+	CreateAddOn(addOn *domain.AddOn) error
+	UpdateAddOn(addOn *domain.AddOn) error
+	GetAddOnById(id string) (*domain.AddOn, error)
+	GetAddOnsByHotelId(hotelId string, activeOnly bool) ([]domain.AddOn, error)
+}
+
+type addOnRepository struct {
+	db *gorm.DB
+}
+
+func NewAddOnRepository(db *gorm.DB) AddOnRepository {
+	return &addOnRepository{db: db}
+}
+
+func (r *addOnRepository) CreateAddOn(addOn *domain.AddOn) error {
+	return r.db.Create(addOn).Error
+}
+
+func (r *addOnRepository) UpdateAddOn(addOn *domain.AddOn) error {
+	return r.db.Save(addOn).Error
+}
+
+func (r *addOnRepository) GetAddOnById(id string) (*domain.AddOn, error) {
+	var addOn domain.AddOn
+	if err := r.db.First(&addOn, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &addOn, nil
+}
+
+func (r *addOnRepository) GetAddOnsByHotelId(hotelId string, activeOnly bool) ([]domain.AddOn, error) {
+	var addOns []domain.AddOn
+	query := r.db.Where("hotel_id = ?", hotelId)
+	if activeOnly {
+		query = query.Where("active = ?", true)
+	}
+	if err := query.Order("name ASC").Find(&addOns).Error; err != nil {
+		return nil, err
+	}
+	return addOns, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type APIKeyRepository interface {
+	CreateKey(key *domain.APIKey) error
+	GetKeyById(id string) (*domain.APIKey, error)
+	GetKeyByHash(keyHash string) (*domain.APIKey, error)
+	GetKeys(userId string) ([]domain.APIKey, error)
+	RevokeKey(id string, revokedAt time.Time) error
+	UseKey(id string, window int64, usedAt time.Time) (bool, error)
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) CreateKey(key *domain.APIKey) error {
+	return r.db.Create(key).Error
+}
+
+func (r *apiKeyRepository) GetKeyById(id string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.First(&key, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetKeyByHash(keyHash string) (*domain.APIKey, error) {
+	var key domain.APIKey
+	if err := r.db.First(&key, "key_hash = ?", keyHash).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// GetKeys lists the user's keys, or every key when userId is empty, newest first
+func (r *apiKeyRepository) GetKeys(userId string) ([]domain.APIKey, error) {
+	query := r.db.Order("created_at DESC")
+	if userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	var keys []domain.APIKey
+	if err := query.Find(&keys).Error; err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (r *apiKeyRepository) RevokeKey(id string, revokedAt time.Time) error {
+	return r.db.Model(&domain.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt).Error
+}
+
+/*
+UseKey
+Params: key id, the minute being counted in (Unix time / 60), time of the request
+Returns: false when the key has used up its rate limit for the minute, error
+Description: Count the request and record when the key was last used in one atomic update, so requests
+racing on several servers are all counted. The count starts again in each new minute.
+*/
+func (r *apiKeyRepository) UseKey(id string, window int64, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.APIKey{}).
+		Where("id = ? AND (rate_limit = 0 OR rate_window <> ? OR rate_count < rate_limit)", id, window).
+		Updates(map[string]interface{}{
+			"rate_count":   gorm.Expr("CASE WHEN rate_window = ? THEN rate_count + 1 ELSE 1 END", window),
+			"rate_window":  window,
+			"last_used_at": usedAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
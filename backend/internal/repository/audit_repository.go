@@ -0,0 +1,34 @@
+package repository
+
+import (
+	"backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository interface {
+	CreateEntry(entry *domain.AuditEntry) error
+	GetEntriesByEntity(entityType string, entityId string) ([]domain.AuditEntry, error)
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+func (r *auditRepository) CreateEntry(entry *domain.AuditEntry) error {
+	return r.db.Create(entry).Error
+}
+
+// GetEntriesByEntity returns an entity's audit trail, oldest first
+func (r *auditRepository) GetEntriesByEntity(entityType string, entityId string) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	err := r.db.Where("entity_type = ? AND entity_id = ?", entityType, entityId).Order("created_at ASC").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -1,14 +1,290 @@
 package repository
 
-import "gorm.io/gorm"
+import (
+	"backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
 
 type AuthRepository struct {
 	db *gorm.DB
 }
 
 type IAuthRepository interface {
+	CreateRefreshToken(token *domain.RefreshToken) error
+	GetRefreshTokenById(id string) (*domain.RefreshToken, error)
+	RotateRefreshToken(id string, replacement *domain.RefreshToken, rotatedAt time.Time) (bool, error)
+	RevokeRefreshTokenFamily(familyId string, revokedAt time.Time) error
+	RevokeUserRefreshTokens(userId string, revokedAt time.Time) (int64, error)
+	CreatePasswordResetToken(token *domain.PasswordResetToken) error
+	GetPasswordResetTokenByHash(tokenHash string) (*domain.PasswordResetToken, error)
+	GetLatestPasswordResetToken(userId string) (*domain.PasswordResetToken, error)
+	ResetPassword(token *domain.PasswordResetToken, passwordHash string, resetAt time.Time) (bool, error)
+	CreateEmailVerificationToken(token *domain.EmailVerificationToken) error
+	GetEmailVerificationTokenByHash(tokenHash string) (*domain.EmailVerificationToken, error)
+	GetLatestEmailVerificationToken(userId string) (*domain.EmailVerificationToken, error)
+	VerifyEmail(token *domain.EmailVerificationToken, verifiedAt time.Time) (bool, error)
+	CreateMFAChallenge(challenge *domain.MFAChallenge) error
+	GetMFAChallengeByHash(tokenHash string) (*domain.MFAChallenge, error)
+	RecordMFAChallengeFailure(id string) error
+	UseMFAChallenge(id string, usedAt time.Time) (bool, error)
+	GetLoginThrottles(ids []string) ([]domain.LoginThrottle, error)
+	RecordLoginFailure(id string, failedAt time.Time, forgetBefore time.Time) (*domain.LoginThrottle, error)
+	LockLogin(id string, until time.Time) error
+	ClearLoginThrottle(id string) error
 }
 
 func NewAuthRepository(db *gorm.DB) IAuthRepository {
 	return &AuthRepository{db: db}
 }
+
+func (r *AuthRepository) CreateRefreshToken(token *domain.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *AuthRepository) GetRefreshTokenById(id string) (*domain.RefreshToken, error) {
+	var token domain.RefreshToken
+	if err := r.db.First(&token, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+/*
+RotateRefreshToken
+Params: id of the token being exchanged, its replacement, time of the exchange
+Returns: false when the token was already rotated or revoked, error
+Description: Mark the token rotated and store its replacement in one transaction. The token is only
+marked while still live, so when two requests race with the same token only one gets a replacement.
+*/
+func (r *AuthRepository) RotateRefreshToken(id string, replacement *domain.RefreshToken, rotatedAt time.Time) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.RefreshToken{}).
+			Where("id = ? AND rotated_at IS NULL AND revoked_at IS NULL", id).
+			Updates(map[string]interface{}{"rotated_at": rotatedAt, "replaced_by_id": replacement.Id})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		rotated = true
+		return tx.Create(replacement).Error
+	})
+	return rotated && err == nil, err
+}
+
+// RevokeRefreshTokenFamily revokes every token of the session the family stands for
+func (r *AuthRepository) RevokeRefreshTokenFamily(familyId string, revokedAt time.Time) error {
+	return r.db.Model(&domain.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyId).
+		Update("revoked_at", revokedAt).Error
+}
+
+// RevokeUserRefreshTokens revokes every refresh token of the user, returning how many sessions were still usable
+func (r *AuthRepository) RevokeUserRefreshTokens(userId string, revokedAt time.Time) (int64, error) {
+	var live int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL AND rotated_at IS NULL AND expires_at > ?", userId, revokedAt).
+			Count(&live).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userId).
+			Update("revoked_at", revokedAt).Error
+	})
+	return live, err
+}
+
+func (r *AuthRepository) CreatePasswordResetToken(token *domain.PasswordResetToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *AuthRepository) GetPasswordResetTokenByHash(tokenHash string) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+	if err := r.db.First(&token, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *AuthRepository) GetLatestPasswordResetToken(userId string) (*domain.PasswordResetToken, error) {
+	var token domain.PasswordResetToken
+	if err := r.db.Where("user_id = ?", userId).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+/*
+ResetPassword
+Params: reset token being used, new password hash, time of the reset
+Returns: false when the token was already used, error
+Description: In one transaction, use up the token along with every other open reset token of the user,
+set the new password and revoke all the user's refresh tokens so every session has to log in again.
+*/
+func (r *AuthRepository) ResetPassword(token *domain.PasswordResetToken, passwordHash string, resetAt time.Time) (bool, error) {
+	reset := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.PasswordResetToken{}).
+			Where("id = ? AND used_at IS NULL", token.Id).
+			Update("used_at", resetAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		err := tx.Model(&domain.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserId).
+			Update("used_at", resetAt).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&domain.User{}).
+			Where("id = ?", token.UserId).
+			Updates(map[string]interface{}{"password": passwordHash, "updated_at": resetAt}).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Model(&domain.RefreshToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", token.UserId).
+			Update("revoked_at", resetAt).Error
+		if err != nil {
+			return err
+		}
+		reset = true
+		return nil
+	})
+	return reset && err == nil, err
+}
+
+func (r *AuthRepository) CreateEmailVerificationToken(token *domain.EmailVerificationToken) error {
+	return r.db.Create(token).Error
+}
+
+func (r *AuthRepository) GetEmailVerificationTokenByHash(tokenHash string) (*domain.EmailVerificationToken, error) {
+	var token domain.EmailVerificationToken
+	if err := r.db.First(&token, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *AuthRepository) GetLatestEmailVerificationToken(userId string) (*domain.EmailVerificationToken, error) {
+	var token domain.EmailVerificationToken
+	if err := r.db.Where("user_id = ?", userId).Order("created_at DESC").First(&token).Error; err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+/*
+VerifyEmail
+Params: verification token being used, time of the verification
+Returns: false when the token was already used, error
+Description: In one transaction, use up the token along with every other open verification token of the
+user and mark the user's email verified.
+*/
+func (r *AuthRepository) VerifyEmail(token *domain.EmailVerificationToken, verifiedAt time.Time) (bool, error) {
+	verified := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.EmailVerificationToken{}).
+			Where("id = ? AND used_at IS NULL", token.Id).
+			Update("used_at", verifiedAt)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		err := tx.Model(&domain.EmailVerificationToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserId).
+			Update("used_at", verifiedAt).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&domain.User{}).
+			Where("id = ? AND email_verified_at IS NULL", token.UserId).
+			Update("email_verified_at", verifiedAt).Error
+		if err != nil {
+			return err
+		}
+		verified = true
+		return nil
+	})
+	return verified && err == nil, err
+}
+
+func (r *AuthRepository) CreateMFAChallenge(challenge *domain.MFAChallenge) error {
+	return r.db.Create(challenge).Error
+}
+
+func (r *AuthRepository) GetMFAChallengeByHash(tokenHash string) (*domain.MFAChallenge, error) {
+	var challenge domain.MFAChallenge
+	if err := r.db.First(&challenge, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &challenge, nil
+}
+
+// RecordMFAChallengeFailure counts a wrong code sent for the challenge
+func (r *AuthRepository) RecordMFAChallengeFailure(id string) error {
+	return r.db.Model(&domain.MFAChallenge{}).Where("id = ?", id).Update("attempts", gorm.Expr("attempts + 1")).Error
+}
+
+// UseMFAChallenge marks the challenge used, returning false when it already was
+func (r *AuthRepository) UseMFAChallenge(id string, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.MFAChallenge{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *AuthRepository) GetLoginThrottles(ids []string) ([]domain.LoginThrottle, error) {
+	var throttles []domain.LoginThrottle
+	if err := r.db.Where("id IN ?", ids).Find(&throttles).Error; err != nil {
+		return nil, err
+	}
+	return throttles, nil
+}
+
+/*
+RecordLoginFailure
+Params: throttle id, time of the failed login, time before which earlier failures are forgotten
+Returns: the throttle with the failure counted, error
+Description: Count the failure with an atomic upsert so concurrent attempts are all counted. Failures from
+before forgetBefore, or from before a lockout that has ended, start the count again.
+*/
+func (r *AuthRepository) RecordLoginFailure(id string, failedAt time.Time, forgetBefore time.Time) (*domain.LoginThrottle, error) {
+	var throttle domain.LoginThrottle
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.LoginThrottle{}).
+			Where("id = ? AND (last_failure_at < ? OR locked_until <= ?)", id, forgetBefore, failedAt).
+			Updates(map[string]interface{}{"failures": 0, "locked_until": nil}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"failures":        gorm.Expr("login_throttles.failures + 1"),
+				"last_failure_at": failedAt,
+			}),
+		}).Create(&domain.LoginThrottle{Id: id, Failures: 1, LastFailureAt: failedAt}).Error
+		if err != nil {
+			return err
+		}
+		return tx.First(&throttle, "id = ?", id).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &throttle, nil
+}
+
+func (r *AuthRepository) LockLogin(id string, until time.Time) error {
+	return r.db.Model(&domain.LoginThrottle{}).Where("id = ?", id).Update("locked_until", until).Error
+}
+
+// ClearLoginThrottle forgets the failures counted for the id, lifting any lockout
+func (r *AuthRepository) ClearLoginThrottle(id string) error {
+	return r.db.Where("id = ?", id).Delete(&domain.LoginThrottle{}).Error
+}
@@ -2,17 +2,35 @@ package repository
 
 import (
 	"backend/internal/domain"
+	"errors"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type BookingRepository interface {
 	CreateBooking(booking *domain.Booking) error
+	CreateBookingIfRoomFree(booking *domain.Booking, claim *domain.WaitlistEntry) error
 	GetAllBookings() ([]domain.Booking, error)
 	GetBookingById(id string) (*domain.Booking, error)
 	GetBookingsByUserId(userId string) ([]domain.Booking, error)
+	GetBookingsByHotelId(hotelId string) ([]domain.Booking, error)
+	UpdateBooking(booking *domain.Booking) error
+	HasOverlappingBooking(roomId string, checkIn time.Time, checkOut time.Time) (bool, error)
+	GetUnarrivedBookings(checkInBefore time.Time) ([]domain.Booking, error)
+	AddBookingAddOn(booking *domain.Booking, addOn *domain.BookingAddOn) error
+	RemoveBookingAddOn(booking *domain.Booking, addOn *domain.BookingAddOn) error
 }
 
+var (
+	// ErrRoomTaken is returned by CreateBookingIfRoomFree when a booking or another guest's open waitlist offer
+	// already holds one of the nights
+	ErrRoomTaken = errors.New("room is already booked for the selected dates")
+	// ErrOfferClosed is returned by CreateBookingIfRoomFree when the claimed waitlist offer is no longer open
+	ErrOfferClosed = errors.New("waitlist offer is no longer open")
+)
+
 type bookingRepository struct {
 	db *gorm.DB
 }
@@ -25,9 +43,58 @@ func (r *bookingRepository) CreateBooking(booking *domain.Booking) error {
 	return r.db.Create(booking).Error
 }
 
+/*
+CreateBookingIfRoomFree
+Params: booking to create, waitlist entry whose offer the booking claims or nil
+Returns: ErrRoomTaken or ErrOfferClosed when the booking cannot be made, error
+Description: Lock the room, check no booking or open waitlist offer to another user holds any of its nights
+and create the booking in one transaction, so two requests for the same room cannot both book it. A claimed
+offer is closed in the same transaction, so the booking and the claim happen together or not at all.
+*/
+func (r *bookingRepository) CreateBookingIfRoomFree(booking *domain.Booking, claim *domain.WaitlistEntry) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var room domain.Room
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&room, "id = ?", booking.RoomId).Error; err != nil {
+			return err
+		}
+		taken, err := hasOverlappingBooking(tx, booking.RoomId.String(), booking.CheckInDate, booking.CheckOutDate)
+		if err != nil {
+			return err
+		}
+		if taken {
+			return ErrRoomTaken
+		}
+
+		var held int64
+		err = tx.Model(&domain.WaitlistEntry{}).
+			Where("room_id = ? AND status = ? AND user_id <> ? AND offer_expires_at > ? AND check_in_date < ? AND check_out_date > ?",
+				booking.RoomId, domain.WaitlistStatusOffered, booking.UserId, time.Now(), booking.CheckOutDate, booking.CheckInDate).
+			Count(&held).Error
+		if err != nil {
+			return err
+		}
+		if held > 0 {
+			return ErrRoomTaken
+		}
+
+		if claim != nil {
+			result := tx.Model(&domain.WaitlistEntry{}).
+				Where("id = ? AND status = ? AND offer_token_hash = ?", claim.Id, domain.WaitlistStatusOffered, claim.OfferTokenHash).
+				Updates(map[string]interface{}{"status": domain.WaitlistStatusClaimed, "offer_token_hash": "", "offer_expires_at": nil})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrOfferClosed
+			}
+		}
+		return tx.Create(booking).Error
+	})
+}
+
 func (r *bookingRepository) GetAllBookings() ([]domain.Booking, error) {
 	var bookings []domain.Booking
-	if err := r.db.Find(&bookings).Error; err != nil {
+	if err := r.db.Preload("AddOns").Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -35,7 +102,16 @@ func (r *bookingRepository) GetAllBookings() ([]domain.Booking, error) {
 
 func (r *bookingRepository) GetBookingsByUserId(userId string) ([]domain.Booking, error) {
 	var bookings []domain.Booking
-	if err := r.db.Where("user_id = ?", userId).Find(&bookings).Error; err != nil {
+	if err := r.db.Preload("AddOns").Where("user_id = ?", userId).Find(&bookings).Error; err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// GetBookingsByHotelId returns a hotel's bookings ordered by arrival
+func (r *bookingRepository) GetBookingsByHotelId(hotelId string) ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	if err := r.db.Preload("AddOns").Where("hotel_id = ?", hotelId).Order("check_in_date ASC").Find(&bookings).Error; err != nil {
 		return nil, err
 	}
 	return bookings, nil
@@ -43,8 +119,65 @@ func (r *bookingRepository) GetBookingsByUserId(userId string) ([]domain.Booking
 
 func (r *bookingRepository) GetBookingById(id string) (*domain.Booking, error) {
 	var booking domain.Booking
-	if err := r.db.First(&booking, "id = ?", id).Error; err != nil {
+	if err := r.db.Preload("AddOns").First(&booking, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &booking, nil
 }
+
+// UpdateBooking saves the booking's own columns; add-ons are changed through AddBookingAddOn and RemoveBookingAddOn
+func (r *bookingRepository) UpdateBooking(booking *domain.Booking) error {
+	return r.db.Omit("AddOns").Save(booking).Error
+}
+
+// HasOverlappingBooking reports whether a booking still holds the room for any night in the range.
+// Cancelled bookings and no-shows have given their nights back.
+func (r *bookingRepository) HasOverlappingBooking(roomId string, checkIn time.Time, checkOut time.Time) (bool, error) {
+	return hasOverlappingBooking(r.db, roomId, checkIn, checkOut)
+}
+
+func hasOverlappingBooking(db *gorm.DB, roomId string, checkIn time.Time, checkOut time.Time) (bool, error) {
+	var count int64
+	err := db.Model(&domain.Booking{}).
+		Where("room_id = ? AND is_cancelled = ? AND status <> ? AND check_in_date < ? AND check_out_date > ?", roomId, false, domain.BookingStatusNoShow, checkOut, checkIn).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetUnarrivedBookings returns active confirmed bookings whose check-in time is before the given time
+func (r *bookingRepository) GetUnarrivedBookings(checkInBefore time.Time) ([]domain.Booking, error) {
+	var bookings []domain.Booking
+	err := r.db.Preload("AddOns").
+		Where("status = ? AND is_cancelled = ? AND check_in_date < ?", domain.BookingStatusConfirmed, false, checkInBefore).
+		Order("check_in_date ASC").
+		Find(&bookings).Error
+	if err != nil {
+		return nil, err
+	}
+	return bookings, nil
+}
+
+// AddBookingAddOn attaches an add-on and raises the booking total in one transaction
+func (r *bookingRepository) AddBookingAddOn(booking *domain.Booking, addOn *domain.BookingAddOn) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(addOn).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Booking{}).Where("id = ?", booking.Id).
+			Update("total_price", gorm.Expr("total_price + ?", addOn.TotalPrice)).Error
+	})
+}
+
+// RemoveBookingAddOn detaches an add-on and lowers the booking total in one transaction
+func (r *bookingRepository) RemoveBookingAddOn(booking *domain.Booking, addOn *domain.BookingAddOn) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&domain.BookingAddOn{}, "id = ? AND booking_id = ?", addOn.Id, booking.Id).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Booking{}).Where("id = ?", booking.Id).
+			Update("total_price", gorm.Expr("total_price - ?", addOn.TotalPrice)).Error
+	})
+}
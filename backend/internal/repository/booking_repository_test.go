@@ -17,21 +17,41 @@ func setupBookingTestDB(t *testing.T) *gorm.DB {
 		t.Fatalf("Failed to connect to test database: %v", err)
 	}
 
-	// Create table manually for SQLite (no PostgreSQL-specific defaults)
+	// Create tables manually for SQLite (no PostgreSQL-specific defaults)
 	err = db.Exec(`
 		CREATE TABLE bookings (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			user_id TEXT,
 			hotel_id TEXT,
 			room_id TEXT,
 			check_in_date DATETIME,
 			check_out_date DATETIME,
+			guests INTEGER DEFAULT 1,
 			total_price REAL,
-			is_cancelled INTEGER DEFAULT 0
+			is_cancelled INTEGER DEFAULT 0,
+			status TEXT DEFAULT 'confirmed',
+			guest_name TEXT,
+			guest_email TEXT,
+			guest_phone TEXT,
+			guest_arrival_time TEXT,
+			guest_special_requests TEXT,
+			guest_structured_requests TEXT
+		);
+		CREATE TABLE booking_add_ons (
+			id TEXT PRIMARY KEY,
+			booking_id TEXT,
+			add_on_id TEXT,
+			name TEXT,
+			pricing TEXT,
+			unit_price REAL,
+			quantity INTEGER,
+			total_price REAL,
+			created_at DATETIME
 		)
 	`).Error
 	if err != nil {
-		t.Fatalf("Failed to create table: %v", err)
+		t.Fatalf("Failed to create tables: %v", err)
 	}
 
 	return db
@@ -181,3 +201,54 @@ func TestBookingRepository_GetBookingById(t *testing.T) {
 		})
 	}
 }
+
+func TestBookingRepository_HasOverlappingBooking(t *testing.T) {
+	db := setupBookingTestDB(t)
+	repo := NewBookingRepository(db)
+
+	roomId := uuid.New()
+	checkIn := time.Date(2030, 1, 10, 14, 0, 0, 0, time.UTC)
+	checkOut := checkIn.AddDate(0, 0, 3)
+
+	repo.CreateBooking(&domain.Booking{
+		Id:           uuid.New(),
+		UserId:       uuid.New(),
+		HotelId:      uuid.New(),
+		RoomId:       roomId,
+		CheckInDate:  checkIn,
+		CheckOutDate: checkOut,
+		TotalPrice:   300.0,
+	})
+	repo.CreateBooking(&domain.Booking{
+		Id:           uuid.New(),
+		UserId:       uuid.New(),
+		HotelId:      uuid.New(),
+		RoomId:       roomId,
+		CheckInDate:  checkIn.AddDate(0, 0, 10),
+		CheckOutDate: checkOut.AddDate(0, 0, 10),
+		TotalPrice:   300.0,
+		IsCancelled:  true,
+	})
+
+	tests := []struct {
+		name     string
+		roomId   uuid.UUID
+		checkIn  time.Time
+		checkOut time.Time
+		expected bool
+	}{
+		{name: "same dates", roomId: roomId, checkIn: checkIn, checkOut: checkOut, expected: true},
+		{name: "partial overlap", roomId: roomId, checkIn: checkIn.AddDate(0, 0, 2), checkOut: checkOut.AddDate(0, 0, 2), expected: true},
+		{name: "back to back", roomId: roomId, checkIn: checkOut, checkOut: checkOut.AddDate(0, 0, 2), expected: false},
+		{name: "cancelled booking ignored", roomId: roomId, checkIn: checkIn.AddDate(0, 0, 10), checkOut: checkOut.AddDate(0, 0, 10), expected: false},
+		{name: "other room", roomId: uuid.New(), checkIn: checkIn, checkOut: checkOut, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taken, err := repo.HasOverlappingBooking(tt.roomId.String(), tt.checkIn, tt.checkOut)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, taken)
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ExternalIdentityRepository interface {
+	CreateLoginState(state *domain.ExternalLoginState) error
+	GetLoginStateByHash(stateHash string) (*domain.ExternalLoginState, error)
+	UseLoginState(id string, usedAt time.Time) (bool, error)
+	GetIdentity(provider string, subject string) (*domain.ExternalIdentity, error)
+	LinkIdentity(identity *domain.ExternalIdentity, newUser *domain.User) error
+}
+
+// ErrIdentityNotLinked is returned by GetIdentity when no user is linked to the identity
+var ErrIdentityNotLinked = errors.New("identity is not linked to a user")
+
+type externalIdentityRepository struct {
+	db *gorm.DB
+}
+
+func NewExternalIdentityRepository(db *gorm.DB) ExternalIdentityRepository {
+	return &externalIdentityRepository{db: db}
+}
+
+func (r *externalIdentityRepository) CreateLoginState(state *domain.ExternalLoginState) error {
+	return r.db.Create(state).Error
+}
+
+func (r *externalIdentityRepository) GetLoginStateByHash(stateHash string) (*domain.ExternalLoginState, error) {
+	var state domain.ExternalLoginState
+	if err := r.db.First(&state, "state_hash = ?", stateHash).Error; err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
+// UseLoginState marks the login state used, returning false when it already was
+func (r *externalIdentityRepository) UseLoginState(id string, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.ExternalLoginState{}).Where("id = ? AND used_at IS NULL", id).Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *externalIdentityRepository) GetIdentity(provider string, subject string) (*domain.ExternalIdentity, error) {
+	var identity domain.ExternalIdentity
+	err := r.db.First(&identity, "provider = ? AND subject = ?", provider, subject).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIdentityNotLinked
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &identity, nil
+}
+
+/*
+LinkIdentity
+Params: identity to link, user to create for it or nil to link an existing user
+Returns: error
+Description: Create the user when there is one and store the link in one transaction.
+*/
+func (r *externalIdentityRepository) LinkIdentity(identity *domain.ExternalIdentity, newUser *domain.User) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if newUser != nil {
+			if err := tx.Create(newUser).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(identity).Error
+	})
+}
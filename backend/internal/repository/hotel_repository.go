@@ -2,7 +2,11 @@ package repository
 
 import (
 	"backend/internal/domain"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
@@ -10,6 +14,10 @@ type HotelRepository interface {
 	CreateHotel(hotel *domain.Hotel) error
 	GetAllHotels() ([]domain.Hotel, error)
 	GetHotelById(id string) (*domain.Hotel, error)
+	SearchHotels(filter *domain.HotelFilter) ([]domain.Hotel, error)
+	GetHotelLocations(filter *domain.HotelFilter) ([]domain.Hotel, error)
+	GetHotelFacets(filter *domain.HotelFilter, ratingBands []float64, priceEdges []float64) (*domain.HotelFacets, error)
+	GetFreeRoomNights(hotelId string, from time.Time, to time.Time) ([]domain.RoomNight, error)
 }
 
 type hotelRepository struct {
@@ -26,7 +34,7 @@ func (r *hotelRepository) CreateHotel(hotel *domain.Hotel) error {
 
 func (r *hotelRepository) GetAllHotels() ([]domain.Hotel, error) {
 	var hotels []domain.Hotel
-	if err := r.db.Preload("Rooms").Preload("Rooms.Facilities").Find(&hotels).Error; err != nil {
+	if err := r.withDetails().Find(&hotels).Error; err != nil {
 		return nil, err
 	}
 	return hotels, nil
@@ -34,8 +42,251 @@ func (r *hotelRepository) GetAllHotels() ([]domain.Hotel, error) {
 
 func (r *hotelRepository) GetHotelById(id string) (*domain.Hotel, error) {
 	var hotel domain.Hotel
-	if err := r.db.Preload("Rooms").Preload("Rooms.Facilities").First(&hotel, "id = ?", id).Error; err != nil {
+	if err := r.withDetails().First(&hotel, "id = ?", id).Error; err != nil {
 		return nil, err
 	}
 	return &hotel, nil
 }
+
+// SearchHotels loads hotels matching the filter. The bounding box is a plain range check,
+// so it works the same on Postgres and SQLite; exact distances are left to the caller.
+func (r *hotelRepository) SearchHotels(filter *domain.HotelFilter) ([]domain.Hotel, error) {
+	if filter.Ids != nil && len(filter.Ids) == 0 {
+		return []domain.Hotel{}, nil
+	}
+
+	var hotels []domain.Hotel
+	if err := applyHotelFilter(r.withDetails(), filter).Find(&hotels).Error; err != nil {
+		return nil, err
+	}
+	return hotels, nil
+}
+
+// GetHotelLocations loads only the id and coordinates of the hotels matching the filter
+func (r *hotelRepository) GetHotelLocations(filter *domain.HotelFilter) ([]domain.Hotel, error) {
+	if filter.Ids != nil && len(filter.Ids) == 0 {
+		return []domain.Hotel{}, nil
+	}
+
+	var hotels []domain.Hotel
+	err := applyHotelFilter(r.db.Model(&domain.Hotel{}), filter).
+		Select("hotels.id", "hotels.latitude", "hotels.longitude").
+		Find(&hotels).Error
+	if err != nil {
+		return nil, err
+	}
+	return hotels, nil
+}
+
+/*
+GetHotelFacets
+Params: filter, minimum ratings of the rating bands, lower edges of the price buckets in ascending order
+Returns: facet counts, error
+Description: Count the matching hotels per facility, rating band and price bucket with one grouped
+query each. Rating and price counts drop their own filter so every band keeps a count.
+*/
+func (r *hotelRepository) GetHotelFacets(filter *domain.HotelFilter, ratingBands []float64, priceEdges []float64) (*domain.HotelFacets, error) {
+	facets := &domain.HotelFacets{Facilities: []domain.FacetCount{}, Ratings: []domain.RatingFacet{}, Prices: []domain.PriceFacet{}}
+	if filter.Ids != nil && len(filter.Ids) == 0 {
+		for _, minRating := range ratingBands {
+			facets.Ratings = append(facets.Ratings, domain.RatingFacet{MinRating: minRating})
+		}
+		facets.Prices = priceFacets(priceEdges, nil)
+		return facets, nil
+	}
+
+	err := applyHotelFilter(r.db.Table("hotels"), filter).
+		Select("facilities.name AS value, COUNT(DISTINCT hotels.id) AS count").
+		Joins("JOIN rooms ON rooms.hotel_id = hotels.id").
+		Joins("JOIN room_facilities ON room_facilities.room_id = rooms.id").
+		Joins("JOIN facilities ON facilities.id = room_facilities.facility_id").
+		Group("facilities.name").
+		Order("count DESC, facilities.name").
+		Scan(&facets.Facilities).Error
+	if err != nil {
+		return nil, err
+	}
+
+	withoutRating := *filter
+	withoutRating.MinRating = nil
+	selects := make([]string, len(ratingBands))
+	args := make([]interface{}, len(ratingBands))
+	for i, minRating := range ratingBands {
+		selects[i] = fmt.Sprintf("COALESCE(SUM(CASE WHEN hotels.rating >= ? THEN 1 ELSE 0 END), 0) AS band%d", i)
+		args[i] = minRating
+	}
+	ratingCounts := make([]int64, len(ratingBands))
+	targets := make([]interface{}, len(ratingBands))
+	for i := range ratingCounts {
+		targets[i] = &ratingCounts[i]
+	}
+	err = applyHotelFilter(r.db.Table("hotels"), &withoutRating).
+		Select(strings.Join(selects, ", "), args...).
+		Row().Scan(targets...)
+	if err != nil {
+		return nil, err
+	}
+	for i, minRating := range ratingBands {
+		facets.Ratings = append(facets.Ratings, domain.RatingFacet{MinRating: minRating, Count: ratingCounts[i]})
+	}
+
+	withoutPrice := *filter
+	withoutPrice.MinPrice = nil
+	withoutPrice.MaxPrice = nil
+	cheapest := applyHotelFilter(r.db.Table("hotels"), &withoutPrice).
+		Select("hotels.id, MIN(rooms.price) AS price").
+		Joins("JOIN rooms ON rooms.hotel_id = hotels.id").
+		Group("hotels.id")
+	cases := make([]string, 0, len(priceEdges))
+	caseArgs := make([]interface{}, 0, len(priceEdges))
+	for i := len(priceEdges) - 1; i > 0; i-- {
+		cases = append(cases, fmt.Sprintf("WHEN price >= ? THEN %d", i))
+		caseArgs = append(caseArgs, priceEdges[i])
+	}
+	var buckets []struct {
+		Bucket int
+		Count  int64
+	}
+	err = r.db.Table("(?) AS cheapest", cheapest).
+		Select(fmt.Sprintf("CASE %s ELSE 0 END AS bucket, COUNT(*) AS count", strings.Join(cases, " ")), caseArgs...).
+		Where("price >= ?", priceEdges[0]).
+		Group("bucket").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[int]int64, len(buckets))
+	for _, bucket := range buckets {
+		counts[bucket.Bucket] = bucket.Count
+	}
+	facets.Prices = priceFacets(priceEdges, counts)
+	return facets, nil
+}
+
+func priceFacets(priceEdges []float64, counts map[int]int64) []domain.PriceFacet {
+	prices := make([]domain.PriceFacet, len(priceEdges))
+	for i, edge := range priceEdges {
+		prices[i] = domain.PriceFacet{MinPrice: edge, Count: counts[i]}
+		if i+1 < len(priceEdges) {
+			maxPrice := priceEdges[i+1]
+			prices[i].MaxPrice = &maxPrice
+		}
+	}
+	return prices
+}
+
+/*
+GetFreeRoomNights
+Params: hotel id, first and last night, each at midnight
+Returns: every room free for sale on each night, ordered by night, error
+Description: Join the hotel's rooms against a generated series of nights, generate_series on Postgres and a
+recursive CTE on SQLite. A room is free on a night when it is available for sale, and no active booking and
+no open waitlist offer checks in before the next day and checks out on it or later.
+*/
+func (r *hotelRepository) GetFreeRoomNights(hotelId string, from time.Time, to time.Time) ([]domain.RoomNight, error) {
+	count := int(to.Sub(from).Hours()/24) + 1
+	if count <= 0 {
+		return []domain.RoomNight{}, nil
+	}
+
+	// Each night ends when the next day starts
+	series := `WITH RECURSIVE nights(night, ends_at) AS (
+			SELECT 0, datetime(?, '+1 day')
+			UNION ALL
+			SELECT night + 1, datetime(ends_at, '+1 day') FROM nights WHERE night + 1 < ?
+		)`
+	if r.db.Dialector.Name() == "postgres" {
+		series = `WITH nights AS (
+			SELECT night, CAST(? AS timestamptz) + (night + 1) * INTERVAL '1 day' AS ends_at
+			FROM generate_series(0, CAST(? AS integer) - 1) AS night
+		)`
+	}
+
+	var rows []struct {
+		Night  int
+		RoomId uuid.UUID
+		Price  float64
+	}
+	err := r.db.Raw(series+`
+		SELECT nights.night, rooms.id AS room_id, rooms.price
+		FROM nights
+		JOIN rooms ON rooms.hotel_id = ? AND rooms.available = ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM bookings
+			WHERE bookings.room_id = rooms.id AND bookings.is_cancelled = ? AND bookings.status <> ?
+				AND bookings.check_in_date < nights.ends_at AND bookings.check_out_date >= nights.ends_at
+		) AND NOT EXISTS (
+			SELECT 1 FROM waitlist_entries
+			WHERE waitlist_entries.room_id = rooms.id AND waitlist_entries.status = ? AND waitlist_entries.offer_expires_at > ?
+				AND waitlist_entries.check_in_date < nights.ends_at AND waitlist_entries.check_out_date >= nights.ends_at
+		)
+		ORDER BY nights.night, rooms.price`,
+		from, count, hotelId, true, false, domain.BookingStatusNoShow, domain.WaitlistStatusOffered, time.Now()).
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	nights := make([]domain.RoomNight, len(rows))
+	for i, row := range rows {
+		nights[i] = domain.RoomNight{RoomId: row.RoomId, Date: from.AddDate(0, 0, row.Night), Price: row.Price}
+	}
+	return nights, nil
+}
+
+// applyHotelFilter adds the filter's conditions to a query over the hotels table
+func applyHotelFilter(query *gorm.DB, filter *domain.HotelFilter) *gorm.DB {
+	if filter.Ids != nil {
+		query = query.Where("hotels.id IN ?", filter.Ids)
+	}
+	if filter.OrganizationId != nil {
+		query = query.Where("hotels.organization_id = ?", *filter.OrganizationId)
+	}
+	if bounds := filter.Bounds; bounds != nil {
+		query = query.Where("hotels.latitude BETWEEN ? AND ?", bounds.MinLat, bounds.MaxLat)
+		if bounds.MinLng <= bounds.MaxLng {
+			query = query.Where("hotels.longitude BETWEEN ? AND ?", bounds.MinLng, bounds.MaxLng)
+		} else {
+			query = query.Where("(hotels.longitude >= ? OR hotels.longitude <= ?)", bounds.MinLng, bounds.MaxLng)
+		}
+	}
+	if filter.City != "" {
+		query = query.Where("LOWER(hotels.city) = LOWER(?)", filter.City)
+	}
+	if filter.Country != "" {
+		query = query.Where("LOWER(hotels.country) = LOWER(?)", filter.Country)
+	}
+	for _, facility := range filter.Facilities {
+		query = query.Where(`hotels.id IN (SELECT rooms.hotel_id FROM rooms
+			JOIN room_facilities ON room_facilities.room_id = rooms.id
+			JOIN facilities ON facilities.id = room_facilities.facility_id
+			WHERE LOWER(facilities.name) = LOWER(?))`, facility)
+	}
+	if filter.MinRating != nil {
+		query = query.Where("hotels.rating >= ?", *filter.MinRating)
+	}
+	if filter.MinPrice != nil || filter.MaxPrice != nil {
+		having := "1 = 1"
+		var args []interface{}
+		if filter.MinPrice != nil {
+			having += " AND MIN(price) >= ?"
+			args = append(args, *filter.MinPrice)
+		}
+		if filter.MaxPrice != nil {
+			having += " AND MIN(price) < ?"
+			args = append(args, *filter.MaxPrice)
+		}
+		query = query.Where("hotels.id IN (SELECT hotel_id FROM rooms GROUP BY hotel_id HAVING "+having+")", args...)
+	}
+	return query
+}
+
+// withDetails preloads the organization, rooms, facilities and photos. Hotel photos exclude those taken of a specific room.
+func (r *hotelRepository) withDetails() *gorm.DB {
+	return r.db.
+		Preload("Organization").
+		Preload("Rooms").
+		Preload("Rooms.Facilities").
+		Preload("Rooms.Photos", func(db *gorm.DB) *gorm.DB { return db.Order("position ASC") }).
+		Preload("Photos", func(db *gorm.DB) *gorm.DB { return db.Where("room_id IS NULL").Order("position ASC") })
+}
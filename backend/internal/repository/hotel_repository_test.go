@@ -20,10 +20,18 @@ func setupHotelTestDB(t *testing.T) *gorm.DB {
 	err = db.Exec(`
 		CREATE TABLE hotels (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			name TEXT,
 			description TEXT,
 			address TEXT,
-			rating REAL
+			city TEXT,
+			country TEXT,
+			latitude REAL,
+			longitude REAL,
+			rating REAL,
+			review_count INTEGER DEFAULT 0,
+			no_show_cutoff_hours INTEGER,
+			no_show_penalty_nights INTEGER
 		);
 		CREATE TABLE rooms (
 			id TEXT PRIMARY KEY,
@@ -37,6 +45,21 @@ func setupHotelTestDB(t *testing.T) *gorm.DB {
 			id TEXT PRIMARY KEY,
 			name TEXT
 		);
+		CREATE TABLE photos (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			position INTEGER,
+			is_cover INTEGER DEFAULT 0,
+			content_type TEXT,
+			width INTEGER,
+			height INTEGER,
+			size INTEGER,
+			storage_key TEXT,
+			url TEXT,
+			thumbnails TEXT,
+			created_at DATETIME
+		);
 		CREATE TABLE room_facilities (
 			room_id TEXT,
 			facility_id TEXT,
@@ -192,3 +215,64 @@ func TestHotelRepository_GetHotelById(t *testing.T) {
 	}
 }
 
+
+func TestHotelRepository_SearchHotels(t *testing.T) {
+	db := setupHotelTestDB(t)
+	repo := NewHotelRepository(db)
+
+	coordinates := func(lat, lng float64) (*float64, *float64) { return &lat, &lng }
+	newHotel := func(name, city string, lat, lng float64) *domain.Hotel {
+		latitude, longitude := coordinates(lat, lng)
+		return &domain.Hotel{Id: uuid.New(), Name: name, City: city, Country: "Test", Latitude: latitude, Longitude: longitude}
+	}
+	hotels := []*domain.Hotel{
+		newHotel("Denpasar", "Denpasar", -8.67, 115.21),
+		newHotel("Ubud", "Ubud", -8.51, 115.26),
+		newHotel("Jakarta", "Jakarta", -6.2, 106.82),
+		newHotel("Fiji", "Suva", -18.14, 178.44),
+		newHotel("Samoa", "Apia", -13.83, -171.76),
+		{Id: uuid.New(), Name: "Unmapped", City: "Denpasar", Country: "Test"},
+	}
+	for _, hotel := range hotels {
+		assert.NoError(t, repo.CreateHotel(hotel))
+	}
+
+	tests := []struct {
+		name     string
+		filter   *domain.HotelFilter
+		expected []string
+	}{
+		{
+			name:     "bounding box around Bali",
+			filter:   &domain.HotelFilter{Bounds: &domain.GeoBounds{MinLat: -9, MinLng: 114.5, MaxLat: -8, MaxLng: 116}},
+			expected: []string{"Denpasar", "Ubud"},
+		},
+		{
+			name:     "bounding box across the antimeridian",
+			filter:   &domain.HotelFilter{Bounds: &domain.GeoBounds{MinLat: -20, MinLng: 170, MaxLat: -10, MaxLng: -170}},
+			expected: []string{"Fiji", "Samoa"},
+		},
+		{
+			name:     "city is case insensitive and includes hotels without coordinates",
+			filter:   &domain.HotelFilter{City: "denpasar"},
+			expected: []string{"Denpasar", "Unmapped"},
+		},
+		{
+			name:     "city and bounding box",
+			filter:   &domain.HotelFilter{City: "Denpasar", Bounds: &domain.GeoBounds{MinLat: -9, MinLng: 114.5, MaxLat: -8, MaxLng: 116}},
+			expected: []string{"Denpasar"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := repo.SearchHotels(tt.filter)
+			assert.NoError(t, err)
+			var names []string
+			for _, hotel := range found {
+				names = append(names, hotel.Name)
+			}
+			assert.ElementsMatch(t, tt.expected, names)
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type InvitationRepository interface {
+	ReplaceOpenInvitations(invitation *domain.StaffInvitation, revokedAt time.Time, deliver func() error) error
+	GetInvitationById(id string) (*domain.StaffInvitation, error)
+	GetInvitationByTokenHash(tokenHash string) (*domain.StaffInvitation, error)
+	GetInvitationsByHotelId(hotelId string) ([]domain.StaffInvitation, error)
+	RevokeInvitation(id string, revokedAt time.Time) error
+	AcceptInvitation(invitation *domain.StaffInvitation, newUser *domain.User, assignment *domain.RoleAssignment) (bool, error)
+}
+
+// errInvitationClosed rolls back an acceptance when the invitation was used or revoked meanwhile
+var errInvitationClosed = errors.New("invitation is no longer open")
+
+type invitationRepository struct {
+	db *gorm.DB
+}
+
+func NewInvitationRepository(db *gorm.DB) InvitationRepository {
+	return &invitationRepository{db: db}
+}
+
+func (r *invitationRepository) GetInvitationById(id string) (*domain.StaffInvitation, error) {
+	var invitation domain.StaffInvitation
+	if err := r.db.First(&invitation, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) GetInvitationByTokenHash(tokenHash string) (*domain.StaffInvitation, error) {
+	var invitation domain.StaffInvitation
+	if err := r.db.First(&invitation, "token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &invitation, nil
+}
+
+func (r *invitationRepository) GetInvitationsByHotelId(hotelId string) ([]domain.StaffInvitation, error) {
+	var invitations []domain.StaffInvitation
+	if err := r.db.Where("hotel_id = ?", hotelId).Order("created_at DESC").Find(&invitations).Error; err != nil {
+		return nil, err
+	}
+	return invitations, nil
+}
+
+func (r *invitationRepository) RevokeInvitation(id string, revokedAt time.Time) error {
+	return r.db.Model(&domain.StaffInvitation{}).
+		Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", id).
+		Update("revoked_at", revokedAt).Error
+}
+
+/*
+ReplaceOpenInvitations
+Params: new invitation, time to revoke the earlier ones at, function that sends the new invitation
+Returns: error
+Description: Revoke the unused invitations to the same email address at the hotel and create the new one in
+one transaction that only commits once deliver succeeds, so an invitation that could not be sent leaves the
+earlier links working.
+*/
+func (r *invitationRepository) ReplaceOpenInvitations(invitation *domain.StaffInvitation, revokedAt time.Time, deliver func() error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.StaffInvitation{}).
+			Where("email = ? AND hotel_id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.Email, invitation.HotelId).
+			Update("revoked_at", revokedAt).Error
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(invitation).Error; err != nil {
+			return err
+		}
+		return deliver()
+	})
+}
+
+/*
+AcceptInvitation
+Params: invitation with AcceptedAt and AcceptedBy set, user to create (nil when linking an existing account), role to grant
+Returns: false when the invitation was no longer open, error
+Description: Create the account if needed, grant the role and mark the invitation used in one transaction.
+The invitation is only marked when it is still open, so a token raced by two requests grants the role once.
+*/
+func (r *invitationRepository) AcceptInvitation(invitation *domain.StaffInvitation, newUser *domain.User, assignment *domain.RoleAssignment) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.StaffInvitation{}).
+			Where("id = ? AND accepted_at IS NULL AND revoked_at IS NULL", invitation.Id).
+			Updates(map[string]interface{}{"accepted_at": invitation.AcceptedAt, "accepted_by": invitation.AcceptedBy})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errInvitationClosed
+		}
+		if newUser != nil {
+			if err := tx.Create(newUser).Error; err != nil {
+				return err
+			}
+		}
+		if assignment != nil {
+			return tx.Create(assignment).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errInvitationClosed) {
+		return false, nil
+	}
+	return err == nil, err
+}
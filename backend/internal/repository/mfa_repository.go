@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type MFARepository interface {
+	GetEnrollment(userId string) (*domain.MFAEnrollment, error)
+	IsEnabled(userId string) (bool, error)
+	SaveEnrollment(enrollment *domain.MFAEnrollment) error
+	ConfirmEnrollment(userId string, step int64, confirmedAt time.Time, codes []domain.MFARecoveryCode) (bool, error)
+	DeleteEnrollment(userId string) error
+	UseTOTPStep(userId string, step int64) (bool, error)
+	UseRecoveryCode(userId string, codeHash string, usedAt time.Time) (bool, error)
+	ReplaceRecoveryCodes(userId string, codes []domain.MFARecoveryCode) error
+	CountUnusedRecoveryCodes(userId string) (int64, error)
+}
+
+type mfaRepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+func (r *mfaRepository) GetEnrollment(userId string) (*domain.MFAEnrollment, error) {
+	var enrollment domain.MFAEnrollment
+	if err := r.db.First(&enrollment, "user_id = ?", userId).Error; err != nil {
+		return nil, err
+	}
+	return &enrollment, nil
+}
+
+// IsEnabled reports whether the user has a confirmed enrollment
+func (r *mfaRepository) IsEnabled(userId string) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.MFAEnrollment{}).Where("user_id = ? AND confirmed_at IS NOT NULL", userId).Count(&count).Error
+	return count > 0, err
+}
+
+// SaveEnrollment stores the enrollment, replacing any earlier one of the user
+func (r *mfaRepository) SaveEnrollment(enrollment *domain.MFAEnrollment) error {
+	return r.db.Save(enrollment).Error
+}
+
+/*
+ConfirmEnrollment
+Params: user id, time step of the code that confirmed it, time of confirmation, the user's recovery codes
+Returns: false when the enrollment was already confirmed or is gone, error
+Description: Turn two-factor login on and store the recovery codes, replacing any old ones, in one transaction.
+*/
+func (r *mfaRepository) ConfirmEnrollment(userId string, step int64, confirmedAt time.Time, codes []domain.MFARecoveryCode) (bool, error) {
+	confirmed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&domain.MFAEnrollment{}).
+			Where("user_id = ? AND confirmed_at IS NULL", userId).
+			Updates(map[string]interface{}{"confirmed_at": confirmedAt, "last_used_step": step, "updated_at": confirmedAt})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+		if err := replaceRecoveryCodes(tx, userId, codes); err != nil {
+			return err
+		}
+		confirmed = true
+		return nil
+	})
+	return confirmed && err == nil, err
+}
+
+// DeleteEnrollment turns two-factor login off, removing the secret and the recovery codes
+func (r *mfaRepository) DeleteEnrollment(userId string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userId).Delete(&domain.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userId).Delete(&domain.MFAEnrollment{}).Error
+	})
+}
+
+// UseTOTPStep records that a code of the time step was accepted, returning false when a code of
+// this or a later step was already used
+func (r *mfaRepository) UseTOTPStep(userId string, step int64) (bool, error) {
+	result := r.db.Model(&domain.MFAEnrollment{}).
+		Where("user_id = ? AND confirmed_at IS NOT NULL AND last_used_step < ?", userId, step).
+		Update("last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// UseRecoveryCode marks the recovery code used, returning false when the user has no such unused code
+func (r *mfaRepository) UseRecoveryCode(userId string, codeHash string, usedAt time.Time) (bool, error) {
+	result := r.db.Model(&domain.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userId, codeHash).
+		Update("used_at", usedAt)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *mfaRepository) ReplaceRecoveryCodes(userId string, codes []domain.MFARecoveryCode) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userId, codes)
+	})
+}
+
+func (r *mfaRepository) CountUnusedRecoveryCodes(userId string) (int64, error) {
+	var count int64
+	err := r.db.Model(&domain.MFARecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&count).Error
+	return count, err
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userId string, codes []domain.MFARecoveryCode) error {
+	if err := tx.Where("user_id = ?", userId).Delete(&domain.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OrganizationRepository interface {
+	CreateOrganization(organization *domain.Organization) error
+	UpdateOrganization(organization *domain.Organization) error
+	GetOrganizationById(id string) (*domain.Organization, error)
+	GetOrganizationByName(name string) (*domain.Organization, error)
+	GetAllOrganizations() ([]domain.Organization, error)
+	MoveHotel(change *domain.HotelOrganizationChange) error
+	GetHotelChanges(hotelId string) ([]domain.HotelOrganizationChange, error)
+	GetHotelReports(organizationId string, from time.Time, to time.Time) ([]domain.HotelReport, error)
+}
+
+type organizationRepository struct {
+	db *gorm.DB
+}
+
+func NewOrganizationRepository(db *gorm.DB) OrganizationRepository {
+	return &organizationRepository{db: db}
+}
+
+func (r *organizationRepository) CreateOrganization(organization *domain.Organization) error {
+	return r.db.Create(organization).Error
+}
+
+func (r *organizationRepository) UpdateOrganization(organization *domain.Organization) error {
+	return r.db.Save(organization).Error
+}
+
+func (r *organizationRepository) GetOrganizationById(id string) (*domain.Organization, error) {
+	var organization domain.Organization
+	if err := r.db.First(&organization, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepository) GetOrganizationByName(name string) (*domain.Organization, error) {
+	var organization domain.Organization
+	if err := r.db.First(&organization, "LOWER(name) = LOWER(?)", name).Error; err != nil {
+		return nil, err
+	}
+	return &organization, nil
+}
+
+func (r *organizationRepository) GetAllOrganizations() ([]domain.Organization, error) {
+	var organizations []domain.Organization
+	if err := r.db.Order("name ASC").Find(&organizations).Error; err != nil {
+		return nil, err
+	}
+	return organizations, nil
+}
+
+// MoveHotel reassigns the hotel and records the change in one transaction. Existing bookings keep their organization.
+func (r *organizationRepository) MoveHotel(change *domain.HotelOrganizationChange) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&domain.Hotel{}).
+			Where("id = ?", change.HotelId).
+			Update("organization_id", change.ToOrganizationId).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(change).Error
+	})
+}
+
+func (r *organizationRepository) GetHotelChanges(hotelId string) ([]domain.HotelOrganizationChange, error) {
+	var changes []domain.HotelOrganizationChange
+	if err := r.db.Where("hotel_id = ?", hotelId).Order("created_at ASC").Find(&changes).Error; err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// GetHotelReports counts the organization's bookings per hotel, grouped in SQL, for stays checking in between from and to
+func (r *organizationRepository) GetHotelReports(organizationId string, from time.Time, to time.Time) ([]domain.HotelReport, error) {
+	var rows []struct {
+		HotelId   string
+		HotelName string
+		Bookings  int64
+		Cancelled int64
+		NoShows   int64
+		Revenue   float64
+	}
+	err := r.db.Table("bookings").
+		Select(`bookings.hotel_id AS hotel_id, hotels.name AS hotel_name, COUNT(*) AS bookings,
+			SUM(CASE WHEN bookings.is_cancelled = ? THEN 1 ELSE 0 END) AS cancelled,
+			SUM(CASE WHEN bookings.status = ? THEN 1 ELSE 0 END) AS no_shows,
+			COALESCE(SUM(CASE WHEN bookings.is_cancelled = ? THEN 0 ELSE bookings.total_price END), 0) AS revenue`,
+			true, domain.BookingStatusNoShow, true).
+		Joins("JOIN hotels ON hotels.id = bookings.hotel_id").
+		Where("bookings.organization_id = ? AND bookings.check_in_date >= ? AND bookings.check_in_date < ?", organizationId, from, to).
+		Group("bookings.hotel_id, hotels.name").
+		Order("hotels.name ASC").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]domain.HotelReport, 0, len(rows))
+	for _, row := range rows {
+		hotelId, err := uuid.Parse(row.HotelId)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, domain.HotelReport{
+			HotelId:   &hotelId,
+			HotelName: row.HotelName,
+			Bookings:  row.Bookings,
+			Cancelled: row.Cancelled,
+			NoShows:   row.NoShows,
+			Revenue:   row.Revenue,
+		})
+	}
+	return reports, nil
+}
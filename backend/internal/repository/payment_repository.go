@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"backend/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type PaymentRepository interface {
+	CreatePayment(payment *domain.Payment) error
+	GetPaymentsByBookingId(bookingId string) ([]domain.Payment, error)
+}
+
+type paymentRepository struct {
+	db *gorm.DB
+}
+
+func NewPaymentRepository(db *gorm.DB) PaymentRepository {
+	return &paymentRepository{db: db}
+}
+
+func (r *paymentRepository) CreatePayment(payment *domain.Payment) error {
+	return r.db.Create(payment).Error
+}
+
+func (r *paymentRepository) GetPaymentsByBookingId(bookingId string) ([]domain.Payment, error) {
+	var payments []domain.Payment
+	if err := r.db.Where("booking_id = ?", bookingId).Order("created_at ASC").Find(&payments).Error; err != nil {
+		return nil, err
+	}
+	return payments, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type PhotoRepository interface {
+	CreatePhoto(photo *domain.Photo) error
+	GetPhotoById(id string) (*domain.Photo, error)
+	GetPhotos(hotelId string, roomId *uuid.UUID) ([]domain.Photo, error)
+	UpdatePhotos(photos []domain.Photo) error
+	DeletePhoto(photo *domain.Photo) error
+}
+
+type photoRepository struct {
+	db *gorm.DB
+}
+
+func NewPhotoRepository(db *gorm.DB) PhotoRepository {
+	return &photoRepository{db: db}
+}
+
+func (r *photoRepository) CreatePhoto(photo *domain.Photo) error {
+	return r.db.Create(photo).Error
+}
+
+func (r *photoRepository) GetPhotoById(id string) (*domain.Photo, error) {
+	var photo domain.Photo
+	if err := r.db.First(&photo, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &photo, nil
+}
+
+// GetPhotos returns the hotel's own photos, or a room's photos when roomId is set, in display order
+func (r *photoRepository) GetPhotos(hotelId string, roomId *uuid.UUID) ([]domain.Photo, error) {
+	query := r.db.Where("hotel_id = ?", hotelId)
+	if roomId != nil {
+		query = query.Where("room_id = ?", *roomId)
+	} else {
+		query = query.Where("room_id IS NULL")
+	}
+
+	var photos []domain.Photo
+	if err := query.Order("position ASC").Find(&photos).Error; err != nil {
+		return nil, err
+	}
+	return photos, nil
+}
+
+// UpdatePhotos saves position and cover changes for a set of photos in one transaction
+func (r *photoRepository) UpdatePhotos(photos []domain.Photo) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		for i := range photos {
+			if err := tx.Save(&photos[i]).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (r *photoRepository) DeletePhoto(photo *domain.Photo) error {
+	return r.db.Delete(&domain.Photo{}, "id = ?", photo.Id).Error
+}
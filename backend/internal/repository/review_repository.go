@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"math"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ReviewRepository interface {
+	CreateReview(review *domain.Review) error
+	UpdateReview(review *domain.Review) error
+	GetReviewById(id string) (*domain.Review, error)
+	GetReviewByBookingId(bookingId string) (*domain.Review, error)
+	GetReviewsByHotelId(hotelId string, status domain.ReviewStatus) ([]domain.Review, error)
+	HasReviewWithFingerprint(fingerprint string) (bool, error)
+	GetModerationQueue() ([]domain.Review, error)
+	AddReport(report *domain.ReviewReport) error
+	GetReportsByReviewId(reviewId string) ([]domain.ReviewReport, error)
+}
+
+type reviewRepository struct {
+	db *gorm.DB
+}
+
+func NewReviewRepository(db *gorm.DB) ReviewRepository {
+	return &reviewRepository{db: db}
+}
+
+// CreateReview stores the review and refreshes the hotel's rating in one transaction
+func (r *reviewRepository) CreateReview(review *domain.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(review).Error; err != nil {
+			return err
+		}
+		return recalculateHotelRating(tx, review.HotelId)
+	})
+}
+
+// UpdateReview saves the review and refreshes the hotel's rating in one transaction
+func (r *reviewRepository) UpdateReview(review *domain.Review) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(review).Error; err != nil {
+			return err
+		}
+		return recalculateHotelRating(tx, review.HotelId)
+	})
+}
+
+func (r *reviewRepository) GetReviewById(id string) (*domain.Review, error) {
+	var review domain.Review
+	if err := r.db.First(&review, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+func (r *reviewRepository) GetReviewByBookingId(bookingId string) (*domain.Review, error) {
+	var review domain.Review
+	if err := r.db.First(&review, "booking_id = ?", bookingId).Error; err != nil {
+		return nil, err
+	}
+	return &review, nil
+}
+
+// GetReviewsByHotelId returns a hotel's reviews in the given status, newest first
+func (r *reviewRepository) GetReviewsByHotelId(hotelId string, status domain.ReviewStatus) ([]domain.Review, error) {
+	var reviews []domain.Review
+	err := r.db.Where("hotel_id = ? AND status = ?", hotelId, status).Order("created_at DESC").Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+func (r *reviewRepository) HasReviewWithFingerprint(fingerprint string) (bool, error) {
+	var count int64
+	if err := r.db.Model(&domain.Review{}).Where("text_fingerprint = ?", fingerprint).Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// GetModerationQueue returns reviews awaiting a decision and published reviews with open reports,
+// most reported first and then oldest first
+func (r *reviewRepository) GetModerationQueue() ([]domain.Review, error) {
+	var reviews []domain.Review
+	err := r.db.
+		Where("status = ? OR (status = ? AND report_count > 0)", domain.ReviewStatusPending, domain.ReviewStatusApproved).
+		Order("report_count DESC, created_at ASC").
+		Find(&reviews).Error
+	if err != nil {
+		return nil, err
+	}
+	return reviews, nil
+}
+
+// AddReport stores a report and raises the review's open report count in one transaction
+func (r *reviewRepository) AddReport(report *domain.ReviewReport) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(report).Error; err != nil {
+			return err
+		}
+		return tx.Model(&domain.Review{}).Where("id = ?", report.ReviewId).
+			Update("report_count", gorm.Expr("report_count + 1")).Error
+	})
+}
+
+func (r *reviewRepository) GetReportsByReviewId(reviewId string) ([]domain.ReviewReport, error) {
+	var reports []domain.ReviewReport
+	if err := r.db.Where("review_id = ?", reviewId).Order("created_at ASC").Find(&reports).Error; err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// recalculateHotelRating sets the hotel's rating to the average of its approved reviews, rounded to two decimals
+func recalculateHotelRating(tx *gorm.DB, hotelId uuid.UUID) error {
+	var aggregate struct {
+		Average float64
+		Count   int
+	}
+	err := tx.Model(&domain.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("hotel_id = ? AND status = ?", hotelId, domain.ReviewStatusApproved).
+		Scan(&aggregate).Error
+	if err != nil {
+		return err
+	}
+
+	return tx.Model(&domain.Hotel{}).Where("id = ?", hotelId).Updates(map[string]interface{}{
+		"rating":       math.Round(aggregate.Average*100) / 100,
+		"review_count": aggregate.Count,
+	}).Error
+}
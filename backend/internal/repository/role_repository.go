@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"backend/internal/domain"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RoleRepository interface {
+	CreateAssignment(assignment *domain.RoleAssignment) error
+	DeleteAssignment(userId string, role domain.Role, hotelId *uuid.UUID, organizationId *uuid.UUID) (bool, error)
+	GetAssignmentsByUserId(userId string) ([]domain.RoleAssignment, error)
+	HasAnyRole(userId uuid.UUID, roles []domain.Role, hotelId *uuid.UUID) (bool, error)
+	FindAssignment(userId string, role domain.Role, hotelId *uuid.UUID, organizationId *uuid.UUID) (*domain.RoleAssignment, error)
+	GetHotelTeam(hotelId string) ([]domain.TeamMember, error)
+}
+
+type roleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+func (r *roleRepository) CreateAssignment(assignment *domain.RoleAssignment) error {
+	return r.db.Create(assignment).Error
+}
+
+// DeleteAssignment removes the assignment with exactly this role and scope, reporting whether one existed
+func (r *roleRepository) DeleteAssignment(userId string, role domain.Role, hotelId *uuid.UUID, organizationId *uuid.UUID) (bool, error) {
+	result := scopeAssignment(r.db, userId, role, hotelId, organizationId).Delete(&domain.RoleAssignment{})
+	return result.RowsAffected > 0, result.Error
+}
+
+func (r *roleRepository) GetAssignmentsByUserId(userId string) ([]domain.RoleAssignment, error) {
+	var assignments []domain.RoleAssignment
+	if err := r.db.Where("user_id = ?", userId).Order("created_at ASC").Find(&assignments).Error; err != nil {
+		return nil, err
+	}
+	return assignments, nil
+}
+
+func (r *roleRepository) FindAssignment(userId string, role domain.Role, hotelId *uuid.UUID, organizationId *uuid.UUID) (*domain.RoleAssignment, error) {
+	var assignment domain.RoleAssignment
+	if err := scopeAssignment(r.db, userId, role, hotelId, organizationId).First(&assignment).Error; err != nil {
+		return nil, err
+	}
+	return &assignment, nil
+}
+
+// HasAnyRole reports whether the user holds one of the roles at the hotel, either directly
+// or through the organization the hotel currently belongs to
+func (r *roleRepository) HasAnyRole(userId uuid.UUID, roles []domain.Role, hotelId *uuid.UUID) (bool, error) {
+	if len(roles) == 0 || hotelId == nil {
+		return false, nil
+	}
+	var count int64
+	err := r.db.Model(&domain.RoleAssignment{}).
+		Where("user_id = ? AND role IN ?", userId, roles).
+		Where("hotel_id = ? OR organization_id IN (SELECT organization_id FROM hotels WHERE id = ? AND organization_id IS NOT NULL)", *hotelId, *hotelId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func scopeAssignment(db *gorm.DB, userId string, role domain.Role, hotelId *uuid.UUID, organizationId *uuid.UUID) *gorm.DB {
+	query := db.Where("user_id = ? AND role = ?", userId, role)
+	if hotelId != nil {
+		query = query.Where("hotel_id = ?", *hotelId)
+	} else {
+		query = query.Where("hotel_id IS NULL")
+	}
+	if organizationId != nil {
+		query = query.Where("organization_id = ?", *organizationId)
+	} else {
+		query = query.Where("organization_id IS NULL")
+	}
+	return query
+}
+
+// GetHotelTeam lists the users holding a role at the hotel, including those whose role covers its organization
+func (r *roleRepository) GetHotelTeam(hotelId string) ([]domain.TeamMember, error) {
+	var members []domain.TeamMember
+	err := r.db.Table("role_assignments").
+		Select("users.id AS user_id, users.username, users.email, role_assignments.role, role_assignments.hotel_id, role_assignments.organization_id, role_assignments.created_at AS since").
+		Joins("JOIN users ON users.id = role_assignments.user_id").
+		Where("role_assignments.hotel_id = ? OR role_assignments.organization_id IN (SELECT organization_id FROM hotels WHERE id = ? AND organization_id IS NOT NULL)", hotelId, hotelId).
+		Order("role_assignments.created_at ASC").
+		Scan(&members).Error
+	return members, err
+}
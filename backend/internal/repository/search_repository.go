@@ -0,0 +1,229 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Field weights of the search document, matching Postgres' default ts_rank weights for A, B, C and D
+const (
+	nameWeight        = 1.0
+	facilitiesWeight  = 0.4
+	addressWeight     = 0.2
+	descriptionWeight = 0.1
+)
+
+type SearchRepository interface {
+	EnsureIndex() error
+	IndexHotel(document *domain.HotelSearchDocument, terms []string) error
+	MatchHotels(terms []string) ([]domain.SearchMatch, error)
+	HasTermWithPrefix(prefix string) (bool, error)
+	GetTerms() ([]string, error)
+	SuggestCities(prefix string, limit int) ([]string, error)
+	SuggestHotels(prefix string, limit int) ([]domain.Hotel, error)
+}
+
+type searchRepository struct {
+	db *gorm.DB
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepository {
+	return &searchRepository{db: db}
+}
+
+func (r *searchRepository) isPostgres() bool {
+	return r.db.Dialector.Name() == "postgres"
+}
+
+// EnsureIndex creates the search tables that AutoMigrate cannot. Postgres keeps a tsvector column
+// that is migrated with the model; SQLite uses an FTS5 virtual table, or FTS4 when the driver was
+// built without FTS5 (mattn/go-sqlite3 needs the sqlite_fts5 build tag).
+func (r *searchRepository) EnsureIndex() error {
+	if err := r.db.AutoMigrate(&domain.SearchTerm{}); err != nil {
+		return err
+	}
+	if r.isPostgres() {
+		return nil
+	}
+
+	err := r.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS hotel_search_documents USING fts5(
+		hotel_id UNINDEXED, name, description, address, facilities, tokenize = 'unicode61'
+	)`).Error
+	if err != nil && strings.Contains(err.Error(), "no such module") {
+		err = r.db.Exec(`CREATE VIRTUAL TABLE IF NOT EXISTS hotel_search_documents USING fts4(
+			hotel_id, name, description, address, facilities, notindexed=hotel_id
+		)`).Error
+	}
+	return err
+}
+
+// IndexHotel replaces the hotel's search document and adds its words to the typo-correction vocabulary
+func (r *searchRepository) IndexHotel(document *domain.HotelSearchDocument, terms []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if r.isPostgres() {
+			err := tx.Exec(`INSERT INTO hotel_search_documents (hotel_id, name, description, address, facilities, search_vector)
+				VALUES (?, ?, ?, ?, ?,
+					setweight(to_tsvector('english', ?), 'A') ||
+					setweight(to_tsvector('english', ?), 'B') ||
+					setweight(to_tsvector('english', ?), 'C') ||
+					setweight(to_tsvector('english', ?), 'D'))
+				ON CONFLICT (hotel_id) DO UPDATE SET
+					name = EXCLUDED.name,
+					description = EXCLUDED.description,
+					address = EXCLUDED.address,
+					facilities = EXCLUDED.facilities,
+					search_vector = EXCLUDED.search_vector`,
+				document.HotelId, document.Name, document.Description, document.Address, document.Facilities,
+				document.Name, document.Facilities, document.Address, document.Description,
+			).Error
+			if err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Exec("DELETE FROM hotel_search_documents WHERE hotel_id = ?", document.HotelId.String()).Error; err != nil {
+				return err
+			}
+			err := tx.Exec("INSERT INTO hotel_search_documents (hotel_id, name, description, address, facilities) VALUES (?, ?, ?, ?, ?)",
+				document.HotelId.String(), document.Name, document.Description, document.Address, document.Facilities,
+			).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(terms) == 0 {
+			return nil
+		}
+		searchTerms := make([]domain.SearchTerm, len(terms))
+		for i, term := range terms {
+			searchTerms[i] = domain.SearchTerm{Term: term}
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&searchTerms).Error
+	})
+}
+
+/*
+MatchHotels
+Params: lowercase query terms
+Returns: matching hotels ordered by relevance, error
+Description: Every term must match a word of the document, as a prefix so partially typed words still match.
+Postgres ranks with ts_rank over the weighted tsvector; SQLite scores the candidates with the same weights.
+*/
+func (r *searchRepository) MatchHotels(terms []string) ([]domain.SearchMatch, error) {
+	if len(terms) == 0 {
+		return nil, nil
+	}
+
+	if r.isPostgres() {
+		prefixes := make([]string, len(terms))
+		for i, term := range terms {
+			prefixes[i] = term + ":*"
+		}
+		var matches []domain.SearchMatch
+		err := r.db.Raw(`SELECT hotel_id, ts_rank(search_vector, query, 1) AS rank
+			FROM hotel_search_documents, to_tsquery('english', ?) AS query
+			WHERE search_vector @@ query
+			ORDER BY rank DESC, hotel_id`, strings.Join(prefixes, " & ")).Scan(&matches).Error
+		return matches, err
+	}
+
+	prefixes := make([]string, len(terms))
+	for i, term := range terms {
+		prefixes[i] = term + "*"
+	}
+	var documents []struct {
+		HotelId     string
+		Name        string
+		Description string
+		Address     string
+		Facilities  string
+	}
+	err := r.db.Raw(`SELECT hotel_id, name, description, address, facilities
+		FROM hotel_search_documents WHERE hotel_search_documents MATCH ?`, strings.Join(prefixes, " ")).Scan(&documents).Error
+	if err != nil {
+		return nil, err
+	}
+
+	matches := make([]domain.SearchMatch, 0, len(documents))
+	for _, document := range documents {
+		hotelId, err := uuid.Parse(document.HotelId)
+		if err != nil {
+			continue
+		}
+		rank := fieldScore(document.Name, terms, nameWeight) +
+			fieldScore(document.Facilities, terms, facilitiesWeight) +
+			fieldScore(document.Address, terms, addressWeight) +
+			fieldScore(document.Description, terms, descriptionWeight)
+		matches = append(matches, domain.SearchMatch{HotelId: hotelId, Rank: rank})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Rank > matches[j].Rank })
+	return matches, nil
+}
+
+// fieldScore is the weighted frequency of the terms in a field, damped by the field's length like ts_rank
+func fieldScore(text string, terms []string, weight float64) float64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+	hits := 0
+	for _, word := range words {
+		for _, term := range terms {
+			if strings.HasPrefix(word, term) {
+				hits++
+			}
+		}
+	}
+	return weight * float64(hits) / (1 + math.Log(float64(len(words))))
+}
+
+func (r *searchRepository) HasTermWithPrefix(prefix string) (bool, error) {
+	var count int64
+	err := r.db.Model(&domain.SearchTerm{}).Where("term LIKE ?", prefix+"%").Limit(1).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *searchRepository) GetTerms() ([]string, error) {
+	var terms []string
+	err := r.db.Model(&domain.SearchTerm{}).Pluck("term", &terms).Error
+	return terms, err
+}
+
+// SuggestCities returns distinct cities starting with the prefix, or with a word of it
+func (r *searchRepository) SuggestCities(prefix string, limit int) ([]string, error) {
+	var cities []string
+	pattern := escapeLike(strings.ToLower(prefix))
+	err := r.db.Model(&domain.Hotel{}).
+		Distinct("city").
+		Where("city <> ''").
+		Where(`LOWER(city) LIKE ? ESCAPE '\' OR LOWER(city) LIKE ? ESCAPE '\'`, pattern+"%", "% "+pattern+"%").
+		Order("city").
+		Limit(limit).
+		Pluck("city", &cities).Error
+	return cities, err
+}
+
+// SuggestHotels returns hotels whose name starts with the prefix, or with a word of it
+func (r *searchRepository) SuggestHotels(prefix string, limit int) ([]domain.Hotel, error) {
+	var hotels []domain.Hotel
+	pattern := escapeLike(strings.ToLower(prefix))
+	err := r.db.Select("id", "name", "city", "country").
+		Where(`LOWER(name) LIKE ? ESCAPE '\' OR LOWER(name) LIKE ? ESCAPE '\'`, pattern+"%", "% "+pattern+"%").
+		Order("name").
+		Limit(limit).
+		Find(&hotels).Error
+	return hotels, err
+}
+
+func escapeLike(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type StayRestrictionRepository interface {
+	ReplaceRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time, restrictions []domain.StayRestriction) error
+	DeleteRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) error
+	GetRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) ([]domain.StayRestriction, error)
+}
+
+type stayRestrictionRepository struct {
+	db *gorm.DB
+}
+
+func NewStayRestrictionRepository(db *gorm.DB) StayRestrictionRepository {
+	return &stayRestrictionRepository{db: db}
+}
+
+// ReplaceRestrictions swaps the rows of one scope (hotel-wide or a single room) over a date range in one transaction
+func (r *stayRestrictionRepository) ReplaceRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time, restrictions []domain.StayRestriction) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := scopeRestrictions(tx, hotelId, roomId, from, to).Delete(&domain.StayRestriction{}).Error; err != nil {
+			return err
+		}
+		if len(restrictions) == 0 {
+			return nil
+		}
+		return tx.Create(&restrictions).Error
+	})
+}
+
+func (r *stayRestrictionRepository) DeleteRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) error {
+	return scopeRestrictions(r.db, hotelId, roomId, from, to).Delete(&domain.StayRestriction{}).Error
+}
+
+// GetRestrictions returns every row of the hotel, or only the hotel-wide and that room's rows when roomId is set
+func (r *stayRestrictionRepository) GetRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) ([]domain.StayRestriction, error) {
+	var restrictions []domain.StayRestriction
+	query := r.db.Where("hotel_id = ? AND date >= ? AND date <= ?", hotelId, from, to)
+	if roomId != nil {
+		query = query.Where("(room_id IS NULL OR room_id = ?)", roomId.String())
+	}
+	if err := query.Order("date ASC").Find(&restrictions).Error; err != nil {
+		return nil, err
+	}
+	return restrictions, nil
+}
+
+func scopeRestrictions(db *gorm.DB, hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) *gorm.DB {
+	query := db.Where("hotel_id = ? AND date >= ? AND date <= ?", hotelId, from, to)
+	if roomId == nil {
+		return query.Where("room_id IS NULL")
+	}
+	return query.Where("room_id = ?", roomId.String())
+}
@@ -13,6 +13,8 @@ type UserRepository interface {
 	GetAllUsers() ([]domain.User, error)
 	GetUserById(id string) (*domain.User, error)
 	GetUserByEmail(email string) (*domain.User, error)
+	GetUserByUsername(username string) (*domain.User, error)
+	UpdateUser(user *domain.User) error
 }
 type userRepository struct {
 	db *gorm.DB
@@ -56,3 +58,15 @@ func (r *userRepository) GetUserByEmail(email string) (*domain.User, error) {
 	}
 	return &user, nil
 }
+
+func (r *userRepository) GetUserByUsername(username string) (*domain.User, error) {
+	var user domain.User
+	if err := r.db.First(&user, "username = ?", username).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) UpdateUser(user *domain.User) error {
+	return r.db.Save(user).Error
+}
@@ -26,7 +26,8 @@ func setupTestDB(t *testing.T) *gorm.DB {
 			password TEXT,
 			created_at DATETIME,
 			updated_at DATETIME,
-			is_admin INTEGER DEFAULT 0
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
 		)
 	`).Error
 	if err != nil {
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"backend/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WaitlistRepository interface {
+	CreateEntry(entry *domain.WaitlistEntry) error
+	UpdateEntry(entry *domain.WaitlistEntry) error
+	GetEntryById(id string) (*domain.WaitlistEntry, error)
+	GetEntryByOfferTokenHash(tokenHash string) (*domain.WaitlistEntry, error)
+	GetEntriesByUserId(userId string) ([]domain.WaitlistEntry, error)
+	GetWaitingEntriesByRoomId(roomId string) ([]domain.WaitlistEntry, error)
+	GetOverlappingOffers(roomId string, checkIn time.Time, checkOut time.Time) ([]domain.WaitlistEntry, error)
+	GetExpiredOffers(now time.Time) ([]domain.WaitlistEntry, error)
+}
+
+type waitlistRepository struct {
+	db *gorm.DB
+}
+
+func NewWaitlistRepository(db *gorm.DB) WaitlistRepository {
+	return &waitlistRepository{db: db}
+}
+
+func (r *waitlistRepository) CreateEntry(entry *domain.WaitlistEntry) error {
+	return r.db.Create(entry).Error
+}
+
+func (r *waitlistRepository) UpdateEntry(entry *domain.WaitlistEntry) error {
+	return r.db.Save(entry).Error
+}
+
+func (r *waitlistRepository) GetEntryById(id string) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	if err := r.db.First(&entry, "id = ?", id).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepository) GetEntryByOfferTokenHash(tokenHash string) (*domain.WaitlistEntry, error) {
+	var entry domain.WaitlistEntry
+	if err := r.db.First(&entry, "offer_token_hash = ?", tokenHash).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+func (r *waitlistRepository) GetEntriesByUserId(userId string) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	if err := r.db.Where("user_id = ?", userId).Order("created_at DESC").Find(&entries).Error; err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetWaitingEntriesByRoomId returns the queue for a room, oldest first
+func (r *waitlistRepository) GetWaitingEntriesByRoomId(roomId string) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.Where("room_id = ? AND status = ?", roomId, domain.WaitlistStatusWaiting).
+		Order("created_at ASC").
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *waitlistRepository) GetOverlappingOffers(roomId string, checkIn time.Time, checkOut time.Time) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.Where("room_id = ? AND status = ? AND check_in_date < ? AND check_out_date > ?",
+		roomId, domain.WaitlistStatusOffered, checkOut, checkIn).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func (r *waitlistRepository) GetExpiredOffers(now time.Time) ([]domain.WaitlistEntry, error) {
+	var entries []domain.WaitlistEntry
+	err := r.db.Where("status = ? AND offer_expires_at < ?", domain.WaitlistStatusOffered, now).
+		Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
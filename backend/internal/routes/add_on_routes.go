@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupAddOnRoutes(router *gin.Engine, db *gorm.DB) {
+	addOnController := controller.NewAddOnController(newAddOnService(db))
+	permissions := newPermissionMiddleware(db)
+	manageRates := permissions.RequirePermission(domain.PermissionRatesManage, middleware.HotelParam("id"))
+
+	addOnRouter := router.Group("/hotels/:id/add-ons")
+	{
+		addOnRouter.GET("", addOnController.GetAddOns)
+		addOnRouter.POST("", manageRates, addOnController.CreateAddOn)
+		addOnRouter.PUT("/:addOnId", manageRates, addOnController.UpdateAddOn)
+		addOnRouter.DELETE("/:addOnId", manageRates, addOnController.DeactivateAddOn)
+	}
+}
+
+func newAddOnService(db *gorm.DB) service.AddOnService {
+	return service.NewAddOnService(repository.NewAddOnRepository(db), repository.NewHotelRepository(db))
+}
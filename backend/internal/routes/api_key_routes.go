@@ -0,0 +1,37 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupAPIKeyRoutes(router *gin.Engine, db *gorm.DB) {
+	apiKeyController := controller.NewAPIKeyController(newAPIKeyService(db))
+	permissions := newPermissionMiddleware(db)
+
+	apiKeyRouter := router.Group("/api-keys", permissions.RequireAdmin())
+	{
+		apiKeyRouter.POST("", apiKeyController.CreateAPIKey)
+		apiKeyRouter.GET("", apiKeyController.GetAPIKeys)
+		apiKeyRouter.GET("/:id", apiKeyController.GetAPIKey)
+		apiKeyRouter.DELETE("/:id", apiKeyController.RevokeAPIKey)
+	}
+}
+
+func newAPIKeyService(db *gorm.DB) service.APIKeyService {
+	return service.NewAPIKeyService(
+		repository.NewAPIKeyRepository(db),
+		repository.NewUserRepository(db),
+		service.NewAuditService(repository.NewAuditRepository(db)),
+	)
+}
+
+// newAPIKeyMiddleware accepts partners' X-API-Key header on routes that also take a bearer token
+func newAPIKeyMiddleware(db *gorm.DB) *middleware.APIKeyMiddleware {
+	return middleware.NewAPIKeyMiddleware(newAPIKeyService(db))
+}
@@ -1,23 +1,107 @@
 package routes
 
 import (
+	"backend/config"
 	"backend/internal/controller"
+	"backend/internal/mail"
+	"backend/internal/middleware"
+	"backend/internal/oauth"
 	"backend/internal/repository"
 	"backend/internal/service"
+	"os"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
 
 func SetupAuthRoutes(router *gin.Engine, db *gorm.DB) {
-	userRepository := repository.NewUserRepository(db)
-	authService := service.NewAuthService(userRepository)
+	authService := newAuthService(db)
 	authController := controller.NewAuthController(authService)
+	mfaController := controller.NewMFAController(newMFAService(db))
+	permissions := newPermissionMiddleware(db)
 
 	authRouter := router.Group("/auth")
 	{
 		authRouter.POST("/login", authController.Login)
 		authRouter.POST("/register", authController.Register)
 		authRouter.POST("/refresh", authController.RefreshToken)
+		authRouter.POST("/logout", authController.Logout)
+		authRouter.POST("/logout-all", middleware.RequireLogin(), authController.LogoutAll)
+		authRouter.POST("/forgot-password", authController.ForgotPassword)
+		authRouter.POST("/reset-password", authController.ResetPassword)
+		authRouter.GET("/verify", authController.VerifyEmail)
+		authRouter.POST("/resend-verification", middleware.RequireLogin(), authController.ResendVerification)
+		authRouter.POST("/mfa/verify", authController.VerifyMFA)
+		authRouter.GET("/providers", authController.GetIdentityProviders)
+		authRouter.POST("/oidc/:provider/start", authController.StartExternalLogin)
+		authRouter.POST("/oidc/:provider/callback", authController.CompleteExternalLogin)
 	}
+
+	mfaRouter := router.Group("/auth/mfa", middleware.RequireLogin())
+	{
+		mfaRouter.GET("", mfaController.GetStatus)
+		mfaRouter.POST("/enroll", mfaController.Enroll)
+		mfaRouter.POST("/confirm", mfaController.Confirm)
+		mfaRouter.POST("/recovery-codes", mfaController.RegenerateRecoveryCodes)
+		mfaRouter.POST("/disable", mfaController.Disable)
+	}
+
+	router.POST("/users/:id/unlock", permissions.RequireAdmin(), authController.UnlockAccount)
+	router.GET("/.well-known/jwks.json", authController.JWKS)
+}
+
+func newAuthService(db *gorm.DB) service.IAuthService {
+	return service.NewAuthService(repository.NewUserRepository(db), repository.NewAuthRepository(db), repository.NewExternalIdentityRepository(db), newMFAService(db), identityProviders(), newMailer(), newAuthSettings())
+}
+
+// identityProviders are the OIDC providers configured by OIDC_PROVIDERS, loaded once so discovery
+// documents and signing keys are cached across requests
+var identityProviders = sync.OnceValue(func() map[string]oauth.IdentityProvider {
+	return oauth.LoadProviders(config.GetEnv("APP_URL", "http://localhost:5173"))
+})
+
+func newMFAService(db *gorm.DB) service.MFAService {
+	return service.NewMFAService(repository.NewMFARepository(db), repository.NewUserRepository(db), config.GetEnv("MFA_ISSUER", "Hotel Booking"))
+}
+
+func newAuthSettings() service.AuthSettings {
+	return service.AuthSettings{
+		AppURL:                      config.GetEnv("APP_URL", "http://localhost:5173"),
+		APIURL:                      config.GetEnv("API_URL", "http://localhost:8080"),
+		PasswordResetTTL:            config.GetEnvDuration("PASSWORD_RESET_TTL", time.Hour),
+		EmailVerificationTTL:        config.GetEnvDuration("EMAIL_VERIFICATION_TTL", 24*time.Hour),
+		VerificationResendInterval:  config.GetEnvDuration("VERIFICATION_RESEND_INTERVAL", time.Minute),
+		PasswordResetResendInterval: config.GetEnvDuration("PASSWORD_RESET_RESEND_INTERVAL", time.Minute),
+		RequireAdminMFA:             config.GetEnvBool("REQUIRE_ADMIN_MFA", false),
+		AccountLoginThrottle: service.LoginThrottlePolicy{
+			FreeAttempts:     3,
+			BaseDelay:        time.Second,
+			MaxDelay:         30 * time.Second,
+			LockoutThreshold: config.GetEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+			LockoutDuration:  config.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		},
+		// Many people can share an IP, so it gets more room than an account
+		IPLoginThrottle: service.LoginThrottlePolicy{
+			FreeAttempts:     20,
+			BaseDelay:        time.Second,
+			MaxDelay:         30 * time.Second,
+			LockoutThreshold: config.GetEnvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 100),
+			LockoutDuration:  config.GetEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		},
+	}
+}
+
+// bookingEmailVerifier checks the booking user's email is verified when REQUIRE_VERIFIED_EMAIL_TO_BOOK is set, and is nil otherwise
+func bookingEmailVerifier(db *gorm.DB) service.EmailVerifier {
+	if !config.GetEnvBool("REQUIRE_VERIFIED_EMAIL_TO_BOOK", false) {
+		return nil
+	}
+	return newAuthService(db)
+}
+
+// newMailer writes outgoing mail to MAIL_OUTBOX_DIR, or only logs it when that is empty
+func newMailer() mail.Mailer {
+	return mail.NewFileMailer(os.Getenv("MAIL_OUTBOX_DIR"))
 }
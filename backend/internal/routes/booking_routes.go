@@ -2,26 +2,81 @@ package routes
 
 import (
 	"backend/internal/controller"
+	"backend/internal/domain"
 	"backend/internal/middleware"
 	"backend/internal/repository"
 	"backend/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
 )
 
 func SetupBookingRoutes(router *gin.Engine, db *gorm.DB) {
 
-	bookingRepository := repository.NewBookingRepository(db)
-	hotelRepository := repository.NewHotelRepository(db)
-	bookingService := service.NewBookingService(hotelRepository, bookingRepository)
+	bookingService := newBookingService(db)
 	bookingController := controller.NewBookingController(bookingService)
+	permissions := newPermissionMiddleware(db)
+	bookingHotel := bookingHotelScope(db)
+	apiKeys := newAPIKeyMiddleware(db)
 
 	bookingRouter := router.Group("/bookings")
 	{
-		bookingRouter.POST("/", middleware.RequireLogin(), bookingController.CreateBooking)
-		bookingRouter.GET("/", bookingController.GetAllBookings)
-		bookingRouter.GET("/:id", bookingController.GetBookingById)
-		bookingRouter.GET("/user/:user_id", bookingController.GetBookingsByUserId)
+		bookingRouter.POST("/", apiKeys.Authenticate(domain.APIKeyScopeBookingsWrite, middleware.RequireLogin()), bookingController.CreateBooking)
+		bookingRouter.GET("/", permissions.RequireAdmin(), bookingController.GetAllBookings)
+		bookingRouter.GET("/:id", permissions.RequireOwnerOrPermission(bookingOwner(db), domain.PermissionBookingsRead, bookingHotel), bookingController.GetBookingById)
+		// A user's bookings span hotels, so apart from the user only super admins may list them
+		bookingRouter.GET("/user/:user_id", permissions.RequireOwnerOrPermission(userParamOwner("user_id"), domain.PermissionBookingsRead, nil), bookingController.GetBookingsByUserId)
+		bookingRouter.POST("/:id/cancel", apiKeys.Authenticate(domain.APIKeyScopeBookingsWrite, middleware.RequireLogin()), bookingController.CancelBooking)
+		bookingRouter.POST("/:id/add-ons", middleware.RequireLogin(), bookingController.AddAddOn)
+		bookingRouter.DELETE("/:id/add-ons/:addOnId", middleware.RequireLogin(), bookingController.RemoveAddOn)
+		bookingRouter.GET("/:id/invoice", apiKeys.Authenticate(domain.APIKeyScopeBookingsRead, middleware.RequireLogin()), bookingController.GetInvoice)
+		bookingRouter.PUT("/:id/guest", middleware.RequireLogin(), bookingController.UpdateGuestDetails)
+		bookingRouter.POST("/:id/check-in", permissions.RequirePermission(domain.PermissionBookingsCheckIn, bookingHotel), bookingController.CheckIn)
+		bookingRouter.POST("/:id/check-out", permissions.RequirePermission(domain.PermissionBookingsCheckOut, bookingHotel), bookingController.CheckOut)
+	}
+
+	router.GET("/hotels/:id/bookings", permissions.RequirePermission(domain.PermissionBookingsRead, middleware.HotelParam("id")), bookingController.GetHotelBookings)
+}
+
+func newBookingService(db *gorm.DB) service.BookingService {
+	return service.NewBookingService(
+		repository.NewHotelRepository(db),
+		repository.NewBookingRepository(db),
+		newAddOnService(db),
+		newStayRestrictionService(db),
+		newWaitlistService(db),
+		bookingEmailVerifier(db),
+	)
+}
+
+// bookingHotelScope scopes a permission to the hotel of the booking in the id path parameter
+func bookingHotelScope(db *gorm.DB) middleware.HotelScope {
+	bookingRepository := repository.NewBookingRepository(db)
+	return func(ctx *gin.Context) (*uuid.UUID, error) {
+		booking, err := bookingRepository.GetBookingById(ctx.Param("id"))
+		if err != nil {
+			return nil, middleware.ErrScopeNotFound
+		}
+		return &booking.HotelId, nil
+	}
+}
+
+// bookingOwner checks the signed-in user made the booking in the id path parameter
+func bookingOwner(db *gorm.DB) middleware.OwnerCheck {
+	bookingRepository := repository.NewBookingRepository(db)
+	return func(ctx *gin.Context, user *domain.User) (bool, error) {
+		booking, err := bookingRepository.GetBookingById(ctx.Param("id"))
+		if err != nil {
+			return false, middleware.ErrScopeNotFound
+		}
+		return booking.UserId == user.Id, nil
+	}
+}
+
+// userParamOwner checks the signed-in user is the user whose id is in the named path parameter
+func userParamOwner(name string) middleware.OwnerCheck {
+	return func(ctx *gin.Context, user *domain.User) (bool, error) {
+		return ctx.Param(name) == user.Id.String(), nil
 	}
 }
@@ -2,7 +2,6 @@ package routes
 
 import (
 	"backend/internal/controller"
-	"backend/internal/middleware"
 	"backend/internal/repository"
 	"backend/internal/service"
 
@@ -12,13 +11,15 @@ import (
 
 func SetupHotelRoutes(router *gin.Engine, db *gorm.DB) {
 	hotelRepository := repository.NewHotelRepository(db)
-	hotelService := service.NewHotelService(hotelRepository)
+	hotelService := service.NewHotelService(hotelRepository, repository.NewOrganizationRepository(db), newSearchService(db), newStayRestrictionService(db))
 	hotelController := controller.NewHotelController(hotelService)
+	permissions := newPermissionMiddleware(db)
 
 	hotelRouter := router.Group("/hotels")
 	{
-		hotelRouter.POST("/", middleware.RequireAdmin(), hotelController.CreateHotel)
+		hotelRouter.POST("/", permissions.RequireAdmin(), hotelController.CreateHotel)
 		hotelRouter.GET("/", hotelController.GetAllHotels)
 		hotelRouter.GET("/:id", hotelController.GetHotelById)
+		hotelRouter.GET("/:id/price-calendar", hotelController.GetPriceCalendar)
 	}
 }
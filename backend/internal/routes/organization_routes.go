@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupOrganizationRoutes(router *gin.Engine, db *gorm.DB) {
+	organizationController := controller.NewOrganizationController(newOrganizationService(db))
+	permissions := newPermissionMiddleware(db)
+
+	organizationRouter := router.Group("/organizations")
+	{
+		organizationRouter.POST("", permissions.RequireAdmin(), organizationController.CreateOrganization)
+		organizationRouter.GET("", organizationController.GetOrganizations)
+		organizationRouter.GET("/:orgId", organizationController.GetOrganization)
+		organizationRouter.PUT("/:orgId", permissions.RequireAdmin(), organizationController.UpdateOrganization)
+		organizationRouter.GET("/:orgId/hotels", organizationController.GetOrganizationHotels)
+		organizationRouter.GET("/:orgId/reports", permissions.RequireAdmin(), organizationController.GetReport)
+	}
+
+	hotelRouter := router.Group("/hotels/:id/organization", permissions.RequireAdmin())
+	{
+		hotelRouter.PUT("", organizationController.MoveHotel)
+		hotelRouter.GET("/history", organizationController.GetHotelOrganizationHistory)
+	}
+}
+
+func newOrganizationService(db *gorm.DB) service.OrganizationService {
+	return service.NewOrganizationService(repository.NewOrganizationRepository(db), repository.NewHotelRepository(db))
+}
@@ -0,0 +1,42 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"backend/internal/storage"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupPhotoRoutes(router *gin.Engine, db *gorm.DB) {
+	blobStore := newLocalBlobStore()
+	photoService := service.NewPhotoService(repository.NewPhotoRepository(db), repository.NewHotelRepository(db), blobStore)
+	photoController := controller.NewPhotoController(photoService)
+
+	// Uploaded files are served straight from the storage directory
+	router.Static("/media", blobStore.Root())
+
+	router.MaxMultipartMemory = service.MaxPhotoBytes
+	permissions := newPermissionMiddleware(db)
+	photoRouter := router.Group("/hotels/:id", permissions.RequirePermission(domain.PermissionPhotosManage, middleware.HotelParam("id")))
+	{
+		photoRouter.POST("/photos", photoController.UploadHotelPhoto)
+		photoRouter.POST("/rooms/:roomId/photos", photoController.UploadRoomPhoto)
+		photoRouter.PUT("/photos/order", photoController.ReorderPhotos)
+		photoRouter.PUT("/photos/:photoId/cover", photoController.SetCoverPhoto)
+		photoRouter.DELETE("/photos/:photoId", photoController.DeletePhoto)
+	}
+}
+
+func newLocalBlobStore() *storage.LocalBlobStore {
+	root := os.Getenv("MEDIA_ROOT")
+	if root == "" {
+		root = "uploads"
+	}
+	return storage.NewLocalBlobStore(root, "/media")
+}
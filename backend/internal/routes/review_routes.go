@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"backend/config"
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupReviewRoutes(router *gin.Engine, db *gorm.DB) {
+	reviewController := controller.NewReviewController(newReviewService(db))
+	permissions := newPermissionMiddleware(db)
+
+	reviewRouter := router.Group("/hotels/:id/reviews")
+	{
+		reviewRouter.GET("", reviewController.GetReviews)
+		reviewRouter.POST("", middleware.RequireLogin(), reviewController.CreateReview)
+		reviewRouter.PUT("/:reviewId/reply", permissions.RequirePermission(domain.PermissionReviewsReply, middleware.HotelParam("id")), reviewController.ReplyToReview)
+	}
+
+	moderationRouter := router.Group("/reviews")
+	{
+		moderationRouter.GET("/moderation", permissions.RequireAdmin(), reviewController.GetModerationQueue)
+		moderationRouter.PUT("/:reviewId/moderation", permissions.RequireAdmin(), reviewController.ModerateReview)
+		moderationRouter.GET("/:reviewId/moderation", permissions.RequireAdmin(), reviewController.GetModerationHistory)
+		moderationRouter.POST("/:reviewId/reports", middleware.RequireLogin(), reviewController.ReportReview)
+		moderationRouter.GET("/:reviewId/reports", permissions.RequireAdmin(), reviewController.GetReports)
+	}
+}
+
+func newReviewService(db *gorm.DB) service.ReviewService {
+	return service.NewReviewService(
+		repository.NewReviewRepository(db),
+		repository.NewBookingRepository(db),
+		service.NewAuditService(repository.NewAuditRepository(db)),
+		config.GetEnvList("REVIEW_BANNED_WORDS", nil),
+	)
+}
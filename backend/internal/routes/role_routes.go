@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupRoleRoutes(router *gin.Engine, db *gorm.DB) {
+	roleController := controller.NewRoleController(newRoleService(db))
+
+	router.GET("/roles", roleController.GetRoles)
+
+	userRoleRouter := router.Group("/users/:id/roles", middleware.RequireLogin())
+	{
+		userRoleRouter.GET("", roleController.GetUserRoles)
+		userRoleRouter.POST("", roleController.GrantRole)
+		userRoleRouter.DELETE("", roleController.RevokeRole)
+	}
+}
+
+func newRoleService(db *gorm.DB) service.RoleService {
+	return service.NewRoleService(
+		repository.NewRoleRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewHotelRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewAuthRepository(db),
+		service.NewAuditService(repository.NewAuditRepository(db)),
+	)
+}
+
+// newPermissionMiddleware checks staff permissions against the role assignments in the database
+func newPermissionMiddleware(db *gorm.DB) *middleware.PermissionMiddleware {
+	return middleware.NewPermissionMiddleware(newRoleService(db))
+}
@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupSearchRoutes(router *gin.Engine, db *gorm.DB) {
+	searchService := newSearchService(db)
+	hotelService := service.NewHotelService(repository.NewHotelRepository(db), repository.NewOrganizationRepository(db), searchService, newStayRestrictionService(db))
+	searchController := controller.NewSearchController(searchService, hotelService)
+
+	// Search is public; partners' API keys are checked and counted when they send one
+	searchRouter := router.Group("/search", newAPIKeyMiddleware(db).Authenticate(domain.APIKeyScopeSearch, nil))
+	{
+		searchRouter.GET("/hotels", searchController.SearchHotels)
+		searchRouter.GET("/suggest", searchController.Suggest)
+	}
+}
+
+func newSearchService(db *gorm.DB) service.SearchService {
+	return service.NewSearchService(repository.NewSearchRepository(db), repository.NewHotelRepository(db))
+}
@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupStayRestrictionRoutes(router *gin.Engine, db *gorm.DB) {
+	stayRestrictionController := controller.NewStayRestrictionController(newStayRestrictionService(db))
+	permissions := newPermissionMiddleware(db)
+	manageRates := permissions.RequirePermission(domain.PermissionRatesManage, middleware.HotelParam("id"))
+
+	restrictionRouter := router.Group("/hotels/:id/restrictions")
+	{
+		restrictionRouter.GET("", stayRestrictionController.GetRestrictions)
+		restrictionRouter.PUT("", manageRates, stayRestrictionController.SetRestrictions)
+		restrictionRouter.DELETE("", manageRates, stayRestrictionController.ClearRestrictions)
+	}
+}
+
+func newStayRestrictionService(db *gorm.DB) service.StayRestrictionService {
+	return service.NewStayRestrictionService(repository.NewStayRestrictionRepository(db), repository.NewHotelRepository(db))
+}
@@ -0,0 +1,44 @@
+package routes
+
+import (
+	"backend/config"
+	"backend/internal/controller"
+	"backend/internal/domain"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupTeamRoutes(router *gin.Engine, db *gorm.DB) {
+	teamController := controller.NewTeamController(newTeamService(db))
+	permissions := newPermissionMiddleware(db)
+	manageRoles := permissions.RequirePermission(domain.PermissionRolesManage, middleware.HotelParam("id"))
+
+	hotelRouter := router.Group("/hotels/:id")
+	{
+		hotelRouter.POST("/invitations", manageRoles, teamController.InviteStaff)
+		hotelRouter.GET("/invitations", manageRoles, teamController.GetInvitations)
+		hotelRouter.DELETE("/invitations/:invitationId", manageRoles, teamController.RevokeInvitation)
+		hotelRouter.GET("/team", permissions.RequirePermission(domain.PermissionTeamRead, middleware.HotelParam("id")), teamController.GetTeam)
+	}
+
+	router.POST("/invitations/accept", teamController.AcceptInvitation)
+}
+
+func newTeamService(db *gorm.DB) service.TeamService {
+	return service.NewTeamService(
+		repository.NewInvitationRepository(db),
+		repository.NewRoleRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewHotelRepository(db),
+		newRoleService(db),
+		service.NewAuditService(repository.NewAuditRepository(db)),
+		newMailer(),
+		newAuthSettings().AppURL,
+		config.GetEnvDuration("INVITATION_TTL", 7*24*time.Hour),
+	)
+}
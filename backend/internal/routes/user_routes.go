@@ -16,6 +16,7 @@ func SetupUserRoutes(router *gin.Engine, db *gorm.DB) {
 	userRepository := repository.NewUserRepository(db)
 	userService := service.NewUserService(userRepository)
 	userController := controller.NewUserController(userService)
+	permissions := newPermissionMiddleware(db)
 
 	userRouter := router.Group("/users")
 	{
@@ -32,7 +33,7 @@ func SetupUserRoutes(router *gin.Engine, db *gorm.DB) {
 		// @Failure      401  {object}  shared.ErrorResponse
 		// @Failure      403  {object}  shared.ErrorResponse
 		// @Router       /users/admin [get]
-		userRouter.GET("/admin", middleware.RequireAdmin(), func(ctx *gin.Context) {
+		userRouter.GET("/admin", permissions.RequireAdmin(), func(ctx *gin.Context) {
 			ctx.JSON(http.StatusOK, shared.ApiResponse{Message: "Admin route accessed successfully"})
 		})
 		// TestAuthRoute godoc
@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"backend/config"
+	"backend/internal/controller"
+	"backend/internal/middleware"
+	"backend/internal/repository"
+	"backend/internal/service"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func SetupWaitlistRoutes(router *gin.Engine, db *gorm.DB) {
+	waitlistService := newWaitlistService(db)
+	bookingService := newBookingService(db)
+	waitlistController := controller.NewWaitlistController(waitlistService, bookingService)
+
+	waitlistRouter := router.Group("/waitlist")
+	{
+		waitlistRouter.POST("/", middleware.RequireLogin(), waitlistController.JoinWaitlist)
+		waitlistRouter.GET("/me", middleware.RequireLogin(), waitlistController.GetMyWaitlist)
+		waitlistRouter.DELETE("/:id", middleware.RequireLogin(), waitlistController.LeaveWaitlist)
+		// Claiming an offer books the room
+		waitlistRouter.POST("/claim/:token", middleware.RequireLogin(), waitlistController.ClaimOffer)
+	}
+}
+
+func newWaitlistService(db *gorm.DB) service.WaitlistService {
+	return service.NewWaitlistService(
+		repository.NewWaitlistRepository(db),
+		repository.NewHotelRepository(db),
+		repository.NewBookingRepository(db),
+		repository.NewUserRepository(db),
+		newMailer(),
+		config.GetEnv("APP_URL", "http://localhost:5173"),
+		config.GetEnvDuration("WAITLIST_OFFER_TTL", 30*time.Minute),
+	)
+}
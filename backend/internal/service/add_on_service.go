@@ -0,0 +1,132 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrAddOnNotFound = errors.New("add-on not found")
+
+type AddOnService interface {
+	CreateAddOn(hotelId string, request *domain.AddOnRequest) (*domain.AddOn, error)
+	UpdateAddOn(hotelId string, addOnId string, request *domain.AddOnRequest) (*domain.AddOn, error)
+	DeactivateAddOn(hotelId string, addOnId string) error
+	GetAddOnsByHotelId(hotelId string, includeInactive bool) ([]domain.AddOn, error)
+	PriceSelections(hotelId uuid.UUID, selections []domain.AddOnSelection, nights int, guests int) ([]*domain.BookingAddOn, error)
+}
+
+type addOnService struct {
+	addOnRepository repository.AddOnRepository
+	hotelRepository repository.HotelRepository
+}
+
+func NewAddOnService(addOnRepository repository.AddOnRepository, hotelRepository repository.HotelRepository) AddOnService {
+	return &addOnService{addOnRepository: addOnRepository, hotelRepository: hotelRepository}
+}
+
+func (s *addOnService) CreateAddOn(hotelId string, request *domain.AddOnRequest) (*domain.AddOn, error) {
+	hotel, err := s.hotelRepository.GetHotelById(hotelId)
+	if err != nil {
+		return nil, err
+	}
+
+	addOn := &domain.AddOn{
+		Id:          uuid.New(),
+		HotelId:     hotel.Id,
+		Name:        request.Name,
+		Description: request.Description,
+		Price:       request.Price,
+		Pricing:     request.Pricing,
+		Active:      request.Active == nil || *request.Active,
+	}
+	if err := s.addOnRepository.CreateAddOn(addOn); err != nil {
+		return nil, err
+	}
+	return addOn, nil
+}
+
+func (s *addOnService) UpdateAddOn(hotelId string, addOnId string, request *domain.AddOnRequest) (*domain.AddOn, error) {
+	addOn, err := s.getHotelAddOn(hotelId, addOnId)
+	if err != nil {
+		return nil, err
+	}
+
+	addOn.Name = request.Name
+	addOn.Description = request.Description
+	addOn.Price = request.Price
+	addOn.Pricing = request.Pricing
+	if request.Active != nil {
+		addOn.Active = *request.Active
+	}
+	if err := s.addOnRepository.UpdateAddOn(addOn); err != nil {
+		return nil, err
+	}
+	return addOn, nil
+}
+
+// DeactivateAddOn removes an add-on from sale. Bookings that already include it keep their copy.
+func (s *addOnService) DeactivateAddOn(hotelId string, addOnId string) error {
+	addOn, err := s.getHotelAddOn(hotelId, addOnId)
+	if err != nil {
+		return err
+	}
+	addOn.Active = false
+	return s.addOnRepository.UpdateAddOn(addOn)
+}
+
+func (s *addOnService) GetAddOnsByHotelId(hotelId string, includeInactive bool) ([]domain.AddOn, error) {
+	return s.addOnRepository.GetAddOnsByHotelId(hotelId, !includeInactive)
+}
+
+/*
+PriceSelections
+Params: hotel id, selected add-ons, number of nights, number of guests
+Returns: booking add-on lines ready to attach to a booking, error
+Description: Resolve each selection against the hotel's active catalogue and price it
+per stay, per night or per guest, multiplied by the selected quantity
+*/
+func (s *addOnService) PriceSelections(hotelId uuid.UUID, selections []domain.AddOnSelection, nights int, guests int) ([]*domain.BookingAddOn, error) {
+	var lines []*domain.BookingAddOn
+	for _, selection := range selections {
+		addOn, err := s.addOnRepository.GetAddOnById(selection.AddOnId.String())
+		if err != nil || addOn.HotelId != hotelId || !addOn.Active {
+			return nil, fmt.Errorf("%w: %s", ErrAddOnNotFound, selection.AddOnId)
+		}
+
+		quantity := selection.Quantity
+		if quantity <= 0 {
+			quantity = 1
+		}
+
+		units := quantity
+		switch addOn.Pricing {
+		case domain.AddOnPricingPerNight:
+			units *= nights
+		case domain.AddOnPricingPerGuest:
+			units *= guests
+		}
+
+		lines = append(lines, &domain.BookingAddOn{
+			Id:         uuid.New(),
+			AddOnId:    addOn.Id,
+			Name:       addOn.Name,
+			Pricing:    addOn.Pricing,
+			UnitPrice:  addOn.Price,
+			Quantity:   quantity,
+			TotalPrice: addOn.Price * float64(units),
+		})
+	}
+	return lines, nil
+}
+
+func (s *addOnService) getHotelAddOn(hotelId string, addOnId string) (*domain.AddOn, error) {
+	addOn, err := s.addOnRepository.GetAddOnById(addOnId)
+	if err != nil || addOn.HotelId.String() != hotelId {
+		return nil, ErrAddOnNotFound
+	}
+	return addOn, nil
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupAddOnServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupBookingServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE add_ons (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			name TEXT,
+			description TEXT,
+			price REAL,
+			pricing TEXT,
+			active INTEGER DEFAULT 1
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	return db
+}
+
+func TestAddOnService_PriceSelections(t *testing.T) {
+	db := setupAddOnServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	service := NewAddOnService(repository.NewAddOnRepository(db), repository.NewHotelRepository(db))
+
+	breakfast, err := service.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Breakfast", Price: 15, Pricing: domain.AddOnPricingPerGuest})
+	assert.NoError(t, err)
+	parking, err := service.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Parking", Price: 10, Pricing: domain.AddOnPricingPerNight})
+	assert.NoError(t, err)
+	transfer, err := service.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Airport transfer", Price: 40, Pricing: domain.AddOnPricingPerStay})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		selection     domain.AddOnSelection
+		expectedTotal float64
+	}{
+		{name: "per guest", selection: domain.AddOnSelection{AddOnId: breakfast.Id}, expectedTotal: 45},            // 15 * 3 guests
+		{name: "per night", selection: domain.AddOnSelection{AddOnId: parking.Id, Quantity: 2}, expectedTotal: 80}, // 10 * 4 nights * 2 spots
+		{name: "per stay", selection: domain.AddOnSelection{AddOnId: transfer.Id}, expectedTotal: 40},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines, err := service.PriceSelections(hotelId, []domain.AddOnSelection{tt.selection}, 4, 3)
+			assert.NoError(t, err)
+			assert.Len(t, lines, 1)
+			assert.InDelta(t, tt.expectedTotal, lines[0].TotalPrice, 0.01)
+		})
+	}
+
+	// Inactive add-ons and add-ons of other hotels cannot be selected
+	assert.NoError(t, service.DeactivateAddOn(hotelId.String(), transfer.Id.String()))
+	_, err = service.PriceSelections(hotelId, []domain.AddOnSelection{{AddOnId: transfer.Id}}, 4, 3)
+	assert.ErrorIs(t, err, ErrAddOnNotFound)
+	_, err = service.PriceSelections(uuid.New(), []domain.AddOnSelection{{AddOnId: breakfast.Id}}, 4, 3)
+	assert.ErrorIs(t, err, ErrAddOnNotFound)
+}
+
+func TestBookingService_AddOnsOnBooking(t *testing.T) {
+	db := setupAddOnServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+
+	hotelRepo := repository.NewHotelRepository(db)
+	addOns := NewAddOnService(repository.NewAddOnRepository(db), hotelRepo)
+	bookings := NewBookingService(hotelRepo, repository.NewBookingRepository(db), addOns, nil, nil, nil)
+
+	breakfast, err := addOns.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Breakfast", Price: 15, Pricing: domain.AddOnPricingPerGuest})
+	assert.NoError(t, err)
+	parking, err := addOns.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Parking", Price: 10, Pricing: domain.AddOnPricingPerNight})
+	assert.NoError(t, err)
+
+	guest := &domain.User{Id: uuid.New()}
+	checkIn := time.Date(time.Now().Year()+1, 6, 1, 14, 0, 0, 0, time.UTC)
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId:      hotelId,
+		UserId:       guest.Id,
+		RoomId:       roomId,
+		CheckInDate:  checkIn,
+		CheckOutDate: checkIn.AddDate(0, 0, 2),
+		Guests:       2,
+		AddOns:       []domain.AddOnSelection{{AddOnId: breakfast.Id}},
+	})
+	assert.NoError(t, err)
+
+	created, err := bookings.GetBookingsByUserId(guest.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, created, 1)
+	booking := created[0]
+	assert.Len(t, booking.AddOns, 1)
+	assert.InDelta(t, 230.0, booking.TotalPrice, 0.01) // 2 nights * 100 + breakfast 15 * 2 guests
+
+	line, err := bookings.AddAddOn(booking.Id.String(), guest, &domain.AddOnSelection{AddOnId: parking.Id})
+	assert.NoError(t, err)
+	updated, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.InDelta(t, 250.0, updated.TotalPrice, 0.01)
+
+	invoice, err := bookings.GetInvoice(booking.Id.String(), guest)
+	assert.NoError(t, err)
+	assert.Len(t, invoice.Lines, 3)
+	assert.InDelta(t, 200.0, invoice.Lines[0].Amount, 0.01)
+	assert.InDelta(t, 250.0, invoice.Total, 0.01)
+
+	_, err = bookings.GetInvoice(booking.Id.String(), &domain.User{Id: uuid.New()})
+	assert.ErrorIs(t, err, ErrBookingNotOwnedByUser)
+
+	assert.NoError(t, bookings.RemoveAddOn(booking.Id.String(), line.Id.String(), guest))
+	updated, err = bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.InDelta(t, 230.0, updated.TotalPrice, 0.01)
+	assert.Len(t, updated.AddOns, 1)
+}
+
+func TestBookingService_AddOnChangesClosedAfterCheckIn(t *testing.T) {
+	db := setupAddOnServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+
+	hotelRepo := repository.NewHotelRepository(db)
+	addOns := NewAddOnService(repository.NewAddOnRepository(db), hotelRepo)
+	bookings := NewBookingService(hotelRepo, repository.NewBookingRepository(db), addOns, nil, nil, nil)
+
+	parking, err := addOns.CreateAddOn(hotelId.String(), &domain.AddOnRequest{Name: "Parking", Price: 10, Pricing: domain.AddOnPricingPerNight})
+	assert.NoError(t, err)
+
+	guest := &domain.User{Id: uuid.New()}
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId:      hotelId,
+		UserId:       guest.Id,
+		RoomId:       roomId,
+		CheckInDate:  time.Now().Add(-time.Hour),
+		CheckOutDate: time.Now().AddDate(0, 0, 2),
+	})
+	assert.NoError(t, err)
+	created, err := bookings.GetBookingsByUserId(guest.Id.String())
+	assert.NoError(t, err)
+
+	_, err = bookings.AddAddOn(created[0].Id.String(), guest, &domain.AddOnSelection{AddOnId: parking.Id})
+	assert.ErrorIs(t, err, ErrBookingChangesClosed)
+}
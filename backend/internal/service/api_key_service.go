@@ -0,0 +1,182 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAPIKeyNotFound       = errors.New("API key not found")
+	ErrAPIKeyAlreadyRevoked = errors.New("API key has already been revoked")
+	ErrInvalidAPIKeyScope   = errors.New("invalid API key scope")
+	ErrInvalidAPIKeyExpiry  = errors.New("expires_at must be in the future")
+)
+
+type APIKeyService interface {
+	CreateKey(actor *domain.User, request *domain.APIKeyRequest) (*domain.APIKeyCreatedResponse, error)
+	GetKeys(userId string) ([]domain.APIKey, error)
+	GetKey(id string) (*domain.APIKey, error)
+	RevokeKey(actor *domain.User, id string) (*domain.APIKey, error)
+	AuthenticateAPIKey(key string, scope domain.APIKeyScope) (*domain.APIKey, *domain.User, error)
+}
+
+type apiKeyService struct {
+	apiKeyRepository repository.APIKeyRepository
+	userRepository   repository.UserRepository
+	audit            AuditService
+}
+
+func NewAPIKeyService(apiKeyRepository repository.APIKeyRepository, userRepository repository.UserRepository, audit AuditService) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepository: apiKeyRepository,
+		userRepository:   userRepository,
+		audit:            audit,
+	}
+}
+
+/*
+CreateKey
+Params: acting admin, APIKeyRequest
+Returns: the key, including the only copy of the key itself, error
+Description: Create an API key that acts as the user within the requested scopes. Only its hash is stored,
+so a lost key cannot be shown again and has to be replaced.
+*/
+func (s *apiKeyService) CreateKey(actor *domain.User, request *domain.APIKeyRequest) (*domain.APIKeyCreatedResponse, error) {
+	scopes, err := normalizeAPIKeyScopes(request.Scopes)
+	if err != nil {
+		return nil, err
+	}
+	if request.ExpiresAt != nil && !request.ExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidAPIKeyExpiry
+	}
+	if _, err := s.userRepository.GetUserById(request.UserId.String()); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	key, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	apiKey := domain.APIKey{
+		Id:        uuid.New(),
+		Name:      strings.TrimSpace(request.Name),
+		UserId:    request.UserId,
+		Prefix:    prefix,
+		KeyHash:   auth.HashAPIKey(key),
+		Scopes:    scopes,
+		RateLimit: request.RateLimit,
+		ExpiresAt: request.ExpiresAt,
+		CreatedBy: actor.Id,
+	}
+	if err := s.apiKeyRepository.CreateKey(&apiKey); err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(&actor.Id, AuditActionAPIKeyCreated, AuditEntityAPIKey, apiKey.Id, fmt.Sprintf("%s for user %s", prefix, request.UserId)); err != nil {
+		return nil, err
+	}
+	return &domain.APIKeyCreatedResponse{APIKey: apiKey, Key: key}, nil
+}
+
+// GetKeys lists the user's API keys, or every key when userId is empty
+func (s *apiKeyService) GetKeys(userId string) ([]domain.APIKey, error) {
+	if userId != "" {
+		if _, err := uuid.Parse(userId); err != nil {
+			return nil, ErrUserNotFound
+		}
+	}
+	return s.apiKeyRepository.GetKeys(userId)
+}
+
+func (s *apiKeyService) GetKey(id string) (*domain.APIKey, error) {
+	key, err := s.apiKeyRepository.GetKeyById(id)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (s *apiKeyService) RevokeKey(actor *domain.User, id string) (*domain.APIKey, error) {
+	key, err := s.apiKeyRepository.GetKeyById(id)
+	if err != nil {
+		return nil, ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyAlreadyRevoked
+	}
+
+	now := time.Now()
+	if err := s.apiKeyRepository.RevokeKey(id, now); err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(&actor.Id, AuditActionAPIKeyRevoked, AuditEntityAPIKey, key.Id, key.Prefix); err != nil {
+		return nil, err
+	}
+	key.RevokedAt = &now
+	return key, nil
+}
+
+/*
+AuthenticateAPIKey
+Params: key from the X-API-Key header, scope the request needs
+Returns: the key, the user it acts as, error
+Description: Check the key is live and has the scope, then count the request against its rate limit and
+record its use. Keys only carry the user's guest rights, even when the user is an admin; admin actions
+need a signed-in admin.
+*/
+func (s *apiKeyService) AuthenticateAPIKey(key string, scope domain.APIKeyScope) (*domain.APIKey, *domain.User, error) {
+	apiKey, err := s.apiKeyRepository.GetKeyByHash(auth.HashAPIKey(key))
+	if err != nil {
+		return nil, nil, auth.ErrInvalidAPIKey
+	}
+	now := time.Now()
+	if apiKey.RevokedAt != nil || (apiKey.ExpiresAt != nil && !now.Before(*apiKey.ExpiresAt)) {
+		return nil, nil, auth.ErrInvalidAPIKey
+	}
+	if !apiKey.HasScope(scope) {
+		return nil, nil, auth.ErrAPIKeyScope
+	}
+
+	window := now.Unix() / 60
+	allowed, err := s.apiKeyRepository.UseKey(apiKey.Id.String(), window, now)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !allowed {
+		return nil, nil, &auth.APIKeyRateLimitedError{RetryAfter: time.Unix((window+1)*60, 0).Sub(now)}
+	}
+	apiKey.LastUsedAt = &now
+
+	user, err := s.userRepository.GetUserById(apiKey.UserId.String())
+	if err != nil {
+		return nil, nil, auth.ErrInvalidAPIKey
+	}
+	user.IsAdmin = false
+	return apiKey, user, nil
+}
+
+// normalizeAPIKeyScopes checks every scope is known and drops repeats
+func normalizeAPIKeyScopes(requested []domain.APIKeyScope) ([]domain.APIKeyScope, error) {
+	known := map[domain.APIKeyScope]bool{}
+	for _, scope := range domain.APIKeyScopes {
+		known[scope] = true
+	}
+	seen := map[domain.APIKeyScope]bool{}
+	scopes := make([]domain.APIKeyScope, 0, len(requested))
+	for _, scope := range requested {
+		if !known[scope] {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidAPIKeyScope, scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			scopes = append(scopes, scope)
+		}
+	}
+	return scopes, nil
+}
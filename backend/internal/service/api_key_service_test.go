@@ -0,0 +1,164 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupAPIKeyServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupRoleServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE api_keys (
+			id TEXT PRIMARY KEY,
+			name TEXT,
+			user_id TEXT,
+			prefix TEXT,
+			key_hash TEXT UNIQUE,
+			scopes TEXT,
+			rate_limit INTEGER DEFAULT 0,
+			rate_window INTEGER DEFAULT 0,
+			rate_count INTEGER DEFAULT 0,
+			expires_at DATETIME,
+			last_used_at DATETIME,
+			revoked_at DATETIME,
+			created_by TEXT,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func newAPIKeyTestService(db *gorm.DB) APIKeyService {
+	return NewAPIKeyService(repository.NewAPIKeyRepository(db), repository.NewUserRepository(db), NewAuditService(repository.NewAuditRepository(db)))
+}
+
+func TestAPIKeyService_CreateAndAuthenticate(t *testing.T) {
+	db := setupAPIKeyServiceTestDB(t)
+	apiKeys := newAPIKeyTestService(db)
+	admin := createRoleTestUser(t, db, "admin", true)
+	partner := createRoleTestUser(t, db, "partner", true)
+
+	created, err := apiKeys.CreateKey(admin, &domain.APIKeyRequest{
+		Name:   "Sunny Travel",
+		UserId: partner.Id,
+		Scopes: []domain.APIKeyScope{domain.APIKeyScopeSearch, domain.APIKeyScopeBookingsWrite, domain.APIKeyScopeSearch},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []domain.APIKeyScope{domain.APIKeyScopeSearch, domain.APIKeyScopeBookingsWrite}, created.Scopes)
+	assert.True(t, len(created.Key) > len(created.Prefix))
+	assert.Equal(t, created.Prefix, created.Key[:len(created.Prefix)])
+
+	stored, err := apiKeys.GetKey(created.Id.String())
+	assert.NoError(t, err)
+	assert.NotEqual(t, created.Key, stored.KeyHash)
+	assert.Nil(t, stored.LastUsedAt)
+
+	apiKey, user, err := apiKeys.AuthenticateAPIKey(created.Key, domain.APIKeyScopeBookingsWrite)
+	assert.NoError(t, err)
+	assert.Equal(t, created.Id, apiKey.Id)
+	assert.Equal(t, partner.Id, user.Id)
+	// Keys never carry admin rights
+	assert.False(t, user.IsAdmin)
+
+	stored, err = apiKeys.GetKey(created.Id.String())
+	assert.NoError(t, err)
+	assert.NotNil(t, stored.LastUsedAt)
+
+	_, _, err = apiKeys.AuthenticateAPIKey(created.Key, domain.APIKeyScopeBookingsRead)
+	assert.ErrorIs(t, err, auth.ErrAPIKeyScope)
+	_, _, err = apiKeys.AuthenticateAPIKey(created.Key+"0", domain.APIKeyScopeSearch)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	keys, err := apiKeys.GetKeys(partner.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, keys, 1)
+	keys, err = apiKeys.GetKeys(admin.Id.String())
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	var entries int64
+	db.Model(&domain.AuditEntry{}).Where("action = ?", AuditActionAPIKeyCreated).Count(&entries)
+	assert.Equal(t, int64(1), entries)
+}
+
+func TestAPIKeyService_CreateValidation(t *testing.T) {
+	db := setupAPIKeyServiceTestDB(t)
+	apiKeys := newAPIKeyTestService(db)
+	admin := createRoleTestUser(t, db, "admin", true)
+
+	_, err := apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Bad scope", UserId: admin.Id, Scopes: []domain.APIKeyScope{"bookings:delete"}})
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyScope)
+
+	past := time.Now().Add(-time.Hour)
+	_, err = apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Expired", UserId: admin.Id, Scopes: []domain.APIKeyScope{domain.APIKeyScopeSearch}, ExpiresAt: &past})
+	assert.ErrorIs(t, err, ErrInvalidAPIKeyExpiry)
+
+	_, err = apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Nobody", UserId: uuid.New(), Scopes: []domain.APIKeyScope{domain.APIKeyScopeSearch}})
+	assert.ErrorIs(t, err, ErrUserNotFound)
+}
+
+func TestAPIKeyService_RateLimit(t *testing.T) {
+	db := setupAPIKeyServiceTestDB(t)
+	apiKeys := newAPIKeyTestService(db)
+	admin := createRoleTestUser(t, db, "admin", true)
+
+	created, err := apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Limited", UserId: admin.Id, Scopes: []domain.APIKeyScope{domain.APIKeyScopeSearch}, RateLimit: 2})
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		_, _, err = apiKeys.AuthenticateAPIKey(created.Key, domain.APIKeyScopeSearch)
+		assert.NoError(t, err)
+	}
+	_, _, err = apiKeys.AuthenticateAPIKey(created.Key, domain.APIKeyScopeSearch)
+	var limited *auth.APIKeyRateLimitedError
+	if assert.ErrorAs(t, err, &limited) {
+		assert.True(t, limited.RetryAfter > 0 && limited.RetryAfter <= time.Minute)
+	}
+	assert.ErrorIs(t, err, auth.ErrAPIKeyRateLimited)
+
+	// A new minute starts the count again
+	db.Model(&domain.APIKey{}).Where("id = ?", created.Id).Update("rate_window", time.Now().Unix()/60-1)
+	_, _, err = apiKeys.AuthenticateAPIKey(created.Key, domain.APIKeyScopeSearch)
+	assert.NoError(t, err)
+}
+
+func TestAPIKeyService_RevokedAndExpiredKeysAreRejected(t *testing.T) {
+	db := setupAPIKeyServiceTestDB(t)
+	apiKeys := newAPIKeyTestService(db)
+	admin := createRoleTestUser(t, db, "admin", true)
+	scopes := []domain.APIKeyScope{domain.APIKeyScopeSearch}
+
+	revoked, err := apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Revoked", UserId: admin.Id, Scopes: scopes})
+	assert.NoError(t, err)
+	key, err := apiKeys.RevokeKey(admin, revoked.Id.String())
+	assert.NoError(t, err)
+	assert.NotNil(t, key.RevokedAt)
+	_, err = apiKeys.RevokeKey(admin, revoked.Id.String())
+	assert.ErrorIs(t, err, ErrAPIKeyAlreadyRevoked)
+	_, _, err = apiKeys.AuthenticateAPIKey(revoked.Key, domain.APIKeyScopeSearch)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	soon := time.Now().Add(time.Hour)
+	expiring, err := apiKeys.CreateKey(admin, &domain.APIKeyRequest{Name: "Expiring", UserId: admin.Id, Scopes: scopes, ExpiresAt: &soon})
+	assert.NoError(t, err)
+	_, _, err = apiKeys.AuthenticateAPIKey(expiring.Key, domain.APIKeyScopeSearch)
+	assert.NoError(t, err)
+	db.Model(&domain.APIKey{}).Where("id = ?", expiring.Id).Update("expires_at", time.Now().Add(-time.Second))
+	_, _, err = apiKeys.AuthenticateAPIKey(expiring.Key, domain.APIKeyScopeSearch)
+	assert.ErrorIs(t, err, auth.ErrInvalidAPIKey)
+
+	_, err = apiKeys.RevokeKey(admin, "missing")
+	assert.ErrorIs(t, err, ErrAPIKeyNotFound)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	AuditEntityBooking    = "booking"
+	AuditEntityReview     = "review"
+	AuditEntityUser       = "user"
+	AuditEntityInvitation = "invitation"
+	AuditEntityAPIKey     = "api_key"
+
+	AuditActionBookingNoShow      = "booking.no_show"
+	AuditActionReviewApproved     = "review.approved"
+	AuditActionReviewRejected     = "review.rejected"
+	AuditActionReviewHidden       = "review.hidden"
+	AuditActionRoleGranted        = "role.granted"
+	AuditActionRoleRevoked        = "role.revoked"
+	AuditActionInvitationSent     = "invitation.sent"
+	AuditActionInvitationRevoked  = "invitation.revoked"
+	AuditActionInvitationAccepted = "invitation.accepted"
+	AuditActionAPIKeyCreated      = "api_key.created"
+	AuditActionAPIKeyRevoked      = "api_key.revoked"
+)
+
+type AuditService interface {
+	Record(actorId *uuid.UUID, action string, entityType string, entityId uuid.UUID, details string) error
+	GetEntriesByEntity(entityType string, entityId string) ([]domain.AuditEntry, error)
+}
+
+type auditService struct {
+	auditRepository repository.AuditRepository
+}
+
+func NewAuditService(auditRepository repository.AuditRepository) AuditService {
+	return &auditService{auditRepository: auditRepository}
+}
+
+// Record appends an entry to the audit trail; pass a nil actor for actions taken by the system
+func (s *auditService) Record(actorId *uuid.UUID, action string, entityType string, entityId uuid.UUID, details string) error {
+	return s.auditRepository.CreateEntry(&domain.AuditEntry{
+		Id:         uuid.New(),
+		ActorId:    actorId,
+		Action:     action,
+		EntityType: entityType,
+		EntityId:   entityId,
+		Details:    details,
+	})
+}
+
+func (s *auditService) GetEntriesByEntity(entityType string, entityId string) ([]domain.AuditEntry, error) {
+	return s.auditRepository.GetEntriesByEntity(entityType, entityId)
+}
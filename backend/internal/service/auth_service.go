@@ -3,46 +3,353 @@ package service
 import (
 	"backend/internal/auth"
 	"backend/internal/domain"
+	"backend/internal/mail"
+	"backend/internal/oauth"
 	"backend/internal/repository"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	// externalLoginTTL is how long a user has to sign in at an identity provider and come back
+	externalLoginTTL = 10 * time.Minute
+	// mfaChallengeTTL is how long the second step of a two-factor login can take
+	mfaChallengeTTL = 5 * time.Minute
+	// maxMFAAttempts is how many wrong codes a login challenge takes before it stops accepting any
+	maxMFAAttempts = 5
+)
+
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token was already used, please log in again")
+	ErrInvalidResetToken   = errors.New("invalid or expired password reset token")
+
+	ErrInvalidVerificationToken = errors.New("invalid or expired email verification token")
+	ErrEmailAlreadyVerified     = errors.New("email address is already verified")
+	ErrVerificationThrottled    = errors.New("a verification email was sent recently")
+
+	ErrInvalidMFAChallenge = errors.New("invalid or expired two-factor login, please log in again")
+
+	// ErrInvalidCredentials is the one error for an unknown email and a wrong password, so logins
+	// do not reveal which addresses have accounts
+	ErrInvalidCredentials = errors.New("invalid email or password")
+	ErrLoginThrottled     = errors.New("too many failed login attempts")
+
+	ErrUnknownIdentityProvider    = errors.New("unknown identity provider")
+	ErrInvalidExternalLoginState  = errors.New("invalid or expired sign-in, please start again")
+	ErrExternalLoginFailed        = errors.New("sign-in with the identity provider failed")
+	ErrExternalEmailNotVerified   = errors.New("the identity provider has not verified your email address")
+	ErrExternalAccountNotVerified = errors.New("an account with this email address exists but has not verified it, log in with its password and verify the address first")
+)
+
+// usernameDisallowed matches what cannot be part of a username made from an external identity
+var usernameDisallowed = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// LoginThrottledError is returned by Login while an account or IP has to wait before trying again
+type LoginThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LoginThrottledError) Error() string {
+	return fmt.Sprintf("%s, try again in %s", ErrLoginThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginThrottledError) Is(target error) bool {
+	return target == ErrLoginThrottled
+}
+
+// LoginThrottlePolicy slows down and then locks out repeated failed logins. The zero policy never throttles.
+type LoginThrottlePolicy struct {
+	// FreeAttempts is how many failures are allowed before delays start
+	FreeAttempts int
+	// BaseDelay is the wait after the first delayed failure, doubling with each further one up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold is how many failures lock logins out for LockoutDuration. Failures are
+	// forgotten once LockoutDuration passes without another one.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+}
+
+// delay is how long to wait after the given number of failures before trying again
+func (p LoginThrottlePolicy) delay(failures int) time.Duration {
+	if p.BaseDelay <= 0 || failures <= p.FreeAttempts {
+		return 0
+	}
+	delay := p.BaseDelay
+	for i := p.FreeAttempts + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, p.MaxDelay)
+}
+
+// AuthSettings configures the account emails the auth service sends
+type AuthSettings struct {
+	// AppURL is the frontend address that links in emails point to
+	AppURL string
+	// APIURL is the address of this API, which email verification links point to
+	APIURL               string
+	PasswordResetTTL     time.Duration
+	EmailVerificationTTL time.Duration
+	// VerificationResendInterval is how long a user waits before another verification email is sent
+	VerificationResendInterval time.Duration
+	// PasswordResetResendInterval is how long before another password reset email is sent to the same account
+	PasswordResetResendInterval time.Duration
+	// RequireAdminMFA withholds admin rights from sessions that did not sign in with a second factor
+	RequireAdminMFA bool
+	// AccountLoginThrottle counts failed logins per email address, IPLoginThrottle per client IP
+	AccountLoginThrottle LoginThrottlePolicy
+	IPLoginThrottle      LoginThrottlePolicy
+}
+
 type AuthService struct {
-	userRepo repository.UserRepository
+	userRepo     repository.UserRepository
+	authRepo     repository.IAuthRepository
+	identityRepo repository.ExternalIdentityRepository
+	mfa          MFAService
+	providers    map[string]oauth.IdentityProvider
+	mailer       mail.Mailer
+	settings     AuthSettings
 }
 
 type IAuthService interface {
-	Login(email string, password string) (domain.LoginResponse, error)
+	Login(email string, password string, device string, ipAddress string) (domain.LoginResponse, error)
+	VerifyMFA(request *domain.MFAVerifyRequest, ipAddress string) (domain.LoginResponse, error)
+	GetIdentityProviders() []domain.IdentityProviderInfo
+	StartExternalLogin(provider string) (*domain.ExternalLoginStartResponse, error)
+	CompleteExternalLogin(provider string, request *domain.ExternalLoginCallbackRequest, device string) (domain.LoginResponse, error)
 	Register(registerRequest *domain.RegisterRequest) error
 	RefreshToken(refreshToken string) (domain.LoginResponse, error)
+	Logout(refreshToken string) error
+	LogoutAll(userId uuid.UUID) (int64, error)
+	GetJWKS() (domain.JSONWebKeySet, error)
+	ForgotPassword(email string) error
+	ResetPassword(request *domain.ResetPasswordRequest) error
+	VerifyEmail(token string) error
+	ResendVerification(userId uuid.UUID) error
+	IsEmailVerified(userId uuid.UUID) (bool, error)
+	UnlockAccount(userId uuid.UUID) error
 }
 
-func NewAuthService(userRepo repository.UserRepository) IAuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(userRepo repository.UserRepository, authRepo repository.IAuthRepository, identityRepo repository.ExternalIdentityRepository, mfa MFAService, providers map[string]oauth.IdentityProvider, mailer mail.Mailer, settings AuthSettings) IAuthService {
+	return &AuthService{
+		userRepo:     userRepo,
+		authRepo:     authRepo,
+		identityRepo: identityRepo,
+		mfa:          mfa,
+		providers:    providers,
+		mailer:       mailer,
+		settings:     settings,
+	}
 }
 
-func (s *AuthService) Login(email string, password string) (domain.LoginResponse, error) {
+/*
+Login
+Params: email, password, name of the device signing in, client IP address
+Returns: access and refresh tokens, or an MFA challenge token when the account uses two-factor login, error
+Description: Check the password. Accounts with two-factor login get a short-lived challenge token that
+VerifyMFA exchanges for tokens along with a code; other accounts get their tokens straight away.
+Failed logins are counted per email address and per IP: after a few, each attempt has to wait longer
+than the last, and after more the email or IP is locked out for a while. Unknown addresses are counted
+and answered like wrong passwords, so neither the errors nor the lockouts reveal which accounts exist.
+The account's failures are only forgotten once the login is complete, after the second factor if any.
+*/
+func (s *AuthService) Login(email string, password string, device string, ipAddress string) (domain.LoginResponse, error) {
+	throttles := s.loginThrottles(email, ipAddress)
+	if err := s.checkLoginThrottles(throttles, time.Now()); err != nil {
+		return domain.LoginResponse{}, err
+	}
+
 	user, err := s.userRepo.GetUserByEmail(email)
 	if err != nil {
+		// Take as long as checking a real password would
+		bcrypt.CompareHashAndPassword(dummyPasswordHash(), []byte(password))
+		if err := s.recordLoginFailure(throttles); err != nil {
+			return domain.LoginResponse{}, err
+		}
+		return domain.LoginResponse{}, ErrInvalidCredentials
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		if err := s.recordLoginFailure(throttles); err != nil {
+			return domain.LoginResponse{}, err
+		}
+		return domain.LoginResponse{}, ErrInvalidCredentials
+	}
+
+	response, err := s.signIn(user, device)
+	if err != nil || response.MFARequired {
+		return response, err
+	}
+	// The IP's failures stay counted, so one working account does not clear the way for guessing others
+	if err := s.authRepo.ClearLoginThrottle(throttles[0].id); err != nil {
 		return domain.LoginResponse{}, err
 	}
+	return response, nil
+}
+
+// GetIdentityProviders lists the identity providers users can sign in with
+func (s *AuthService) GetIdentityProviders() []domain.IdentityProviderInfo {
+	providers := make([]domain.IdentityProviderInfo, 0, len(s.providers))
+	for name := range s.providers {
+		providers = append(providers, domain.IdentityProviderInfo{Name: name})
+	}
+	sort.Slice(providers, func(i, j int) bool { return providers[i].Name < providers[j].Name })
+	return providers
+}
 
-	err = bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password))
+/*
+StartExternalLogin
+Params: name of the identity provider
+Returns: the provider's sign-in URL and the state it will send back, error
+Description: Start an authorization-code login with PKCE. The state, nonce and code verifier are kept on
+the server; the user is sent to the provider with the state, the nonce and the verifier's challenge.
+*/
+func (s *AuthService) StartExternalLogin(providerName string) (*domain.ExternalLoginStartResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return nil, ErrUnknownIdentityProvider
+	}
+
+	state, err := generateAccountToken()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := generateAccountToken()
 	if err != nil {
-		return domain.LoginResponse{}, errors.New("invalid password")
+		return nil, err
+	}
+	verifier, err := oauth.NewCodeVerifier()
+	if err != nil {
+		return nil, err
+	}
+	authorizationURL, err := provider.AuthorizationURL(oauth.AuthorizationRequest{State: state, Nonce: nonce, CodeChallenge: oauth.CodeChallenge(verifier)})
+	if err != nil {
+		return nil, err
 	}
 
-	token, err := auth.GenerateToken(user)
+	err = s.identityRepo.CreateLoginState(&domain.ExternalLoginState{
+		Id:           uuid.New(),
+		Provider:     providerName,
+		StateHash:    hashToken(state),
+		CodeVerifier: verifier,
+		Nonce:        nonce,
+		ExpiresAt:    time.Now().Add(externalLoginTTL),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &domain.ExternalLoginStartResponse{AuthorizationURL: authorizationURL, State: state}, nil
+}
+
+/*
+CompleteExternalLogin
+Params: name of the identity provider, code and state the provider redirected back with, name of the device signing in
+Returns: access and refresh tokens, or an MFA challenge token when the account uses two-factor login, error
+Description: Redeem the code for the user's identity at the provider and sign in the user it is linked to.
+An identity that is not linked yet is linked to the user with the same email address, or to a new
+user, but only when the provider has verified the address. An existing user must have verified it too,
+or whoever registered the address could take over the provider's user.
+*/
+func (s *AuthService) CompleteExternalLogin(providerName string, request *domain.ExternalLoginCallbackRequest, device string) (domain.LoginResponse, error) {
+	provider, ok := s.providers[providerName]
+	if !ok {
+		return domain.LoginResponse{}, ErrUnknownIdentityProvider
+	}
+
+	state, err := s.identityRepo.GetLoginStateByHash(hashToken(request.State))
+	if err != nil || state.Provider != providerName || state.UsedAt != nil || !time.Now().Before(state.ExpiresAt) {
+		return domain.LoginResponse{}, ErrInvalidExternalLoginState
+	}
+	used, err := s.identityRepo.UseLoginState(state.Id.String(), time.Now())
 	if err != nil {
 		return domain.LoginResponse{}, err
 	}
-	return domain.LoginResponse{
-		AccessToken:  token.AccessToken,
-		RefreshToken: token.RefreshToken,
-	}, nil
+	if !used {
+		return domain.LoginResponse{}, ErrInvalidExternalLoginState
+	}
+
+	identity, err := provider.Exchange(request.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		log.Printf("sign-in with %s failed: %v", providerName, err)
+		return domain.LoginResponse{}, ErrExternalLoginFailed
+	}
+	user, err := s.userForIdentity(providerName, identity)
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	return s.signIn(user, device)
+}
+
+/*
+VerifyMFA
+Params: challenge token from Login, code from the authenticator app or a recovery code, client IP address
+Returns: access and refresh tokens, error
+Description: Complete a two-factor login. Each challenge can be completed once and stops accepting codes
+after a few wrong ones, so the password step has to be repeated rather than the code guessed. Wrong codes
+also count against the account's and IP's login throttles, so repeating the password step does not give
+an unlimited number of guesses either.
+*/
+func (s *AuthService) VerifyMFA(request *domain.MFAVerifyRequest, ipAddress string) (domain.LoginResponse, error) {
+	challenge, err := s.authRepo.GetMFAChallengeByHash(hashToken(request.MFAToken))
+	if err != nil || challenge.UsedAt != nil || challenge.Attempts >= maxMFAAttempts || !time.Now().Before(challenge.ExpiresAt) {
+		return domain.LoginResponse{}, ErrInvalidMFAChallenge
+	}
+	user, err := s.userRepo.GetUserById(challenge.UserId.String())
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	throttles := s.loginThrottles(user.Email, ipAddress)
+	if err := s.checkLoginThrottles(throttles, time.Now()); err != nil {
+		return domain.LoginResponse{}, err
+	}
+
+	if err := s.mfa.VerifyCode(challenge.UserId, request.Code); err != nil {
+		if errors.Is(err, ErrMFANotEnabled) {
+			// Two-factor login was turned off since the challenge was made
+			return domain.LoginResponse{}, ErrInvalidMFAChallenge
+		}
+		if errors.Is(err, ErrInvalidMFACode) {
+			if err := s.authRepo.RecordMFAChallengeFailure(challenge.Id.String()); err != nil {
+				return domain.LoginResponse{}, err
+			}
+			if err := s.recordLoginFailure(throttles); err != nil {
+				return domain.LoginResponse{}, err
+			}
+		}
+		return domain.LoginResponse{}, err
+	}
+	used, err := s.authRepo.UseMFAChallenge(challenge.Id.String(), time.Now())
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	if !used {
+		return domain.LoginResponse{}, ErrInvalidMFAChallenge
+	}
+
+	tokens, record, err := s.newTokens(user, challenge.Device, uuid.New(), true)
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	if err := s.authRepo.CreateRefreshToken(record); err != nil {
+		return domain.LoginResponse{}, err
+	}
+	if err := s.authRepo.ClearLoginThrottle(throttles[0].id); err != nil {
+		return domain.LoginResponse{}, err
+	}
+	return tokens, nil
 }
 
 func (s *AuthService) Register(registerRequest *domain.RegisterRequest) error {
@@ -58,30 +365,498 @@ func (s *AuthService) Register(registerRequest *domain.RegisterRequest) error {
 	user.Password = string(hashedPassword)
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
-	return s.userRepo.CreateUser(user)
+	if err := s.userRepo.CreateUser(user); err != nil {
+		return err
+	}
+
+	// The account exists now; if the email does not go out the user can ask for it again
+	if err := s.sendVerificationEmail(user); err != nil {
+		log.Printf("failed to send verification email to user %s: %v", user.Id, err)
+	}
+	return nil
 }
 
+/*
+RefreshToken
+Params: refresh token
+Returns: new access and refresh tokens, error
+Description: Exchange the refresh token for a new pair, rotating it so it cannot be used again. A token
+that was already exchanged is being replayed, by a thief or by the client it was stolen from, so the
+whole session is revoked and the user has to log in again.
+*/
 func (s *AuthService) RefreshToken(refreshToken string) (domain.LoginResponse, error) {
-	// Validate the refresh token and extract user information
-	user, err := auth.ValidateRefreshToken(refreshToken)
+	// Validate the refresh token and check it is still live in the token store
+	stored, err := s.storedRefreshToken(refreshToken)
 	if err != nil {
 		return domain.LoginResponse{}, err
 	}
+	if stored.RotatedAt != nil {
+		return domain.LoginResponse{}, s.revokeReusedFamily(stored)
+	}
+	if stored.RevokedAt != nil || !time.Now().Before(stored.ExpiresAt) {
+		return domain.LoginResponse{}, ErrInvalidRefreshToken
+	}
 
 	// Get full user details from database to ensure user still exists
-	fullUser, err := s.userRepo.GetUserById(user.Id.String())
+	fullUser, err := s.userRepo.GetUserById(stored.UserId.String())
 	if err != nil {
 		return domain.LoginResponse{}, errors.New("user not found")
 	}
 
-	// Generate new access and refresh tokens
-	token, err := auth.GenerateToken(fullUser)
+	// Generate new access and refresh tokens in the same family
+	tokens, replacement, err := s.newTokens(fullUser, stored.Device, stored.FamilyId, stored.MFAVerified)
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	rotated, err := s.authRepo.RotateRefreshToken(stored.Id.String(), replacement, time.Now())
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	if !rotated {
+		// Another request exchanged the same token first
+		return domain.LoginResponse{}, s.revokeReusedFamily(stored)
+	}
+	return tokens, nil
+}
+
+// Logout revokes the session the refresh token belongs to. Logging out of a revoked session succeeds.
+func (s *AuthService) Logout(refreshToken string) error {
+	stored, err := s.storedRefreshToken(refreshToken)
+	if err != nil {
+		return err
+	}
+	return s.authRepo.RevokeRefreshTokenFamily(stored.FamilyId.String(), time.Now())
+}
+
+// LogoutAll revokes every refresh token of the user, signing them out on all devices, and returns how many sessions were ended
+func (s *AuthService) LogoutAll(userId uuid.UUID) (int64, error) {
+	return s.authRepo.RevokeUserRefreshTokens(userId.String(), time.Now())
+}
+
+// GetJWKS returns the public keys our tokens can be verified with
+func (s *AuthService) GetJWKS() (domain.JSONWebKeySet, error) {
+	keys, err := auth.Keys()
+	if err != nil {
+		return domain.JSONWebKeySet{}, err
+	}
+	return keys.JWKS(), nil
+}
+
+/*
+ForgotPassword
+Params: email address
+Returns: error
+Description: Email a single-use password reset link to the account with this address. Nothing is sent when
+there is no such account, and the caller is told the same either way, so the endpoint cannot be used to
+find out which addresses have accounts. Only one email is sent per resend interval so the endpoint cannot
+be used to flood an inbox; requests in between are quietly dropped for the same reason.
+*/
+func (s *AuthService) ForgotPassword(email string) error {
+	user, err := s.userRepo.GetUserByEmail(strings.TrimSpace(email))
+	if err != nil {
+		return nil
+	}
+	if latest, err := s.authRepo.GetLatestPasswordResetToken(user.Id.String()); err == nil {
+		if time.Now().Before(latest.CreatedAt.Add(s.settings.PasswordResetResendInterval)) {
+			return nil
+		}
+	}
+
+	token, err := generateAccountToken()
+	if err != nil {
+		return err
+	}
+	err = s.authRepo.CreatePasswordResetToken(&domain.PasswordResetToken{
+		Id:        uuid.New(),
+		UserId:    user.Id,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.settings.PasswordResetTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimSuffix(s.settings.AppURL, "/") + "/reset-password?token=" + url.QueryEscape(token)
+	err = s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to choose a new password. It works once and expires in %s.\n\n%s\n\n"+
+			"If you did not ask to reset your password you can ignore this email.", user.Username, s.settings.PasswordResetTTL, link),
+	})
+	if err != nil {
+		// Failing here would tell the caller the address has an account
+		log.Printf("failed to send password reset email to user %s: %v", user.Id, err)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password with a reset token and signs the user out of every session
+func (s *AuthService) ResetPassword(request *domain.ResetPasswordRequest) error {
+	token, err := s.authRepo.GetPasswordResetTokenByHash(hashToken(request.Token))
+	if err != nil || token.UsedAt != nil || !time.Now().Before(token.ExpiresAt) {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	reset, err := s.authRepo.ResetPassword(token, string(hashedPassword), time.Now())
+	if err != nil {
+		return err
+	}
+	if !reset {
+		return ErrInvalidResetToken
+	}
+	return nil
+}
+
+// VerifyEmail marks the email address of the token's user verified
+func (s *AuthService) VerifyEmail(token string) error {
+	stored, err := s.authRepo.GetEmailVerificationTokenByHash(hashToken(token))
+	if err != nil || stored.UsedAt != nil || !time.Now().Before(stored.ExpiresAt) {
+		return ErrInvalidVerificationToken
+	}
+
+	verified, err := s.authRepo.VerifyEmail(stored, time.Now())
+	if err != nil {
+		return err
+	}
+	if !verified {
+		return ErrInvalidVerificationToken
+	}
+	return nil
+}
+
+/*
+ResendVerification
+Params: id of the signed-in user
+Returns: error
+Description: Send the user a new verification link. Links sent earlier keep working until they expire.
+Only one email is sent per resend interval so the endpoint cannot be used to flood an inbox.
+*/
+func (s *AuthService) ResendVerification(userId uuid.UUID) error {
+	user, err := s.userRepo.GetUserById(userId.String())
+	if err != nil {
+		return err
+	}
+	if user.IsEmailVerified() {
+		return ErrEmailAlreadyVerified
+	}
+
+	latest, err := s.authRepo.GetLatestEmailVerificationToken(userId.String())
+	if err == nil {
+		if wait := time.Until(latest.CreatedAt.Add(s.settings.VerificationResendInterval)); wait > 0 {
+			return fmt.Errorf("%w, try again in %s", ErrVerificationThrottled, wait.Round(time.Second))
+		}
+	}
+	return s.sendVerificationEmail(user)
+}
+
+// IsEmailVerified reports whether the user has confirmed their email address
+func (s *AuthService) IsEmailVerified(userId uuid.UUID) (bool, error) {
+	user, err := s.userRepo.GetUserById(userId.String())
+	if err != nil {
+		return false, err
+	}
+	return user.IsEmailVerified(), nil
+}
+
+// sendVerificationEmail stores a new verification token for the user and emails them the link that uses it
+func (s *AuthService) sendVerificationEmail(user *domain.User) error {
+	token, err := generateAccountToken()
+	if err != nil {
+		return err
+	}
+	err = s.authRepo.CreateEmailVerificationToken(&domain.EmailVerificationToken{
+		Id:        uuid.New(),
+		UserId:    user.Id,
+		TokenHash: hashToken(token),
+		ExpiresAt: time.Now().Add(s.settings.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimSuffix(s.settings.APIURL, "/") + "/auth/verify?token=" + url.QueryEscape(token)
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nUse this link to confirm your email address. It expires in %s.\n\n%s\n\n"+
+			"If you did not create an account you can ignore this email.", user.Username, s.settings.EmailVerificationTTL, link),
+	})
+}
+
+// signIn finishes a login whose first factor has been checked, starting an MFA challenge when the user has two-factor login
+func (s *AuthService) signIn(user *domain.User, device string) (domain.LoginResponse, error) {
+	mfaEnabled, err := s.mfa.IsEnabled(user.Id)
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	if mfaEnabled {
+		return s.newMFAChallenge(user, device)
+	}
+
+	tokens, record, err := s.newTokens(user, device, uuid.New(), false)
 	if err != nil {
 		return domain.LoginResponse{}, err
 	}
+	if err := s.authRepo.CreateRefreshToken(record); err != nil {
+		return domain.LoginResponse{}, err
+	}
+	tokens.MFAEnrollmentRequired = user.IsAdmin && s.settings.RequireAdminMFA
+	return tokens, nil
+}
+
+// userForIdentity finds the user an external identity is linked to, linking it by verified email address when it is new
+func (s *AuthService) userForIdentity(providerName string, identity *oauth.Identity) (*domain.User, error) {
+	linked, err := s.identityRepo.GetIdentity(providerName, identity.Subject)
+	if err == nil {
+		return s.userRepo.GetUserById(linked.UserId.String())
+	}
+	if !errors.Is(err, repository.ErrIdentityNotLinked) {
+		return nil, err
+	}
+	email := strings.TrimSpace(identity.Email)
+	if !identity.EmailVerified || email == "" {
+		return nil, ErrExternalEmailNotVerified
+	}
+
+	now := time.Now()
+	var newUser *domain.User
+	user, err := s.userRepo.GetUserByEmail(email)
+	if err == nil && !user.IsEmailVerified() {
+		// Whoever registered the address never proved they own it, so it may not be the provider's user
+		return nil, ErrExternalAccountNotVerified
+	}
+	if err != nil {
+		// Nobody has a password for the new account; they can set one with a password reset
+		password, err := generateAccountToken()
+		if err != nil {
+			return nil, err
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		username, err := s.availableUsername(identity)
+		if err != nil {
+			return nil, err
+		}
+		newUser = &domain.User{
+			Id:              uuid.New(),
+			Username:        username,
+			Email:           email,
+			Password:        string(hashedPassword),
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		user = newUser
+	}
+
+	err = s.identityRepo.LinkIdentity(&domain.ExternalIdentity{
+		Id:       uuid.New(),
+		UserId:   user.Id,
+		Provider: providerName,
+		Subject:  identity.Subject,
+		Email:    email,
+	}, newUser)
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
 
+// availableUsername makes a username for a new user from their name at the provider, or their email address
+func (s *AuthService) availableUsername(identity *oauth.Identity) (string, error) {
+	source := identity.Name
+	if strings.TrimSpace(source) == "" {
+		source, _, _ = strings.Cut(identity.Email, "@")
+	}
+	base := strings.Trim(usernameDisallowed.ReplaceAllString(strings.ToLower(source), "-"), "-")
+	if base == "" {
+		base = "guest"
+	}
+	if _, err := s.userRepo.GetUserByUsername(base); err != nil {
+		return base, nil
+	}
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return base + "-" + hex.EncodeToString(suffix), nil
+}
+
+// UnlockAccount lifts a login lockout of the user's email address and forgets its failed logins
+func (s *AuthService) UnlockAccount(userId uuid.UUID) error {
+	user, err := s.userRepo.GetUserById(userId.String())
+	if err != nil {
+		return ErrUserNotFound
+	}
+	return s.authRepo.ClearLoginThrottle(accountThrottleId(user.Email))
+}
+
+// loginThrottle is a login throttle id with the policy that applies to it
+type loginThrottle struct {
+	id     string
+	policy LoginThrottlePolicy
+}
+
+// loginThrottles are the throttles a login counts against, the account's first
+func (s *AuthService) loginThrottles(email string, ipAddress string) []loginThrottle {
+	throttles := []loginThrottle{{id: accountThrottleId(email), policy: s.settings.AccountLoginThrottle}}
+	if ipAddress != "" {
+		throttles = append(throttles, loginThrottle{id: "ip:" + ipAddress, policy: s.settings.IPLoginThrottle})
+	}
+	return throttles
+}
+
+// checkLoginThrottles returns a LoginThrottledError when any of the throttles is locked out or delaying attempts
+func (s *AuthService) checkLoginThrottles(throttles []loginThrottle, now time.Time) error {
+	ids := make([]string, len(throttles))
+	policies := make(map[string]LoginThrottlePolicy, len(throttles))
+	for i, throttle := range throttles {
+		ids[i] = throttle.id
+		policies[throttle.id] = throttle.policy
+	}
+	stored, err := s.authRepo.GetLoginThrottles(ids)
+	if err != nil {
+		return err
+	}
+
+	var wait time.Duration
+	for _, throttle := range stored {
+		policy := policies[throttle.Id]
+		if throttle.LockedUntil != nil {
+			wait = max(wait, throttle.LockedUntil.Sub(now))
+		} else if now.Sub(throttle.LastFailureAt) < policy.LockoutDuration {
+			wait = max(wait, throttle.LastFailureAt.Add(policy.delay(throttle.Failures)).Sub(now))
+		}
+	}
+	if wait > 0 {
+		return &LoginThrottledError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// recordLoginFailure counts a failed login or second factor against the throttles, locking out those that reach their threshold
+func (s *AuthService) recordLoginFailure(throttles []loginThrottle) error {
+	now := time.Now()
+	for _, throttle := range throttles {
+		stored, err := s.authRepo.RecordLoginFailure(throttle.id, now, now.Add(-throttle.policy.LockoutDuration))
+		if err != nil {
+			return err
+		}
+		if throttle.policy.LockoutThreshold > 0 && stored.Failures >= throttle.policy.LockoutThreshold && stored.LockedUntil == nil {
+			log.Printf("locking out logins for %s after %d failed attempts", throttle.id, stored.Failures)
+			if err := s.authRepo.LockLogin(throttle.id, now.Add(throttle.policy.LockoutDuration)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func accountThrottleId(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+var (
+	dummyHashOnce sync.Once
+	dummyHash     []byte
+)
+
+// dummyPasswordHash is compared against when there is no account, so the answer takes as long as a wrong password
+func dummyPasswordHash() []byte {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = bcrypt.GenerateFromPassword([]byte("not a password"), bcrypt.DefaultCost)
+	})
+	return dummyHash
+}
+
+// newMFAChallenge stores a challenge for the second step of a two-factor login and returns its token
+func (s *AuthService) newMFAChallenge(user *domain.User, device string) (domain.LoginResponse, error) {
+	token, err := generateAccountToken()
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	err = s.authRepo.CreateMFAChallenge(&domain.MFAChallenge{
+		Id:        uuid.New(),
+		UserId:    user.Id,
+		TokenHash: hashToken(token),
+		Device:    device,
+		ExpiresAt: time.Now().Add(mfaChallengeTTL),
+	})
+	if err != nil {
+		return domain.LoginResponse{}, err
+	}
+	return domain.LoginResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// newTokens signs a new token pair and builds the store record of its refresh token.
+// mfaVerified says whether the session was started with a second factor.
+func (s *AuthService) newTokens(user *domain.User, device string, familyId uuid.UUID, mfaVerified bool) (domain.LoginResponse, *domain.RefreshToken, error) {
+	if user.IsAdmin && s.settings.RequireAdminMFA && !mfaVerified {
+		// The tokens carry no admin rights until the admin signs in with a second factor
+		withoutAdmin := *user
+		withoutAdmin.IsAdmin = false
+		user = &withoutAdmin
+	}
+
+	refreshTokenId := uuid.New()
+	token, err := auth.GenerateToken(user, refreshTokenId)
+	if err != nil {
+		return domain.LoginResponse{}, nil, err
+	}
+
+	record := &domain.RefreshToken{
+		Id:          refreshTokenId,
+		FamilyId:    familyId,
+		UserId:      user.Id,
+		TokenHash:   hashToken(token.RefreshToken),
+		Device:      device,
+		ExpiresAt:   time.Now().Add(auth.RefreshTokenTTL),
+		MFAVerified: mfaVerified,
+	}
 	return domain.LoginResponse{
 		AccessToken:  token.AccessToken,
 		RefreshToken: token.RefreshToken,
-	}, nil
+	}, record, nil
+}
+
+func (s *AuthService) revokeReusedFamily(stored *domain.RefreshToken) error {
+	log.Printf("refresh token %s of user %s was reused, revoking its session", stored.Id, stored.UserId)
+	if err := s.authRepo.RevokeRefreshTokenFamily(stored.FamilyId.String(), time.Now()); err != nil {
+		return err
+	}
+	return ErrRefreshTokenReused
+}
+
+// storedRefreshToken checks the token's signature and finds its record, which must hold the same token
+func (s *AuthService) storedRefreshToken(refreshToken string) (*domain.RefreshToken, error) {
+	user, tokenId, err := auth.ValidateRefreshToken(refreshToken)
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+	stored, err := s.authRepo.GetRefreshTokenById(tokenId.String())
+	if err != nil || stored.UserId != user.Id || stored.TokenHash != hashToken(refreshToken) {
+		return nil, ErrInvalidRefreshToken
+	}
+	return stored, nil
+}
+
+// generateAccountToken makes the random token sent in account emails
+func generateAccountToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// hashToken is what the database keeps of a refresh token or an emailed token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
 }
@@ -0,0 +1,823 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/mail"
+	"backend/internal/oauth"
+	"backend/internal/repository"
+	"errors"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAuthServiceTestDB(t *testing.T) *gorm.DB {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.Exec(`
+		CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			username TEXT UNIQUE,
+			email TEXT UNIQUE,
+			password TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
+		);
+		CREATE TABLE refresh_tokens (
+			id TEXT PRIMARY KEY,
+			family_id TEXT,
+			user_id TEXT,
+			token_hash TEXT UNIQUE,
+			device TEXT,
+			expires_at DATETIME,
+			rotated_at DATETIME,
+			replaced_by_id TEXT,
+			revoked_at DATETIME,
+			mfa_verified INTEGER DEFAULT 0,
+			created_at DATETIME
+		);
+		CREATE TABLE password_reset_tokens (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			token_hash TEXT UNIQUE,
+			expires_at DATETIME,
+			used_at DATETIME,
+			created_at DATETIME
+		);
+		CREATE TABLE email_verification_tokens (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			token_hash TEXT UNIQUE,
+			expires_at DATETIME,
+			used_at DATETIME,
+			created_at DATETIME
+		);
+		CREATE TABLE mfa_enrollments (
+			user_id TEXT PRIMARY KEY,
+			secret TEXT,
+			confirmed_at DATETIME,
+			last_used_step INTEGER DEFAULT 0,
+			created_at DATETIME,
+			updated_at DATETIME
+		);
+		CREATE TABLE mfa_recovery_codes (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			code_hash TEXT,
+			used_at DATETIME,
+			created_at DATETIME
+		);
+		CREATE TABLE mfa_challenges (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			token_hash TEXT UNIQUE,
+			device TEXT,
+			attempts INTEGER DEFAULT 0,
+			expires_at DATETIME,
+			used_at DATETIME,
+			created_at DATETIME
+		);
+		CREATE TABLE login_throttles (
+			id TEXT PRIMARY KEY,
+			failures INTEGER DEFAULT 0,
+			last_failure_at DATETIME,
+			locked_until DATETIME
+		);
+		CREATE TABLE external_identities (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			provider TEXT,
+			subject TEXT,
+			email TEXT,
+			created_at DATETIME,
+			UNIQUE (provider, subject)
+		);
+		CREATE TABLE external_login_states (
+			id TEXT PRIMARY KEY,
+			provider TEXT,
+			state_hash TEXT UNIQUE,
+			code_verifier TEXT,
+			nonce TEXT,
+			expires_at DATETIME,
+			used_at DATETIME,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+// recordingMailer keeps sent messages for tests to inspect, or fails with err when it is set
+type recordingMailer struct {
+	messages []*mail.Message
+	err      error
+}
+
+func (m *recordingMailer) Send(message *mail.Message) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.messages = append(m.messages, message)
+	return nil
+}
+
+var testAuthSettings = AuthSettings{
+	AppURL:                      "http://app.test",
+	APIURL:                      "http://api.test",
+	PasswordResetTTL:            time.Hour,
+	EmailVerificationTTL:        time.Hour,
+	VerificationResendInterval:  time.Minute,
+	PasswordResetResendInterval: time.Minute,
+}
+
+func newAuthTestService(t *testing.T, db *gorm.DB) IAuthService {
+	return newAuthTestServiceWithMailer(t, db, &recordingMailer{}, testAuthSettings)
+}
+
+func newAuthServiceWithoutUsers(db *gorm.DB, mailer *recordingMailer, settings AuthSettings) IAuthService {
+	return newAuthServiceWithProviders(db, nil, mailer, settings)
+}
+
+func newAuthServiceWithProviders(db *gorm.DB, providers map[string]oauth.IdentityProvider, mailer *recordingMailer, settings AuthSettings) IAuthService {
+	userRepository := repository.NewUserRepository(db)
+	mfa := NewMFAService(repository.NewMFARepository(db), userRepository, "Hotel Booking")
+	return NewAuthService(userRepository, repository.NewAuthRepository(db), repository.NewExternalIdentityRepository(db), mfa, providers, mailer, settings)
+}
+
+// newAuthTestServiceWithMailer registers the guest user and then empties the mailer's outbox
+func newAuthTestServiceWithMailer(t *testing.T, db *gorm.DB, mailer *recordingMailer, settings AuthSettings) IAuthService {
+	authService := newAuthServiceWithoutUsers(db, mailer, settings)
+	err := authService.Register(&domain.RegisterRequest{Username: "guest", Email: "guest@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	mailer.messages = nil
+	return authService
+}
+
+func TestAuthService_LoginStoresRefreshToken(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	tokens, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+
+	var stored []domain.RefreshToken
+	assert.NoError(t, db.Find(&stored).Error)
+	if assert.Len(t, stored, 1) {
+		assert.Equal(t, "Laptop", stored[0].Device)
+		assert.NotEqual(t, tokens.RefreshToken, stored[0].TokenHash)
+		assert.Nil(t, stored[0].RevokedAt)
+	}
+
+	refreshed, err := authService.RefreshToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+	assert.NotEqual(t, tokens.RefreshToken, refreshed.RefreshToken)
+
+	_, err = authService.RefreshToken("not-a-token")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthService_LogoutRevokesRefreshToken(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	laptop, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	phone, err := authService.Login("guest@example.com", "password123", "Phone", "")
+	assert.NoError(t, err)
+
+	assert.NoError(t, authService.Logout(laptop.RefreshToken))
+	assert.NoError(t, authService.Logout(laptop.RefreshToken))
+	_, err = authService.RefreshToken(laptop.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	_, err = authService.RefreshToken(phone.RefreshToken)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, authService.Logout("not-a-token"), ErrInvalidRefreshToken)
+}
+
+func TestAuthService_LogoutAllRevokesEverySession(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	laptop, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	phone, err := authService.Login("guest@example.com", "password123", "Phone", "")
+	assert.NoError(t, err)
+
+	user, err := repository.NewUserRepository(db).GetUserByEmail("guest@example.com")
+	assert.NoError(t, err)
+	revoked, err := authService.LogoutAll(user.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), revoked)
+
+	for _, tokens := range []domain.LoginResponse{laptop, phone} {
+		_, err = authService.RefreshToken(tokens.RefreshToken)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	}
+}
+
+func TestAuthService_RefreshRotatesToken(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	login, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	first, err := authService.RefreshToken(login.RefreshToken)
+	assert.NoError(t, err)
+	second, err := authService.RefreshToken(first.RefreshToken)
+	assert.NoError(t, err)
+
+	var tokens []domain.RefreshToken
+	assert.NoError(t, db.Order("created_at").Find(&tokens).Error)
+	if assert.Len(t, tokens, 3) {
+		for _, token := range tokens {
+			assert.Equal(t, tokens[0].FamilyId, token.FamilyId)
+			assert.Equal(t, "Laptop", token.Device)
+		}
+	}
+
+	// Logging out ends the session whichever of its tokens is presented
+	assert.NoError(t, authService.Logout(login.RefreshToken))
+	_, err = authService.RefreshToken(second.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestAuthService_RefreshTokenReuseRevokesFamily(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	stolen, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	other, err := authService.Login("guest@example.com", "password123", "Phone", "")
+	assert.NoError(t, err)
+
+	// The legitimate client rotates, then the thief replays the stolen token
+	current, err := authService.RefreshToken(stolen.RefreshToken)
+	assert.NoError(t, err)
+	_, err = authService.RefreshToken(stolen.RefreshToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	// Both holders are signed out of that session, but other sessions are untouched
+	_, err = authService.RefreshToken(current.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = authService.RefreshToken(other.RefreshToken)
+	assert.NoError(t, err)
+}
+
+// tokenFromLink pulls the token out of the link to the page in an email
+func tokenFromLink(t *testing.T, message *mail.Message, page string) string {
+	for _, line := range strings.Split(message.Body, "\n") {
+		if link, err := url.Parse(line); err == nil && link.Host != "" && link.Path == page {
+			return link.Query().Get("token")
+		}
+	}
+	t.Fatalf("No link to %s in %q", page, message.Body)
+	return ""
+}
+
+func TestAuthService_PasswordResetSignsOutEverywhere(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	authService := newAuthTestServiceWithMailer(t, db, mailer, testAuthSettings)
+
+	session, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+
+	// A second request within the resend interval sends nothing, and looks the same to the caller
+	assert.NoError(t, authService.ForgotPassword("guest@example.com"))
+	assert.NoError(t, authService.ForgotPassword("guest@example.com"))
+	assert.Len(t, mailer.messages, 1)
+	assert.NoError(t, db.Model(&domain.PasswordResetToken{}).Where("1 = 1").Update("created_at", time.Now().Add(-2*time.Minute)).Error)
+	assert.NoError(t, authService.ForgotPassword("guest@example.com"))
+	if !assert.Len(t, mailer.messages, 2) {
+		return
+	}
+	assert.Equal(t, "guest@example.com", mailer.messages[0].To)
+	first, second := tokenFromLink(t, mailer.messages[0], "/reset-password"), tokenFromLink(t, mailer.messages[1], "/reset-password")
+
+	var stored domain.PasswordResetToken
+	assert.NoError(t, db.First(&stored).Error)
+	assert.NotEqual(t, first, stored.TokenHash)
+
+	assert.NoError(t, authService.ResetPassword(&domain.ResetPasswordRequest{Token: second, Password: "newpassword123"}))
+
+	// Every reset token of the user is used up, and the old password and sessions stop working
+	assert.ErrorIs(t, authService.ResetPassword(&domain.ResetPasswordRequest{Token: second, Password: "again"}), ErrInvalidResetToken)
+	assert.ErrorIs(t, authService.ResetPassword(&domain.ResetPasswordRequest{Token: first, Password: "again"}), ErrInvalidResetToken)
+	_, err = authService.RefreshToken(session.RefreshToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	_, err = authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.Error(t, err)
+	_, err = authService.Login("guest@example.com", "newpassword123", "Laptop", "")
+	assert.NoError(t, err)
+}
+
+func TestAuthService_PasswordResetDoesNotRevealAccounts(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	authService := newAuthTestServiceWithMailer(t, db, mailer, testAuthSettings)
+
+	assert.NoError(t, authService.ForgotPassword("nobody@example.com"))
+	assert.Empty(t, mailer.messages)
+
+	assert.ErrorIs(t, authService.ResetPassword(&domain.ResetPasswordRequest{Token: "guessed", Password: "newpassword123"}), ErrInvalidResetToken)
+}
+
+func TestAuthService_PasswordResetTokenExpires(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	authService := newAuthTestServiceWithMailer(t, db, mailer, AuthSettings{AppURL: "http://app.test", PasswordResetTTL: -time.Minute})
+
+	assert.NoError(t, authService.ForgotPassword("guest@example.com"))
+	if assert.Len(t, mailer.messages, 1) {
+		err := authService.ResetPassword(&domain.ResetPasswordRequest{Token: tokenFromLink(t, mailer.messages[0], "/reset-password"), Password: "newpassword123"})
+		assert.ErrorIs(t, err, ErrInvalidResetToken)
+	}
+}
+
+func TestAuthService_RegisterSendsVerificationEmail(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	authService := newAuthTestServiceWithMailer(t, db, mailer, testAuthSettings)
+
+	err := authService.Register(&domain.RegisterRequest{Username: "traveller", Email: "traveller@example.com", Password: "password123"})
+	assert.NoError(t, err)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	assert.Equal(t, "traveller@example.com", mailer.messages[0].To)
+	assert.Contains(t, mailer.messages[0].Body, "http://api.test/auth/verify?token=")
+
+	var user domain.User
+	assert.NoError(t, db.First(&user, "email = ?", "traveller@example.com").Error)
+	verified, err := authService.IsEmailVerified(user.Id)
+	assert.NoError(t, err)
+	assert.False(t, verified)
+
+	token := tokenFromLink(t, mailer.messages[0], "/auth/verify")
+	assert.NoError(t, authService.VerifyEmail(token))
+	verified, err = authService.IsEmailVerified(user.Id)
+	assert.NoError(t, err)
+	assert.True(t, verified)
+
+	// The link works once, and a verified user cannot ask for another
+	assert.ErrorIs(t, authService.VerifyEmail(token), ErrInvalidVerificationToken)
+	assert.ErrorIs(t, authService.VerifyEmail("guessed"), ErrInvalidVerificationToken)
+	assert.ErrorIs(t, authService.ResendVerification(user.Id), ErrEmailAlreadyVerified)
+}
+
+func TestAuthService_ResendVerificationIsThrottled(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	authService := newAuthTestServiceWithMailer(t, db, mailer, testAuthSettings)
+
+	var user domain.User
+	assert.NoError(t, db.First(&user, "email = ?", "guest@example.com").Error)
+
+	// Registration just sent an email
+	assert.ErrorIs(t, authService.ResendVerification(user.Id), ErrVerificationThrottled)
+	assert.Empty(t, mailer.messages)
+
+	assert.NoError(t, db.Model(&domain.EmailVerificationToken{}).Where("user_id = ?", user.Id).
+		Update("created_at", time.Now().Add(-2*time.Minute)).Error)
+	assert.NoError(t, authService.ResendVerification(user.Id))
+	assert.ErrorIs(t, authService.ResendVerification(user.Id), ErrVerificationThrottled)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+
+	// Verifying with the new link also uses up the one sent at registration
+	assert.NoError(t, authService.VerifyEmail(tokenFromLink(t, mailer.messages[0], "/auth/verify")))
+	var open int64
+	assert.NoError(t, db.Model(&domain.EmailVerificationToken{}).Where("used_at IS NULL").Count(&open).Error)
+	assert.Zero(t, open)
+}
+
+func TestAuthService_VerificationTokenExpires(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mailer := &recordingMailer{}
+	settings := testAuthSettings
+	settings.EmailVerificationTTL = -time.Minute
+	authService := newAuthServiceWithoutUsers(db, mailer, settings)
+
+	err := authService.Register(&domain.RegisterRequest{Username: "guest", Email: "guest@example.com", Password: "password123"})
+	assert.NoError(t, err)
+	if assert.Len(t, mailer.messages, 1) {
+		err := authService.VerifyEmail(tokenFromLink(t, mailer.messages[0], "/auth/verify"))
+		assert.ErrorIs(t, err, ErrInvalidVerificationToken)
+	}
+}
+
+func guestUserId(t *testing.T, db *gorm.DB) uuid.UUID {
+	var user domain.User
+	if err := db.First(&user, "email = ?", "guest@example.com").Error; err != nil {
+		t.Fatalf("Failed to find guest: %v", err)
+	}
+	return user.Id
+}
+
+func TestAuthService_LoginWithMFA(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+	secret, _ := enableMFA(t, newMFATestService(db), guestUserId(t, db))
+
+	challenge, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	assert.True(t, challenge.MFARequired)
+	assert.NotEmpty(t, challenge.MFAToken)
+	assert.Empty(t, challenge.AccessToken)
+	assert.Empty(t, challenge.RefreshToken)
+
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"}, "")
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: "guessed", Code: totpCode(t, secret, 1)}, "")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+
+	tokens, err := authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.False(t, tokens.MFARequired)
+
+	var stored domain.RefreshToken
+	assert.NoError(t, db.First(&stored).Error)
+	assert.True(t, stored.MFAVerified)
+	assert.Equal(t, "Laptop", stored.Device)
+
+	// A challenge completes one login
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestAuthService_MFAChallengeStopsAfterWrongCodes(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+	secret, _ := enableMFA(t, newMFATestService(db), guestUserId(t, db))
+
+	challenge, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	for i := 0; i < maxMFAAttempts; i++ {
+		_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"}, "")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assert.ErrorIs(t, err, ErrInvalidMFAChallenge)
+}
+
+func TestAuthService_MFAFailuresCountAgainstLoginThrottle(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	settings := testAuthSettings
+	settings.AccountLoginThrottle = LoginThrottlePolicy{FreeAttempts: 10, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	authService := newAuthTestServiceWithMailer(t, db, &recordingMailer{}, settings)
+	secret, _ := enableMFA(t, newMFATestService(db), guestUserId(t, db))
+
+	// The right password alone does not forget earlier failures
+	_, err := authService.Login("guest@example.com", "wrong", "Laptop", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	challenge, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	var throttle domain.LoginThrottle
+	assert.NoError(t, db.First(&throttle, "id = ?", "account:guest@example.com").Error)
+	assert.Equal(t, 1, throttle.Failures)
+
+	// Wrong codes count too, so fresh challenges do not give unlimited guesses
+	for i := 0; i < 2; i++ {
+		_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: "000000"}, "")
+		assert.ErrorIs(t, err, ErrInvalidMFACode)
+	}
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assertThrottled(t, err, 59*time.Minute)
+	_, err = authService.Login("guest@example.com", "password123", "Laptop", "")
+	assertThrottled(t, err, 59*time.Minute)
+
+	// Completing the second factor forgets the account's failures
+	assert.NoError(t, authService.UnlockAccount(guestUserId(t, db)))
+	_, err = authService.Login("guest@example.com", "wrong", "Laptop", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	challenge, err = authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	_, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assert.NoError(t, err)
+	var count int64
+	assert.NoError(t, db.Model(&domain.LoginThrottle{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestAuthService_RequireAdminMFAWithholdsAdminRights(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	settings := testAuthSettings
+	settings.RequireAdminMFA = true
+	authService := newAuthTestServiceWithMailer(t, db, &recordingMailer{}, settings)
+	userId := guestUserId(t, db)
+	assert.NoError(t, db.Model(&domain.User{}).Where("id = ?", userId).Update("is_admin", true).Error)
+
+	tokens, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	assert.True(t, tokens.MFAEnrollmentRequired)
+	user, err := auth.ValidateToken(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.False(t, user.IsAdmin)
+
+	// Refreshing does not restore the rights
+	refreshed, err := authService.RefreshToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+	user, err = auth.ValidateToken(refreshed.AccessToken)
+	assert.NoError(t, err)
+	assert.False(t, user.IsAdmin)
+
+	secret, _ := enableMFA(t, newMFATestService(db), userId)
+	challenge, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	tokens, err = authService.VerifyMFA(&domain.MFAVerifyRequest{MFAToken: challenge.MFAToken, Code: totpCode(t, secret, 1)}, "")
+	assert.NoError(t, err)
+	assert.False(t, tokens.MFAEnrollmentRequired)
+
+	refreshed, err = authService.RefreshToken(tokens.RefreshToken)
+	assert.NoError(t, err)
+	user, err = auth.ValidateToken(refreshed.AccessToken)
+	assert.NoError(t, err)
+	assert.True(t, user.IsAdmin)
+}
+
+func TestAuthService_LoginErrorsDoNotRevealAccounts(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService := newAuthTestService(t, db)
+
+	_, unknown := authService.Login("nobody@example.com", "password123", "Laptop", "")
+	_, wrongPassword := authService.Login("guest@example.com", "wrong", "Laptop", "")
+	assert.ErrorIs(t, unknown, ErrInvalidCredentials)
+	assert.ErrorIs(t, wrongPassword, ErrInvalidCredentials)
+	assert.Equal(t, unknown.Error(), wrongPassword.Error())
+}
+
+func TestLoginThrottlePolicy_Delay(t *testing.T) {
+	policy := LoginThrottlePolicy{FreeAttempts: 3, BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+	expected := map[int]time.Duration{0: 0, 3: 0, 4: time.Second, 5: 2 * time.Second, 6: 4 * time.Second, 7: 8 * time.Second, 8: 10 * time.Second, 50: 10 * time.Second}
+	for failures, delay := range expected {
+		assert.Equal(t, delay, policy.delay(failures), "after %d failures", failures)
+	}
+	assert.Zero(t, LoginThrottlePolicy{}.delay(100))
+}
+
+func assertThrottled(t *testing.T, err error, atLeast time.Duration) {
+	var throttled *LoginThrottledError
+	if assert.True(t, errors.As(err, &throttled), "expected a throttled login, got %v", err) {
+		assert.ErrorIs(t, err, ErrLoginThrottled)
+		assert.Greater(t, throttled.RetryAfter, atLeast)
+	}
+}
+
+func TestAuthService_LoginDelaysGrowAfterFailures(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	settings := testAuthSettings
+	settings.AccountLoginThrottle = LoginThrottlePolicy{FreeAttempts: 2, BaseDelay: time.Minute, MaxDelay: time.Hour, LockoutDuration: time.Hour}
+	authService := newAuthTestServiceWithMailer(t, db, &recordingMailer{}, settings)
+
+	for i := 0; i < 3; i++ {
+		_, err := authService.Login("guest@example.com", "wrong", "Laptop", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// Even the right password waits out the delay
+	_, err := authService.Login("GUEST@example.com", "password123", "Laptop", "")
+	assertThrottled(t, err, 50*time.Second)
+
+	assert.NoError(t, db.Model(&domain.LoginThrottle{}).Where("id = ?", "account:guest@example.com").
+		Update("last_failure_at", time.Now().Add(-2*time.Minute)).Error)
+	_, err = authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+
+	// Logging in forgets the account's failures
+	var count int64
+	assert.NoError(t, db.Model(&domain.LoginThrottle{}).Count(&count).Error)
+	assert.Zero(t, count)
+}
+
+func TestAuthService_LoginLockoutAndUnlock(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	settings := testAuthSettings
+	settings.AccountLoginThrottle = LoginThrottlePolicy{FreeAttempts: 10, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	authService := newAuthTestServiceWithMailer(t, db, &recordingMailer{}, settings)
+
+	for i := 0; i < 3; i++ {
+		_, err := authService.Login("guest@example.com", "wrong", "Laptop", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+		_, err = authService.Login("nobody@example.com", "wrong", "Laptop", "")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	// Unknown addresses lock out the same way, so lockouts do not reveal accounts either
+	_, err := authService.Login("guest@example.com", "password123", "Laptop", "")
+	assertThrottled(t, err, 59*time.Minute)
+	_, err = authService.Login("nobody@example.com", "password123", "Laptop", "")
+	assertThrottled(t, err, 59*time.Minute)
+
+	assert.NoError(t, authService.UnlockAccount(guestUserId(t, db)))
+	_, err = authService.Login("guest@example.com", "password123", "Laptop", "")
+	assert.NoError(t, err)
+	assert.ErrorIs(t, authService.UnlockAccount(uuid.New()), ErrUserNotFound)
+
+	// Once a lockout ends the failures start counting again from zero
+	assert.NoError(t, db.Model(&domain.LoginThrottle{}).Where("id = ?", "account:nobody@example.com").
+		Update("locked_until", time.Now().Add(-time.Minute)).Error)
+	_, err = authService.Login("nobody@example.com", "wrong", "Laptop", "")
+	assert.ErrorIs(t, err, ErrInvalidCredentials)
+	var throttle domain.LoginThrottle
+	assert.NoError(t, db.First(&throttle, "id = ?", "account:nobody@example.com").Error)
+	assert.Equal(t, 1, throttle.Failures)
+	assert.Nil(t, throttle.LockedUntil)
+}
+
+func TestAuthService_LoginLockoutPerIP(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	settings := testAuthSettings
+	settings.IPLoginThrottle = LoginThrottlePolicy{FreeAttempts: 10, LockoutThreshold: 3, LockoutDuration: time.Hour}
+	authService := newAuthTestServiceWithMailer(t, db, &recordingMailer{}, settings)
+
+	for _, email := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		_, err := authService.Login(email, "password123", "Laptop", "203.0.113.7")
+		assert.ErrorIs(t, err, ErrInvalidCredentials)
+	}
+
+	_, err := authService.Login("guest@example.com", "password123", "Laptop", "203.0.113.7")
+	assertThrottled(t, err, 59*time.Minute)
+	_, err = authService.Login("guest@example.com", "password123", "Laptop", "198.51.100.2")
+	assert.NoError(t, err)
+}
+
+// fakeIdentityProvider signs in as identity, checking the code verifier and nonce like a real provider would
+type fakeIdentityProvider struct {
+	identity *oauth.Identity
+	request  oauth.AuthorizationRequest
+}
+
+func (p *fakeIdentityProvider) Name() string {
+	return "fake"
+}
+
+func (p *fakeIdentityProvider) AuthorizationURL(request oauth.AuthorizationRequest) (string, error) {
+	p.request = request
+	return "https://idp.test/authorize?state=" + url.QueryEscape(request.State), nil
+}
+
+func (p *fakeIdentityProvider) Exchange(code string, codeVerifier string, nonce string) (*oauth.Identity, error) {
+	if code != "good-code" || oauth.CodeChallenge(codeVerifier) != p.request.CodeChallenge || nonce != p.request.Nonce {
+		return nil, errors.New("invalid_grant")
+	}
+	return p.identity, nil
+}
+
+// newExternalLoginTestService registers the guest user and offers the fake provider as "fake"
+func newExternalLoginTestService(t *testing.T, db *gorm.DB, identity *oauth.Identity) (IAuthService, *fakeIdentityProvider) {
+	provider := &fakeIdentityProvider{identity: identity}
+	mailer := &recordingMailer{}
+	authService := newAuthServiceWithProviders(db, map[string]oauth.IdentityProvider{"fake": provider}, mailer, testAuthSettings)
+	err := authService.Register(&domain.RegisterRequest{Username: "guest", Email: "guest@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("Failed to register user: %v", err)
+	}
+	return authService, provider
+}
+
+func signInWithFakeProvider(t *testing.T, authService IAuthService) (domain.LoginResponse, error) {
+	start, err := authService.StartExternalLogin("fake")
+	if err != nil {
+		t.Fatalf("Failed to start external login: %v", err)
+	}
+	return authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: start.State}, "Laptop")
+}
+
+func TestAuthService_ExternalLoginCreatesUser(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, provider := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane Doe"})
+
+	start, err := authService.StartExternalLogin("fake")
+	assert.NoError(t, err)
+	assert.Contains(t, start.AuthorizationURL, url.QueryEscape(start.State))
+	assert.NotEmpty(t, provider.request.Nonce)
+	assert.NotEmpty(t, provider.request.CodeChallenge)
+
+	tokens, err := authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: start.State}, "Laptop")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, tokens.AccessToken)
+	assert.NotEmpty(t, tokens.RefreshToken)
+
+	var user domain.User
+	assert.NoError(t, db.First(&user, "email = ?", "Jane@Example.com").Error)
+	assert.Equal(t, "jane-doe", user.Username)
+	assert.True(t, user.IsEmailVerified())
+
+	// Signing in again finds the linked user rather than creating another
+	provider.identity = &oauth.Identity{Subject: "sub-1", Email: "jane@elsewhere.example", Name: "Jane Doe"}
+	_, err = signInWithFakeProvider(t, authService)
+	assert.NoError(t, err)
+	var users int64
+	db.Model(&domain.User{}).Count(&users)
+	assert.Equal(t, int64(2), users)
+}
+
+func TestAuthService_ExternalLoginLinksByVerifiedEmail(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "guest@example.com", EmailVerified: true, Name: "Guest"})
+	db.Model(&domain.User{}).Where("email = ?", "guest@example.com").Update("email_verified_at", time.Now())
+
+	_, err := signInWithFakeProvider(t, authService)
+	assert.NoError(t, err)
+
+	var identity domain.ExternalIdentity
+	assert.NoError(t, db.First(&identity, "provider = ? AND subject = ?", "fake", "sub-1").Error)
+	assert.Equal(t, guestUserId(t, db), identity.UserId)
+}
+
+func TestAuthService_ExternalLoginDoesNotLinkUnverifiedAccount(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	// Someone registered the address without proving they own it
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "guest@example.com", EmailVerified: true, Name: "Guest"})
+
+	_, err := signInWithFakeProvider(t, authService)
+	assert.ErrorIs(t, err, ErrExternalAccountNotVerified)
+
+	var links int64
+	db.Model(&domain.ExternalIdentity{}).Count(&links)
+	assert.Zero(t, links)
+	var user domain.User
+	assert.NoError(t, db.First(&user, "email = ?", "guest@example.com").Error)
+	assert.False(t, user.IsEmailVerified())
+}
+
+func TestAuthService_ExternalLoginRefusesUnverifiedEmail(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "guest@example.com", Name: "Guest"})
+
+	_, err := signInWithFakeProvider(t, authService)
+	assert.ErrorIs(t, err, ErrExternalEmailNotVerified)
+
+	var links int64
+	db.Model(&domain.ExternalIdentity{}).Count(&links)
+	assert.Zero(t, links)
+}
+
+func TestAuthService_ExternalLoginStateIsSingleUse(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "jane@example.com", EmailVerified: true})
+
+	start, err := authService.StartExternalLogin("fake")
+	assert.NoError(t, err)
+	_, err = authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: "forged"}, "Laptop")
+	assert.ErrorIs(t, err, ErrInvalidExternalLoginState)
+
+	// A failed exchange still uses up the state
+	_, err = authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "bad-code", State: start.State}, "Laptop")
+	assert.ErrorIs(t, err, ErrExternalLoginFailed)
+	_, err = authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: start.State}, "Laptop")
+	assert.ErrorIs(t, err, ErrInvalidExternalLoginState)
+
+	start, err = authService.StartExternalLogin("fake")
+	assert.NoError(t, err)
+	db.Model(&domain.ExternalLoginState{}).Where("used_at IS NULL").Update("expires_at", time.Now().Add(-time.Minute))
+	_, err = authService.CompleteExternalLogin("fake", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: start.State}, "Laptop")
+	assert.ErrorIs(t, err, ErrInvalidExternalLoginState)
+}
+
+func TestAuthService_ExternalLoginUnknownProvider(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1"})
+
+	_, err := authService.StartExternalLogin("other")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+	_, err = authService.CompleteExternalLogin("other", &domain.ExternalLoginCallbackRequest{Code: "good-code", State: "state"}, "Laptop")
+	assert.ErrorIs(t, err, ErrUnknownIdentityProvider)
+	assert.Equal(t, []domain.IdentityProviderInfo{{Name: "fake"}}, authService.GetIdentityProviders())
+}
+
+func TestAuthService_ExternalLoginStillRequiresMFA(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	authService, _ := newExternalLoginTestService(t, db, &oauth.Identity{Subject: "sub-1", Email: "guest@example.com", EmailVerified: true})
+	db.Model(&domain.User{}).Where("email = ?", "guest@example.com").Update("email_verified_at", time.Now())
+	enableMFA(t, newMFATestService(db), guestUserId(t, db))
+
+	challenge, err := signInWithFakeProvider(t, authService)
+	assert.NoError(t, err)
+	assert.True(t, challenge.MFARequired)
+	assert.Empty(t, challenge.AccessToken)
+}
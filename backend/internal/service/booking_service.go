@@ -4,23 +4,94 @@ import (
 	"backend/internal/domain"
 	"backend/internal/repository"
 	"errors"
+	"fmt"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
 )
 
+var (
+	ErrRoomUnavailable       = errors.New("room is already booked for the selected dates")
+	ErrBookingNotFound       = errors.New("booking not found")
+	ErrBookingAlreadyClosed  = errors.New("booking is already cancelled")
+	ErrBookingNotOwnedByUser = errors.New("booking does not belong to user")
+	ErrBookingChangesClosed  = errors.New("booking can only be changed before check-in")
+	ErrInvalidGuestDetails   = errors.New("invalid guest details")
+	ErrBookingStatusConflict = errors.New("booking status does not allow this action")
+	ErrCheckInNotOpen        = errors.New("check-in opens on the arrival date")
+	ErrEmailNotVerified      = errors.New("verify your email address before booking")
+)
+
+const (
+	maxGuestNameLength       = 100
+	maxSpecialRequestsLength = 1000
+)
+
+var (
+	guestPhonePattern = regexp.MustCompile(`^\+?[0-9 ()-]{6,20}$`)
+
+	knownSpecialRequests = map[domain.SpecialRequestType]bool{
+		domain.SpecialRequestHighFloor:      true,
+		domain.SpecialRequestLowFloor:       true,
+		domain.SpecialRequestQuietRoom:      true,
+		domain.SpecialRequestCrib:           true,
+		domain.SpecialRequestExtraBed:       true,
+		domain.SpecialRequestAccessibleRoom: true,
+		domain.SpecialRequestEarlyCheckIn:   true,
+		domain.SpecialRequestLateCheckOut:   true,
+	}
+)
+
+// InventoryListener hands rooms given back by bookings to the next guests waiting for them, and looks up
+// the open offers those guests claim with ClaimWaitlistOffer
+type InventoryListener interface {
+	InventoryReleased(booking *domain.Booking)
+	GetOpenOffer(token string, userId uuid.UUID) (*domain.WaitlistEntry, error)
+}
+
+// EmailVerifier tells whether a user has confirmed their email address
+type EmailVerifier interface {
+	IsEmailVerified(userId uuid.UUID) (bool, error)
+}
+
 type BookingService interface {
 	CreateBooking(request *domain.CreateBookingRequest) error
+	ClaimWaitlistOffer(token string, userId uuid.UUID) (*domain.Booking, error)
 	GetAllBookings() ([]domain.Booking, error)
 	GetBookingById(id string) (*domain.Booking, error)
 	GetBookingsByUserId(userId string) ([]domain.Booking, error)
+	GetBookingsByHotelId(hotelId string) ([]domain.Booking, error)
+	CancelBooking(id string, user *domain.User) error
+	AddAddOn(id string, user *domain.User, selection *domain.AddOnSelection) (*domain.BookingAddOn, error)
+	RemoveAddOn(id string, bookingAddOnId string, user *domain.User) error
+	GetInvoice(id string, user *domain.User) (*domain.Invoice, error)
+	UpdateGuestDetails(id string, user *domain.User, details *domain.GuestDetails) (*domain.Booking, error)
+	CheckIn(id string, now time.Time) (*domain.Booking, error)
+	CheckOut(id string) (*domain.Booking, error)
 }
 type bookingService struct {
 	hotelRepository   repository.HotelRepository
 	bookingRepository repository.BookingRepository
+	addOns            AddOnService
+	stayRestrictions  StayRestrictionService
+	inventoryListener InventoryListener
+	verifiedEmails    EmailVerifier
 }
 
-func NewBookingService(hotelRepository repository.HotelRepository, bookingRepository repository.BookingRepository) BookingService {
-	return &bookingService{hotelRepository: hotelRepository, bookingRepository: bookingRepository}
+// NewBookingService creates a booking service. addOns, stayRestrictions, inventoryListener and verifiedEmails
+// may be nil. When verifiedEmails is set, only users with a verified email address can book.
+func NewBookingService(hotelRepository repository.HotelRepository, bookingRepository repository.BookingRepository, addOns AddOnService, stayRestrictions StayRestrictionService, inventoryListener InventoryListener, verifiedEmails EmailVerifier) BookingService {
+	return &bookingService{
+		hotelRepository:   hotelRepository,
+		bookingRepository: bookingRepository,
+		addOns:            addOns,
+		stayRestrictions:  stayRestrictions,
+		inventoryListener: inventoryListener,
+		verifiedEmails:    verifiedEmails,
+	}
 }
 
 /*
@@ -30,10 +101,50 @@ Returns: error
 Description: Create a new booking
 */
 func (s *bookingService) CreateBooking(request *domain.CreateBookingRequest) error {
+	_, err := s.createBooking(request, nil)
+	return err
+}
+
+/*
+ClaimWaitlistOffer
+Params: offer token from the claim link, id of the user claiming it
+Returns: the booking, error
+Description: Book the room held by the user's open waitlist offer. The offer is closed in the same
+transaction that creates the booking, so a failed booking leaves the offer open to retry until it expires.
+*/
+func (s *bookingService) ClaimWaitlistOffer(token string, userId uuid.UUID) (*domain.Booking, error) {
+	if s.inventoryListener == nil {
+		return nil, ErrOfferNotFound
+	}
+	entry, err := s.inventoryListener.GetOpenOffer(token, userId)
+	if err != nil {
+		return nil, err
+	}
+	return s.createBooking(&domain.CreateBookingRequest{
+		HotelId:      entry.HotelId,
+		UserId:       entry.UserId,
+		RoomId:       entry.RoomId,
+		CheckInDate:  entry.CheckInDate,
+		CheckOutDate: entry.CheckOutDate,
+	}, entry)
+}
+
+// createBooking prices and stores a booking, closing the claimed waitlist offer with it when there is one
+func (s *bookingService) createBooking(request *domain.CreateBookingRequest, claim *domain.WaitlistEntry) (*domain.Booking, error) {
+	if s.verifiedEmails != nil {
+		verified, err := s.verifiedEmails.IsEmailVerified(request.UserId)
+		if err != nil {
+			return nil, err
+		}
+		if !verified {
+			return nil, ErrEmailNotVerified
+		}
+	}
+
 	// get room price
 	hotel, err := s.hotelRepository.GetHotelById(request.HotelId.String())
 	if err != nil {
-		return err
+		return nil, err
 	}
 	var targetRoom *domain.Room
 
@@ -45,31 +156,83 @@ func (s *bookingService) CreateBooking(request *domain.CreateBookingRequest) err
 	}
 
 	if targetRoom == nil {
-		return errors.New("room not found")
+		return nil, errors.New("room not found")
 	}
 
 	if !targetRoom.Available {
-		return errors.New("room is not available")
+		return nil, errors.New("room is not available")
 	}
 
 	if request.CheckOutDate.Before(request.CheckInDate) {
-		return errors.New("check out date must be after check in date")
+		return nil, errors.New("check out date must be after check in date")
+	}
+
+	if s.stayRestrictions != nil {
+		err := s.stayRestrictions.ValidateStay(request.HotelId, request.RoomId, request.CheckInDate, request.CheckOutDate, time.Now())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	guests := request.Guests
+	if guests <= 0 {
+		guests = 1
+	}
+
+	var guest domain.GuestDetails
+	if request.Guest != nil {
+		guest = *request.Guest
+		if err := normalizeGuestDetails(&guest); err != nil {
+			return nil, err
+		}
 	}
 
 	numberOfDays := request.CheckOutDate.Sub(request.CheckInDate).Hours() / 24
 	totalPrice := targetRoom.Price * numberOfDays
 
+	bookingId := uuid.New()
+	var addOns []*domain.BookingAddOn
+	if len(request.AddOns) > 0 {
+		if s.addOns == nil {
+			return nil, ErrAddOnNotFound
+		}
+		nights := daysBetween(truncateToDay(request.CheckInDate), truncateToDay(request.CheckOutDate))
+		addOns, err = s.addOns.PriceSelections(request.HotelId, request.AddOns, nights, guests)
+		if err != nil {
+			return nil, err
+		}
+		for _, addOn := range addOns {
+			addOn.BookingId = bookingId
+			totalPrice += addOn.TotalPrice
+		}
+	}
+
 	booking := &domain.Booking{
-		Id:           uuid.New(),
-		UserId:       request.UserId,
-		HotelId:      request.HotelId,
-		RoomId:       request.RoomId,
-		CheckInDate:  request.CheckInDate,
-		CheckOutDate: request.CheckOutDate,
-		TotalPrice:   totalPrice,
-		IsCancelled:  false,
+		Id:             bookingId,
+		UserId:         request.UserId,
+		HotelId:        request.HotelId,
+		OrganizationId: hotel.OrganizationId,
+		RoomId:         request.RoomId,
+		CheckInDate:    request.CheckInDate,
+		CheckOutDate:   request.CheckOutDate,
+		Guests:         guests,
+		TotalPrice:     totalPrice,
+		IsCancelled:    false,
+		Status:         domain.BookingStatusConfirmed,
+		Guest:          guest,
+		AddOns:         addOns,
+	}
+	err = s.bookingRepository.CreateBookingIfRoomFree(booking, claim)
+	if errors.Is(err, repository.ErrRoomTaken) {
+		return nil, ErrRoomUnavailable
+	}
+	if errors.Is(err, repository.ErrOfferClosed) {
+		return nil, ErrWaitlistEntryClosed
+	}
+	if err != nil {
+		return nil, err
 	}
-	return s.bookingRepository.CreateBooking(booking)
+	return booking, nil
 }
 
 func (s *bookingService) GetBookingsByUserId(userId string) ([]domain.Booking, error) {
@@ -83,3 +246,270 @@ func (s *bookingService) GetAllBookings() ([]domain.Booking, error) {
 func (s *bookingService) GetBookingById(id string) (*domain.Booking, error) {
 	return s.bookingRepository.GetBookingById(id)
 }
+
+func (s *bookingService) GetBookingsByHotelId(hotelId string) ([]domain.Booking, error) {
+	return s.bookingRepository.GetBookingsByHotelId(hotelId)
+}
+
+/*
+CancelBooking
+Params: booking id, user requesting the cancellation
+Returns: error
+Description: Cancel a booking owned by the user (admins may cancel any booking) and release the room
+*/
+func (s *bookingService) CancelBooking(id string, user *domain.User) error {
+	booking, err := s.getOwnedBooking(id, user)
+	if err != nil {
+		return err
+	}
+	if booking.IsCancelled {
+		return ErrBookingAlreadyClosed
+	}
+	if booking.Status != domain.BookingStatusConfirmed {
+		return ErrBookingStatusConflict
+	}
+
+	booking.IsCancelled = true
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return err
+	}
+
+	if s.inventoryListener != nil {
+		s.inventoryListener.InventoryReleased(booking)
+	}
+	return nil
+}
+
+/*
+AddAddOn
+Params: booking id, user, add-on selection
+Returns: the add-on line attached to the booking, error
+Description: Add a catalogue add-on to an active booking before check-in and raise its total
+*/
+func (s *bookingService) AddAddOn(id string, user *domain.User, selection *domain.AddOnSelection) (*domain.BookingAddOn, error) {
+	booking, err := s.getModifiableBooking(id, user)
+	if err != nil {
+		return nil, err
+	}
+	if s.addOns == nil {
+		return nil, ErrAddOnNotFound
+	}
+
+	nights := daysBetween(truncateToDay(booking.CheckInDate), truncateToDay(booking.CheckOutDate))
+	lines, err := s.addOns.PriceSelections(booking.HotelId, []domain.AddOnSelection{*selection}, nights, booking.Guests)
+	if err != nil {
+		return nil, err
+	}
+	line := lines[0]
+	line.BookingId = booking.Id
+
+	if err := s.bookingRepository.AddBookingAddOn(booking, line); err != nil {
+		return nil, err
+	}
+	return line, nil
+}
+
+/*
+RemoveAddOn
+Params: booking id, booking add-on id, user
+Returns: error
+Description: Remove an add-on from an active booking before check-in and lower its total
+*/
+func (s *bookingService) RemoveAddOn(id string, bookingAddOnId string, user *domain.User) error {
+	booking, err := s.getModifiableBooking(id, user)
+	if err != nil {
+		return err
+	}
+
+	for _, line := range booking.AddOns {
+		if line.Id.String() == bookingAddOnId {
+			return s.bookingRepository.RemoveBookingAddOn(booking, line)
+		}
+	}
+	return ErrAddOnNotFound
+}
+
+/*
+GetInvoice
+Params: booking id, user
+Returns: Invoice, error
+Description: Itemise the booking total into the room stay and each add-on
+*/
+func (s *bookingService) GetInvoice(id string, user *domain.User) (*domain.Invoice, error) {
+	booking, err := s.getOwnedBooking(id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	addOnTotal := 0.0
+	var addOnLines []domain.InvoiceLine
+	for _, line := range booking.AddOns {
+		addOnTotal += line.TotalPrice
+		addOnLines = append(addOnLines, domain.InvoiceLine{
+			Description: line.Name + " (" + string(line.Pricing) + ")",
+			Quantity:    float64(line.Quantity),
+			UnitPrice:   line.UnitPrice,
+			Amount:      line.TotalPrice,
+		})
+	}
+
+	// The stored total always equals the room stay plus the attached add-ons
+	nights := booking.CheckOutDate.Sub(booking.CheckInDate).Hours() / 24
+	roomAmount := booking.TotalPrice - addOnTotal
+	roomLine := domain.InvoiceLine{Description: "Room stay", Quantity: nights, Amount: roomAmount}
+	if nights > 0 {
+		roomLine.UnitPrice = roomAmount / nights
+	}
+
+	return &domain.Invoice{
+		BookingId: booking.Id,
+		HotelId:   booking.HotelId,
+		UserId:    booking.UserId,
+		Lines:     append([]domain.InvoiceLine{roomLine}, addOnLines...),
+		Total:     booking.TotalPrice,
+		IssuedAt:  time.Now(),
+	}, nil
+}
+
+/*
+UpdateGuestDetails
+Params: booking id, user, guest details
+Returns: updated booking, error
+Description: Replace the staying guest's details and special requests on an active booking before check-in
+*/
+func (s *bookingService) UpdateGuestDetails(id string, user *domain.User, details *domain.GuestDetails) (*domain.Booking, error) {
+	booking, err := s.getModifiableBooking(id, user)
+	if err != nil {
+		return nil, err
+	}
+
+	guest := *details
+	if err := normalizeGuestDetails(&guest); err != nil {
+		return nil, err
+	}
+	booking.Guest = guest
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+/*
+CheckIn
+Params: booking id, current time
+Returns: updated booking, error
+Description: Mark a confirmed booking as arrived. Check-in opens on the arrival date.
+*/
+func (s *bookingService) CheckIn(id string, now time.Time) (*domain.Booking, error) {
+	booking, err := s.bookingRepository.GetBookingById(id)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if booking.IsCancelled {
+		return nil, ErrBookingAlreadyClosed
+	}
+	if booking.Status != domain.BookingStatusConfirmed {
+		return nil, ErrBookingStatusConflict
+	}
+	if truncateToDay(now).Before(truncateToDay(booking.CheckInDate)) {
+		return nil, ErrCheckInNotOpen
+	}
+
+	booking.Status = domain.BookingStatusCheckedIn
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+/*
+CheckOut
+Params: booking id
+Returns: updated booking, error
+Description: Mark a checked-in booking as departed
+*/
+func (s *bookingService) CheckOut(id string) (*domain.Booking, error) {
+	booking, err := s.bookingRepository.GetBookingById(id)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if booking.Status != domain.BookingStatusCheckedIn {
+		return nil, ErrBookingStatusConflict
+	}
+
+	booking.Status = domain.BookingStatusCheckedOut
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return nil, err
+	}
+	return booking, nil
+}
+
+// getOwnedBooking loads a booking the user owns; admins may access any booking
+func (s *bookingService) getOwnedBooking(id string, user *domain.User) (*domain.Booking, error) {
+	booking, err := s.bookingRepository.GetBookingById(id)
+	if err != nil {
+		return nil, ErrBookingNotFound
+	}
+	if booking.UserId != user.Id && !user.IsAdmin {
+		return nil, ErrBookingNotOwnedByUser
+	}
+	return booking, nil
+}
+
+// getModifiableBooking loads an owned booking that is still active and has not reached check-in
+func (s *bookingService) getModifiableBooking(id string, user *domain.User) (*domain.Booking, error) {
+	booking, err := s.getOwnedBooking(id, user)
+	if err != nil {
+		return nil, err
+	}
+	if booking.IsCancelled {
+		return nil, ErrBookingAlreadyClosed
+	}
+	if !time.Now().Before(booking.CheckInDate) {
+		return nil, ErrBookingChangesClosed
+	}
+	return booking, nil
+}
+
+// normalizeGuestDetails trims the free-text fields and rejects values staff could not act on
+func normalizeGuestDetails(guest *domain.GuestDetails) error {
+	guest.Name = strings.TrimSpace(guest.Name)
+	guest.Email = strings.TrimSpace(guest.Email)
+	guest.Phone = strings.TrimSpace(guest.Phone)
+	guest.ArrivalTime = strings.TrimSpace(guest.ArrivalTime)
+	guest.SpecialRequests = strings.TrimSpace(guest.SpecialRequests)
+
+	if len(guest.Name) > maxGuestNameLength {
+		return fmt.Errorf("%w: name cannot exceed %d characters", ErrInvalidGuestDetails, maxGuestNameLength)
+	}
+	if guest.Email != "" {
+		if _, err := mail.ParseAddress(guest.Email); err != nil {
+			return fmt.Errorf("%w: email is not a valid address", ErrInvalidGuestDetails)
+		}
+	}
+	if guest.Phone != "" && !guestPhonePattern.MatchString(guest.Phone) {
+		return fmt.Errorf("%w: phone must contain 6 to 20 digits", ErrInvalidGuestDetails)
+	}
+	if guest.ArrivalTime != "" {
+		if _, err := time.Parse("15:04", guest.ArrivalTime); err != nil {
+			return fmt.Errorf("%w: arrival time must use HH:MM format", ErrInvalidGuestDetails)
+		}
+	}
+	if len(guest.SpecialRequests) > maxSpecialRequestsLength {
+		return fmt.Errorf("%w: special requests cannot exceed %d characters", ErrInvalidGuestDetails, maxSpecialRequestsLength)
+	}
+
+	seen := make(map[domain.SpecialRequestType]bool, len(guest.StructuredRequests))
+	var requests []domain.SpecialRequestType
+	for _, request := range guest.StructuredRequests {
+		if !knownSpecialRequests[request] {
+			return fmt.Errorf("%w: unknown special request %q", ErrInvalidGuestDetails, request)
+		}
+		if !seen[request] {
+			seen[request] = true
+			requests = append(requests, request)
+		}
+	}
+	guest.StructuredRequests = requests
+	return nil
+}
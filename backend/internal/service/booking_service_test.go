@@ -22,10 +22,18 @@ func setupBookingServiceTestDB(t *testing.T) *gorm.DB {
 	err = db.Exec(`
 		CREATE TABLE hotels (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			name TEXT,
 			description TEXT,
 			address TEXT,
-			rating REAL
+			city TEXT,
+			country TEXT,
+			latitude REAL,
+			longitude REAL,
+			rating REAL,
+			review_count INTEGER DEFAULT 0,
+			no_show_cutoff_hours INTEGER,
+			no_show_penalty_nights INTEGER
 		);
 		CREATE TABLE rooms (
 			id TEXT PRIMARY KEY,
@@ -39,6 +47,21 @@ func setupBookingServiceTestDB(t *testing.T) *gorm.DB {
 			id TEXT PRIMARY KEY,
 			name TEXT
 		);
+		CREATE TABLE photos (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			position INTEGER,
+			is_cover INTEGER DEFAULT 0,
+			content_type TEXT,
+			width INTEGER,
+			height INTEGER,
+			size INTEGER,
+			storage_key TEXT,
+			url TEXT,
+			thumbnails TEXT,
+			created_at DATETIME
+		);
 		CREATE TABLE room_facilities (
 			room_id TEXT,
 			facility_id TEXT,
@@ -46,13 +69,46 @@ func setupBookingServiceTestDB(t *testing.T) *gorm.DB {
 		);
 		CREATE TABLE bookings (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			user_id TEXT,
 			hotel_id TEXT,
 			room_id TEXT,
 			check_in_date DATETIME,
 			check_out_date DATETIME,
+			guests INTEGER DEFAULT 1,
+			total_price REAL,
+			is_cancelled INTEGER DEFAULT 0,
+			status TEXT DEFAULT 'confirmed',
+			guest_name TEXT,
+			guest_email TEXT,
+			guest_phone TEXT,
+			guest_arrival_time TEXT,
+			guest_special_requests TEXT,
+			guest_structured_requests TEXT
+		);
+		CREATE TABLE booking_add_ons (
+			id TEXT PRIMARY KEY,
+			booking_id TEXT,
+			add_on_id TEXT,
+			name TEXT,
+			pricing TEXT,
+			unit_price REAL,
+			quantity INTEGER,
 			total_price REAL,
-			is_cancelled INTEGER DEFAULT 0
+			created_at DATETIME
+		);
+		CREATE TABLE waitlist_entries (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			hotel_id TEXT,
+			room_id TEXT,
+			check_in_date DATETIME,
+			check_out_date DATETIME,
+			status TEXT,
+			offer_token_hash TEXT,
+			offer_expires_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME
 		)
 	`).Error
 	if err != nil {
@@ -179,7 +235,7 @@ func TestBookingService_CreateBooking(t *testing.T) {
 
 			repo := repository.NewBookingRepository(db)
 			hotelRepo := repository.NewHotelRepository(db)
-			service := NewBookingService(hotelRepo, repo)
+			service := NewBookingService(hotelRepo, repo, nil, nil, nil, nil)
 			err = service.CreateBooking(&domain.CreateBookingRequest{
 				HotelId:      tt.hotelId,
 				UserId:       tt.userId,
@@ -222,7 +278,7 @@ func TestBookingService_GetAllBookings(t *testing.T) {
 
 	repo := repository.NewBookingRepository(db)
 	hotelRepo := repository.NewHotelRepository(db)
-	service := NewBookingService(hotelRepo, repo)
+	service := NewBookingService(hotelRepo, repo, nil, nil, nil, nil)
 
 	// Create test bookings
 	userId1 := uuid.New()
@@ -268,7 +324,7 @@ func TestBookingService_GetBookingById(t *testing.T) {
 
 	repo := repository.NewBookingRepository(db)
 	hotelRepo := repository.NewHotelRepository(db)
-	service := NewBookingService(hotelRepo, repo)
+	service := NewBookingService(hotelRepo, repo, nil, nil, nil, nil)
 
 	checkIn := time.Now()
 	checkOut := time.Now().AddDate(0, 0, 4)
@@ -331,8 +387,128 @@ func TestNewBookingService(t *testing.T) {
 
 	repo := repository.NewBookingRepository(db)
 	hotelRepo := repository.NewHotelRepository(db)
-	service := NewBookingService(hotelRepo, repo)
+	service := NewBookingService(hotelRepo, repo, nil, nil, nil, nil)
 	assert.NotNil(t, service)
 	assert.Equal(t, hotelRepo, service.(*bookingService).hotelRepository)
 	assert.Equal(t, repo, service.(*bookingService).bookingRepository)
 }
+
+func TestBookingService_GuestDetailsValidation(t *testing.T) {
+	tests := []struct {
+		name        string
+		guest       domain.GuestDetails
+		shouldError bool
+	}{
+		{
+			name: "complete details",
+			guest: domain.GuestDetails{
+				Name:               "Jane Doe",
+				Email:              "jane@example.com",
+				Phone:              "+62 812-3456-7890",
+				ArrivalTime:        "15:30",
+				SpecialRequests:    "Anniversary trip",
+				StructuredRequests: []domain.SpecialRequestType{domain.SpecialRequestHighFloor, domain.SpecialRequestCrib},
+			},
+			shouldError: false,
+		},
+		{name: "empty details", guest: domain.GuestDetails{}, shouldError: false},
+		{name: "invalid email", guest: domain.GuestDetails{Email: "not-an-email"}, shouldError: true},
+		{name: "invalid phone", guest: domain.GuestDetails{Phone: "call me"}, shouldError: true},
+		{name: "invalid arrival time", guest: domain.GuestDetails{ArrivalTime: "25:99"}, shouldError: true},
+		{name: "unknown structured request", guest: domain.GuestDetails{StructuredRequests: []domain.SpecialRequestType{"helipad"}}, shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := setupBookingServiceTestDB(t)
+			hotelId := uuid.New()
+			roomId := uuid.New()
+			assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+			service := NewBookingService(repository.NewHotelRepository(db), repository.NewBookingRepository(db), nil, nil, nil, nil)
+
+			guest := tt.guest
+			err := service.CreateBooking(&domain.CreateBookingRequest{
+				HotelId:      hotelId,
+				UserId:       uuid.New(),
+				RoomId:       roomId,
+				CheckInDate:  time.Now().AddDate(0, 0, 1),
+				CheckOutDate: time.Now().AddDate(0, 0, 3),
+				Guest:        &guest,
+			})
+			if tt.shouldError {
+				assert.ErrorIs(t, err, ErrInvalidGuestDetails)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBookingService_UpdateGuestDetails(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	service := NewBookingService(repository.NewHotelRepository(db), repository.NewBookingRepository(db), nil, nil, nil, nil)
+
+	booker := &domain.User{Id: uuid.New()}
+	err := service.CreateBooking(&domain.CreateBookingRequest{
+		HotelId:      hotelId,
+		UserId:       booker.Id,
+		RoomId:       roomId,
+		CheckInDate:  time.Now().AddDate(0, 0, 5),
+		CheckOutDate: time.Now().AddDate(0, 0, 7),
+	})
+	assert.NoError(t, err)
+	bookings, err := service.GetBookingsByUserId(booker.Id.String())
+	assert.NoError(t, err)
+	bookingId := bookings[0].Id.String()
+
+	_, err = service.UpdateGuestDetails(bookingId, &domain.User{Id: uuid.New()}, &domain.GuestDetails{Name: "Someone Else"})
+	assert.ErrorIs(t, err, ErrBookingNotOwnedByUser)
+
+	_, err = service.UpdateGuestDetails(bookingId, booker, &domain.GuestDetails{
+		Name:               "  John Guest ",
+		ArrivalTime:        "22:00",
+		StructuredRequests: []domain.SpecialRequestType{domain.SpecialRequestAccessibleRoom, domain.SpecialRequestAccessibleRoom},
+	})
+	assert.NoError(t, err)
+
+	// Hotel staff see the details when listing the hotel's bookings
+	hotelBookings, err := service.GetBookingsByHotelId(hotelId.String())
+	assert.NoError(t, err)
+	assert.Len(t, hotelBookings, 1)
+	assert.Equal(t, "John Guest", hotelBookings[0].Guest.Name)
+	assert.Equal(t, "22:00", hotelBookings[0].Guest.ArrivalTime)
+	assert.Equal(t, []domain.SpecialRequestType{domain.SpecialRequestAccessibleRoom}, hotelBookings[0].Guest.StructuredRequests)
+}
+
+// verifiedEmailSet is an EmailVerifier backed by the ids of users who verified their email
+type verifiedEmailSet map[uuid.UUID]bool
+
+func (s verifiedEmailSet) IsEmailVerified(userId uuid.UUID) (bool, error) {
+	return s[userId], nil
+}
+
+func TestBookingService_RequiresVerifiedEmail(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	verified := uuid.New()
+	service := NewBookingService(repository.NewHotelRepository(db), repository.NewBookingRepository(db), nil, nil, nil, verifiedEmailSet{verified: true})
+
+	request := func(userId uuid.UUID, days int) *domain.CreateBookingRequest {
+		return &domain.CreateBookingRequest{
+			HotelId:      hotelId,
+			UserId:       userId,
+			RoomId:       roomId,
+			CheckInDate:  time.Now().AddDate(0, 0, days),
+			CheckOutDate: time.Now().AddDate(0, 0, days+2),
+		}
+	}
+
+	// The check applies to the user the booking is for, whoever authenticated the request
+	assert.ErrorIs(t, service.CreateBooking(request(uuid.New(), 1)), ErrEmailNotVerified)
+	assert.NoError(t, service.CreateBooking(request(verified, 1)))
+}
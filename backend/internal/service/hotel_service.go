@@ -3,24 +3,88 @@ package service
 import (
 	"backend/internal/domain"
 	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	earthRadiusKm  = 6371.0088
+	kmPerDegreeLat = 111.32
+	maxRadiusKm    = 1000
+
+	maxPriceCalendarDays = 366
+)
+
+var (
+	// ratingBands are the minimum ratings counted by the rating facet
+	ratingBands = []float64{4.5, 4, 3.5, 3}
+	// priceBucketEdges are the lower edges of the price facet's buckets; the last bucket is open-ended
+	priceBucketEdges = []float64{0, 50, 100, 200, 400}
+)
+
+var (
+	ErrInvalidLocationSearch = errors.New("invalid location search")
+	ErrInvalidSearchQuery    = errors.New("invalid search query")
+	ErrInvalidHotelFilter    = errors.New("invalid hotel filter")
+	ErrHotelNotFound         = errors.New("hotel not found")
+	ErrInvalidCalendarRange  = errors.New("invalid calendar range")
 )
 
 type HotelService interface {
 	CreateHotel(hotel *domain.Hotel) error
 	GetAllHotels() ([]domain.Hotel, error)
 	GetHotelById(id string) (*domain.Hotel, error)
+	SearchHotels(search *domain.HotelSearch) ([]domain.Hotel, error)
+	SearchHotelsWithFacets(search *domain.HotelSearch) (*domain.HotelSearchResult, error)
+	GetPriceCalendar(hotelId string, from time.Time, to time.Time) ([]domain.PriceCalendarDay, error)
 }
 
 type hotelService struct {
-	hotelRepository repository.HotelRepository
+	hotelRepository        repository.HotelRepository
+	organizationRepository repository.OrganizationRepository
+	textSearch             HotelTextSearch
+	stayRestrictions       StayRestrictionService
 }
 
-func NewHotelService(hotelRepository repository.HotelRepository) HotelService {
-	return &hotelService{hotelRepository: hotelRepository}
+// NewHotelService creates a hotel service. textSearch may be nil, which disables text queries.
+// stayRestrictions may be nil, in which case the price calendar ignores stay restrictions.
+func NewHotelService(hotelRepository repository.HotelRepository, organizationRepository repository.OrganizationRepository, textSearch HotelTextSearch, stayRestrictions StayRestrictionService) HotelService {
+	return &hotelService{hotelRepository: hotelRepository, organizationRepository: organizationRepository, textSearch: textSearch, stayRestrictions: stayRestrictions}
 }
 
 func (s *hotelService) CreateHotel(hotel *domain.Hotel) error {
-	return s.hotelRepository.CreateHotel(hotel)
+	if hotel.OrganizationId != nil {
+		if _, err := s.organizationRepository.GetOrganizationById(hotel.OrganizationId.String()); err != nil {
+			return ErrOrganizationNotFound
+		}
+	}
+	// A new hotel has no reviews yet
+	hotel.Rating = 0
+	hotel.ReviewCount = 0
+	// The organization is only referenced by id; moving a hotel later goes through the organization service
+	hotel.Organization = nil
+	// Photos are only added through the upload endpoints
+	hotel.Photos = nil
+	for _, room := range hotel.Rooms {
+		room.Photos = nil
+	}
+	if err := s.hotelRepository.CreateHotel(hotel); err != nil {
+		return err
+	}
+	// The index is rebuilt on startup, so a failure here only delays the hotel showing up in text search
+	if s.textSearch != nil {
+		if err := s.textSearch.IndexHotel(hotel); err != nil {
+			log.Printf("Error indexing hotel %s for search: %v", hotel.Id, err)
+		}
+	}
+	return nil
 }
 
 func (s *hotelService) GetAllHotels() ([]domain.Hotel, error) {
@@ -30,3 +94,317 @@ func (s *hotelService) GetAllHotels() ([]domain.Hotel, error) {
 func (s *hotelService) GetHotelById(id string) (*domain.Hotel, error) {
 	return s.hotelRepository.GetHotelById(id)
 }
+
+/*
+SearchHotels
+Params: HotelSearch
+Returns: matching hotels, error
+Description: Filter hotels by a text query, city, country, bounding box, radius around a point,
+facilities, minimum rating and price of the cheapest room. A text query orders the hotels by relevance.
+When a point is given the hotels are sorted by distance from it instead, with the distance in each result;
+hotels without coordinates come last.
+*/
+func (s *hotelService) SearchHotels(search *domain.HotelSearch) ([]domain.Hotel, error) {
+	filter, ranks, err := s.buildHotelFilter(search)
+	if err != nil {
+		return nil, err
+	}
+	hotels, _, err := s.findHotels(search, filter, ranks)
+	return hotels, err
+}
+
+/*
+SearchHotelsWithFacets
+Params: HotelSearch
+Returns: matching hotels with facet counts, error
+Description: Search like SearchHotels and count the hotels matching the same filters
+by facility, rating band and price bucket. When a text query matches more hotels than are shown,
+only the hotels shown are counted.
+*/
+func (s *hotelService) SearchHotelsWithFacets(search *domain.HotelSearch) (*domain.HotelSearchResult, error) {
+	filter, ranks, err := s.buildHotelFilter(search)
+	if err != nil {
+		return nil, err
+	}
+	hotels, limited, err := s.findHotels(search, filter, ranks)
+	if err != nil {
+		return nil, err
+	}
+	if limited {
+		shown := *filter
+		shown.Ids = make([]uuid.UUID, len(hotels))
+		for i := range hotels {
+			shown.Ids[i] = hotels[i].Id
+		}
+		filter = &shown
+	}
+	facets, err := s.hotelRepository.GetHotelFacets(filter, ratingBands, priceBucketEdges)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.HotelSearchResult{Hotels: hotels, Facets: facets}, nil
+}
+
+// findHotels loads the filtered hotels and orders them by relevance or distance, reporting whether
+// text matches beyond maxSearchMatches were left out
+func (s *hotelService) findHotels(search *domain.HotelSearch, filter *domain.HotelFilter, ranks map[uuid.UUID]float64) ([]domain.Hotel, bool, error) {
+	hotels, err := s.hotelRepository.SearchHotels(filter)
+	if err != nil {
+		return nil, false, err
+	}
+	limited := false
+	if ranks != nil {
+		for i := range hotels {
+			relevance := ranks[hotels[i].Id]
+			hotels[i].Relevance = &relevance
+		}
+		sort.SliceStable(hotels, func(i, j int) bool { return *hotels[i].Relevance > *hotels[j].Relevance })
+		// Only the most relevant text matches are shown, counted after every other filter has applied
+		if len(hotels) > maxSearchMatches {
+			hotels = hotels[:maxSearchMatches]
+			limited = true
+		}
+	}
+	if search.Lat == nil {
+		return hotels, limited, nil
+	}
+
+	for i := range hotels {
+		if hotels[i].Latitude != nil && hotels[i].Longitude != nil {
+			distance := math.Round(haversineKm(*search.Lat, *search.Lng, *hotels[i].Latitude, *hotels[i].Longitude)*100) / 100
+			hotels[i].DistanceKm = &distance
+		}
+	}
+	sort.SliceStable(hotels, func(i, j int) bool {
+		a, b := hotels[i].DistanceKm, hotels[j].DistanceKm
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return *a < *b
+	})
+	return hotels, limited, nil
+}
+
+/*
+GetPriceCalendar
+Params: hotel id, first and last date of the calendar
+Returns: the cheapest nightly rate and number of rooms that can be booked for an arrival on each date, error
+Description: A room counts on a date when the shortest stay arriving then passes the same checks as a booking:
+the room is free and not held by an open waitlist offer on every night, and the stay restrictions allow it.
+Dates are calendar days; the time of day is ignored.
+*/
+func (s *hotelService) GetPriceCalendar(hotelId string, from time.Time, to time.Time) ([]domain.PriceCalendarDay, error) {
+	from, to = truncateToDay(from), truncateToDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidCalendarRange)
+	}
+	if daysBetween(from, to) >= maxPriceCalendarDays {
+		return nil, fmt.Errorf("%w: the calendar cannot span more than %d days", ErrInvalidCalendarRange, maxPriceCalendarDays)
+	}
+	if _, err := s.hotelRepository.GetHotelById(hotelId); err != nil {
+		return nil, ErrHotelNotFound
+	}
+
+	// Minimum stays arriving late in the range need the nights and departure rules after it
+	var restrictions []domain.StayRestriction
+	lookahead := 1
+	if s.stayRestrictions != nil {
+		var err error
+		restrictions, err = s.stayRestrictions.GetRestrictions(hotelId, nil, from, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, restriction := range restrictions {
+			lookahead = max(lookahead, restriction.MinStay)
+		}
+		later, err := s.stayRestrictions.GetRestrictions(hotelId, nil, to.AddDate(0, 0, 1), to.AddDate(0, 0, lookahead))
+		if err != nil {
+			return nil, err
+		}
+		restrictions = append(restrictions, later...)
+	}
+
+	roomNights, err := s.hotelRepository.GetFreeRoomNights(hotelId, from, to.AddDate(0, 0, lookahead-1))
+	if err != nil {
+		return nil, err
+	}
+	return priceCalendar(from, to, roomNights, restrictions, time.Now()), nil
+}
+
+// priceCalendar counts, for each date, the rooms whose shortest allowed stay arriving that date is free and
+// passes checkStay, keeping the cheapest of their rates
+func priceCalendar(from time.Time, to time.Time, roomNights []domain.RoomNight, restrictions []domain.StayRestriction, now time.Time) []domain.PriceCalendarDay {
+	days := make([]domain.PriceCalendarDay, daysBetween(from, to)+1)
+	for i := range days {
+		days[i].Date = from.AddDate(0, 0, i)
+	}
+
+	free := make(map[uuid.UUID]map[time.Time]bool)
+	prices := make(map[uuid.UUID]float64)
+	for _, night := range roomNights {
+		if free[night.RoomId] == nil {
+			free[night.RoomId] = make(map[time.Time]bool)
+		}
+		free[night.RoomId][night.Date] = true
+		prices[night.RoomId] = night.Price
+	}
+
+	for roomId, nights := range free {
+		var rows []domain.StayRestriction
+		for _, restriction := range restrictions {
+			if restriction.RoomId == nil || *restriction.RoomId == roomId {
+				rows = append(rows, restriction)
+			}
+		}
+		rules := effectiveRestrictions(rows)
+
+		for i := range days {
+			arrival := days[i].Date
+			stay := 1
+			if rule, ok := rules[arrival]; ok && rule.MinStay > stay {
+				stay = rule.MinStay
+			}
+			bookable := true
+			for night := 0; night < stay && bookable; night++ {
+				bookable = nights[arrival.AddDate(0, 0, night)]
+			}
+			if !bookable || checkStay(rules, arrival, arrival.AddDate(0, 0, stay), now) != nil {
+				continue
+			}
+
+			price := prices[roomId]
+			days[i].AvailableRooms++
+			days[i].Sellable = true
+			if days[i].Price == nil || price < *days[i].Price {
+				days[i].Price = &price
+			}
+		}
+	}
+	return days
+}
+
+// buildHotelFilter validates the search and turns it into a database filter. The text query and radius
+// are resolved to hotel ids up front, returning the relevance of each text match.
+func (s *hotelService) buildHotelFilter(search *domain.HotelSearch) (*domain.HotelFilter, map[uuid.UUID]float64, error) {
+	if err := validateHotelSearch(search); err != nil {
+		return nil, nil, err
+	}
+
+	filter := &domain.HotelFilter{OrganizationId: search.OrganizationId, Bounds: search.Bounds, City: search.City, Country: search.Country}
+
+	var ranks map[uuid.UUID]float64
+	if query := strings.TrimSpace(search.Query); query != "" {
+		if s.textSearch == nil {
+			return nil, nil, fmt.Errorf("%w: text search is not available", ErrInvalidSearchQuery)
+		}
+		matches, err := s.textSearch.MatchHotels(query)
+		if err != nil {
+			return nil, nil, err
+		}
+		ranks = make(map[uuid.UUID]float64, len(matches))
+		filter.Ids = make([]uuid.UUID, 0, len(matches))
+		for _, match := range matches {
+			ranks[match.HotelId] = math.Round(match.Rank*10000) / 10000
+			filter.Ids = append(filter.Ids, match.HotelId)
+		}
+	}
+
+	if search.RadiusKm != nil {
+		// The bounding box over-selects at its corners, so the radius is checked exactly here
+		if filter.Bounds == nil {
+			filter.Bounds = boundsAround(*search.Lat, *search.Lng, *search.RadiusKm)
+		}
+		locations, err := s.hotelRepository.GetHotelLocations(filter)
+		if err != nil {
+			return nil, nil, err
+		}
+		filter.Ids = make([]uuid.UUID, 0, len(locations))
+		for _, hotel := range locations {
+			if haversineKm(*search.Lat, *search.Lng, *hotel.Latitude, *hotel.Longitude) <= *search.RadiusKm {
+				filter.Ids = append(filter.Ids, hotel.Id)
+			}
+		}
+	}
+
+	filter.Facilities = search.Facilities
+	filter.MinRating = search.MinRating
+	filter.MinPrice = search.MinPrice
+	filter.MaxPrice = search.MaxPrice
+	return filter, ranks, nil
+}
+
+func validateHotelSearch(search *domain.HotelSearch) error {
+	if (search.Lat == nil) != (search.Lng == nil) {
+		return fmt.Errorf("%w: lat and lng must be given together", ErrInvalidLocationSearch)
+	}
+	if search.Lat != nil && (*search.Lat < -90 || *search.Lat > 90 || *search.Lng < -180 || *search.Lng > 180) {
+		return fmt.Errorf("%w: lat must be between -90 and 90 and lng between -180 and 180", ErrInvalidLocationSearch)
+	}
+	if search.RadiusKm != nil {
+		if search.Lat == nil {
+			return fmt.Errorf("%w: radius_km needs lat and lng", ErrInvalidLocationSearch)
+		}
+		if *search.RadiusKm <= 0 || *search.RadiusKm > maxRadiusKm {
+			return fmt.Errorf("%w: radius_km must be greater than 0 and at most %d", ErrInvalidLocationSearch, maxRadiusKm)
+		}
+	}
+	if search.MinRating != nil && (*search.MinRating < 0 || *search.MinRating > 5) {
+		return fmt.Errorf("%w: min_rating must be between 0 and 5", ErrInvalidHotelFilter)
+	}
+	if (search.MinPrice != nil && *search.MinPrice < 0) || (search.MaxPrice != nil && *search.MaxPrice < 0) {
+		return fmt.Errorf("%w: prices cannot be negative", ErrInvalidHotelFilter)
+	}
+	if search.MinPrice != nil && search.MaxPrice != nil && *search.MinPrice >= *search.MaxPrice {
+		return fmt.Errorf("%w: min_price must be less than max_price", ErrInvalidHotelFilter)
+	}
+	if bounds := search.Bounds; bounds != nil {
+		if bounds.MinLat > bounds.MaxLat || bounds.MinLat < -90 || bounds.MaxLat > 90 ||
+			bounds.MinLng < -180 || bounds.MinLng > 180 || bounds.MaxLng < -180 || bounds.MaxLng > 180 {
+			return fmt.Errorf("%w: bounding box is out of range", ErrInvalidLocationSearch)
+		}
+	}
+	return nil
+}
+
+// boundsAround returns a box enclosing the circle, wrapping across the antimeridian when needed
+func boundsAround(lat float64, lng float64, radiusKm float64) *domain.GeoBounds {
+	deltaLat := radiusKm / kmPerDegreeLat
+	bounds := &domain.GeoBounds{
+		MinLat: math.Max(lat-deltaLat, -90),
+		MaxLat: math.Min(lat+deltaLat, 90),
+		MinLng: -180,
+		MaxLng: 180,
+	}
+
+	cosLat := math.Cos(lat * math.Pi / 180)
+	if bounds.MinLat == -90 || bounds.MaxLat == 90 || cosLat < 1e-6 {
+		return bounds
+	}
+	deltaLng := radiusKm / (kmPerDegreeLat * cosLat)
+	if deltaLng >= 180 {
+		return bounds
+	}
+	bounds.MinLng = wrapLongitude(lng - deltaLng)
+	bounds.MaxLng = wrapLongitude(lng + deltaLng)
+	return bounds
+}
+
+func wrapLongitude(lng float64) float64 {
+	if lng < -180 {
+		return lng + 360
+	}
+	if lng > 180 {
+		return lng - 360
+	}
+	return lng
+}
+
+// haversineKm is the great-circle distance between two points
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (lat2 - lat1) * toRad
+	dLng := (lng2 - lng1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(lat1*toRad)*math.Cos(lat2*toRad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(a)))
+}
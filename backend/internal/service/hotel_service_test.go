@@ -4,6 +4,7 @@ import (
 	"backend/internal/domain"
 	"backend/internal/repository"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -40,10 +41,18 @@ func TestHotelService_CreateHotel(t *testing.T) {
 			err = db.Exec(`
 				CREATE TABLE hotels (
 					id TEXT PRIMARY KEY,
+					organization_id TEXT,
 					name TEXT,
 					description TEXT,
 					address TEXT,
-					rating REAL
+					city TEXT,
+					country TEXT,
+					latitude REAL,
+					longitude REAL,
+					rating REAL,
+					review_count INTEGER DEFAULT 0,
+					no_show_cutoff_hours INTEGER,
+					no_show_penalty_nights INTEGER
 				);
 				CREATE TABLE rooms (
 					id TEXT PRIMARY KEY,
@@ -57,6 +66,21 @@ func TestHotelService_CreateHotel(t *testing.T) {
 					id TEXT PRIMARY KEY,
 					name TEXT
 				);
+				CREATE TABLE photos (
+					id TEXT PRIMARY KEY,
+					hotel_id TEXT,
+					room_id TEXT,
+					position INTEGER,
+					is_cover INTEGER DEFAULT 0,
+					content_type TEXT,
+					width INTEGER,
+					height INTEGER,
+					size INTEGER,
+					storage_key TEXT,
+					url TEXT,
+					thumbnails TEXT,
+					created_at DATETIME
+				);
 				CREATE TABLE room_facilities (
 					room_id TEXT,
 					facility_id TEXT,
@@ -66,7 +90,7 @@ func TestHotelService_CreateHotel(t *testing.T) {
 			assert.NoError(t, err)
 
 			repo := repository.NewHotelRepository(db)
-			service := NewHotelService(repo)
+			service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
 			err = service.CreateHotel(tt.hotel)
 
 			if tt.shouldError {
@@ -95,10 +119,18 @@ func TestHotelService_GetAllHotels(t *testing.T) {
 	err = db.Exec(`
 		CREATE TABLE hotels (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			name TEXT,
 			description TEXT,
 			address TEXT,
-			rating REAL
+			city TEXT,
+			country TEXT,
+			latitude REAL,
+			longitude REAL,
+			rating REAL,
+			review_count INTEGER DEFAULT 0,
+			no_show_cutoff_hours INTEGER,
+			no_show_penalty_nights INTEGER
 		);
 		CREATE TABLE rooms (
 			id TEXT PRIMARY KEY,
@@ -112,6 +144,21 @@ func TestHotelService_GetAllHotels(t *testing.T) {
 			id TEXT PRIMARY KEY,
 			name TEXT
 		);
+		CREATE TABLE photos (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			position INTEGER,
+			is_cover INTEGER DEFAULT 0,
+			content_type TEXT,
+			width INTEGER,
+			height INTEGER,
+			size INTEGER,
+			storage_key TEXT,
+			url TEXT,
+			thumbnails TEXT,
+			created_at DATETIME
+		);
 		CREATE TABLE room_facilities (
 			room_id TEXT,
 			facility_id TEXT,
@@ -121,7 +168,7 @@ func TestHotelService_GetAllHotels(t *testing.T) {
 	assert.NoError(t, err)
 
 	repo := repository.NewHotelRepository(db)
-	service := NewHotelService(repo)
+	service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
 
 	// Create test hotels
 	hotel1 := &domain.Hotel{
@@ -156,10 +203,18 @@ func TestHotelService_GetHotelById(t *testing.T) {
 	err = db.Exec(`
 		CREATE TABLE hotels (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			name TEXT,
 			description TEXT,
 			address TEXT,
-			rating REAL
+			city TEXT,
+			country TEXT,
+			latitude REAL,
+			longitude REAL,
+			rating REAL,
+			review_count INTEGER DEFAULT 0,
+			no_show_cutoff_hours INTEGER,
+			no_show_penalty_nights INTEGER
 		);
 		CREATE TABLE rooms (
 			id TEXT PRIMARY KEY,
@@ -173,6 +228,21 @@ func TestHotelService_GetHotelById(t *testing.T) {
 			id TEXT PRIMARY KEY,
 			name TEXT
 		);
+		CREATE TABLE photos (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			position INTEGER,
+			is_cover INTEGER DEFAULT 0,
+			content_type TEXT,
+			width INTEGER,
+			height INTEGER,
+			size INTEGER,
+			storage_key TEXT,
+			url TEXT,
+			thumbnails TEXT,
+			created_at DATETIME
+		);
 		CREATE TABLE room_facilities (
 			room_id TEXT,
 			facility_id TEXT,
@@ -182,7 +252,7 @@ func TestHotelService_GetHotelById(t *testing.T) {
 	assert.NoError(t, err)
 
 	repo := repository.NewHotelRepository(db)
-	service := NewHotelService(repo)
+	service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
 
 	hotel := &domain.Hotel{
 		Id:          uuid.New(),
@@ -237,10 +307,18 @@ func TestNewHotelService(t *testing.T) {
 	err = db.Exec(`
 		CREATE TABLE hotels (
 			id TEXT PRIMARY KEY,
+			organization_id TEXT,
 			name TEXT,
 			description TEXT,
 			address TEXT,
-			rating REAL
+			city TEXT,
+			country TEXT,
+			latitude REAL,
+			longitude REAL,
+			rating REAL,
+			review_count INTEGER DEFAULT 0,
+			no_show_cutoff_hours INTEGER,
+			no_show_penalty_nights INTEGER
 		);
 		CREATE TABLE rooms (
 			id TEXT PRIMARY KEY,
@@ -254,6 +332,21 @@ func TestNewHotelService(t *testing.T) {
 			id TEXT PRIMARY KEY,
 			name TEXT
 		);
+		CREATE TABLE photos (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			position INTEGER,
+			is_cover INTEGER DEFAULT 0,
+			content_type TEXT,
+			width INTEGER,
+			height INTEGER,
+			size INTEGER,
+			storage_key TEXT,
+			url TEXT,
+			thumbnails TEXT,
+			created_at DATETIME
+		);
 		CREATE TABLE room_facilities (
 			room_id TEXT,
 			facility_id TEXT,
@@ -263,8 +356,204 @@ func TestNewHotelService(t *testing.T) {
 	assert.NoError(t, err)
 
 	repo := repository.NewHotelRepository(db)
-	service := NewHotelService(repo)
+	service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
 
 	assert.NotNil(t, service)
 	assert.Equal(t, repo, service.(*hotelService).hotelRepository)
 }
+
+func TestHotelService_SearchHotels(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	repo := repository.NewHotelRepository(db)
+	service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
+
+	place := func(name string, lat, lng float64) *domain.Hotel {
+		return &domain.Hotel{Id: uuid.New(), Name: name, Latitude: &lat, Longitude: &lng}
+	}
+	for _, hotel := range []*domain.Hotel{
+		place("Ubud", -8.5069, 115.2625),
+		place("Kuta", -8.7222, 115.1723),
+		place("Sanur", -8.6882, 115.2613),
+		place("Lovina", -8.1587, 115.0255),
+		{Id: uuid.New(), Name: "Unmapped"},
+	} {
+		assert.NoError(t, repo.CreateHotel(hotel))
+	}
+
+	denpasarLat, denpasarLng := -8.6705, 115.2126
+	radius := 20.0
+	hotels, err := service.SearchHotels(&domain.HotelSearch{Lat: &denpasarLat, Lng: &denpasarLng, RadiusKm: &radius})
+	assert.NoError(t, err)
+	var names []string
+	for _, hotel := range hotels {
+		names = append(names, hotel.Name)
+		assert.NotNil(t, hotel.DistanceKm)
+		assert.LessOrEqual(t, *hotel.DistanceKm, radius)
+	}
+	assert.Equal(t, []string{"Sanur", "Kuta", "Ubud"}, names)
+
+	// Without a radius every hotel is returned, nearest first and unmapped hotels last
+	hotels, err = service.SearchHotels(&domain.HotelSearch{Lat: &denpasarLat, Lng: &denpasarLng})
+	assert.NoError(t, err)
+	assert.Len(t, hotels, 5)
+	assert.Equal(t, "Lovina", hotels[3].Name)
+	assert.Equal(t, "Unmapped", hotels[4].Name)
+	assert.Nil(t, hotels[4].DistanceKm)
+
+	invalid := []*domain.HotelSearch{
+		{Lat: &denpasarLat},
+		{RadiusKm: &radius},
+		{Lat: &radius, Lng: &radius, RadiusKm: &denpasarLat},
+		{Bounds: &domain.GeoBounds{MinLat: 10, MaxLat: -10}},
+	}
+	for _, search := range invalid {
+		_, err := service.SearchHotels(search)
+		assert.ErrorIs(t, err, ErrInvalidLocationSearch)
+	}
+}
+
+func TestHotelService_SearchHotelsWithFacets(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	repo := repository.NewHotelRepository(db)
+	service := NewHotelService(repo, repository.NewOrganizationRepository(db), nil, nil)
+
+	pool := &domain.Facility{Id: uuid.New(), Name: "Pool"}
+	spa := &domain.Facility{Id: uuid.New(), Name: "Spa"}
+	hotel := func(name string, city string, rating float64, prices []float64, facilities ...*domain.Facility) {
+		h := &domain.Hotel{Id: uuid.New(), Name: name, City: city}
+		for _, price := range prices {
+			h.Rooms = append(h.Rooms, &domain.Room{Id: uuid.New(), Price: price, Facilities: facilities})
+		}
+		assert.NoError(t, repo.CreateHotel(h))
+		assert.NoError(t, db.Exec("UPDATE hotels SET rating = ? WHERE id = ?", rating, h.Id.String()).Error)
+	}
+	hotel("Budget Inn", "Kuta", 3.2, []float64{40, 60})
+	hotel("Pool House", "Kuta", 4.1, []float64{90}, pool)
+	hotel("Spa Retreat", "Kuta", 4.7, []float64{250, 300}, pool, spa)
+	hotel("Far Away", "Lovina", 4.9, []float64{120}, pool)
+
+	result, err := service.SearchHotelsWithFacets(&domain.HotelSearch{City: "Kuta"})
+	assert.NoError(t, err)
+	assert.Len(t, result.Hotels, 3)
+	assert.Equal(t, []domain.FacetCount{{Value: "Pool", Count: 2}, {Value: "Spa", Count: 1}}, result.Facets.Facilities)
+	assert.Equal(t, []domain.RatingFacet{
+		{MinRating: 4.5, Count: 1}, {MinRating: 4, Count: 2}, {MinRating: 3.5, Count: 2}, {MinRating: 3, Count: 3},
+	}, result.Facets.Ratings)
+	priceCounts := map[float64]int64{}
+	for _, bucket := range result.Facets.Prices {
+		priceCounts[bucket.MinPrice] = bucket.Count
+	}
+	// Hotels are bucketed by their cheapest room
+	assert.Equal(t, map[float64]int64{0: 1, 50: 1, 100: 0, 200: 1, 400: 0}, priceCounts)
+	assert.Nil(t, result.Facets.Prices[len(result.Facets.Prices)-1].MaxPrice)
+
+	// Facility counts narrow with the facility filter; rating counts ignore the rating filter
+	minRating := 4.5
+	result, err = service.SearchHotelsWithFacets(&domain.HotelSearch{City: "Kuta", Facilities: []string{"pool"}, MinRating: &minRating})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Hotels, 1) {
+		assert.Equal(t, "Spa Retreat", result.Hotels[0].Name)
+	}
+	assert.Equal(t, []domain.FacetCount{{Value: "Pool", Count: 1}, {Value: "Spa", Count: 1}}, result.Facets.Facilities)
+	assert.Equal(t, int64(2), result.Facets.Ratings[1].Count)
+
+	// Price counts ignore the price filter
+	minPrice, maxPrice := 50.0, 100.0
+	result, err = service.SearchHotelsWithFacets(&domain.HotelSearch{City: "Kuta", MinPrice: &minPrice, MaxPrice: &maxPrice})
+	assert.NoError(t, err)
+	if assert.Len(t, result.Hotels, 1) {
+		assert.Equal(t, "Pool House", result.Hotels[0].Name)
+	}
+	assert.Equal(t, int64(1), result.Facets.Prices[0].Count)
+	assert.Equal(t, []domain.FacetCount{{Value: "Pool", Count: 1}}, result.Facets.Facilities)
+
+	_, err = service.SearchHotelsWithFacets(&domain.HotelSearch{MinPrice: &maxPrice, MaxPrice: &minPrice})
+	assert.ErrorIs(t, err, ErrInvalidHotelFilter)
+}
+
+func TestHotelService_GetPriceCalendar(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	service := NewHotelService(repository.NewHotelRepository(db), repository.NewOrganizationRepository(db), nil, nil)
+
+	hotelId, cheapRoom := uuid.New(), uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, cheapRoom, 100))
+	suiteRoom := &domain.Room{Id: uuid.New(), HotelId: hotelId, Price: 150, Available: true}
+	assert.NoError(t, db.Create(suiteRoom).Error)
+	closedRoom := &domain.Room{Id: uuid.New(), HotelId: hotelId, Price: 50}
+	assert.NoError(t, db.Create(closedRoom).Error)
+	assert.NoError(t, db.Model(closedRoom).Update("available", false).Error)
+
+	day := func(d int) time.Time { return time.Date(2030, 1, d, 0, 0, 0, 0, time.UTC) }
+	book := func(roomId uuid.UUID, checkIn time.Time, checkOut time.Time, cancelled bool) {
+		booking := &domain.Booking{Id: uuid.New(), UserId: uuid.New(), HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut}
+		assert.NoError(t, db.Create(booking).Error)
+		if cancelled {
+			assert.NoError(t, db.Model(booking).Update("is_cancelled", true).Error)
+		}
+	}
+	// The cheap room is taken for the nights of the 2nd and 3rd; times of day do not shift the nights
+	book(cheapRoom, day(2).Add(14*time.Hour), day(4).Add(11*time.Hour), false)
+	book(suiteRoom.Id, day(3), day(4), false)
+	book(suiteRoom.Id, day(1), day(2), true)
+
+	calendar, err := service.GetPriceCalendar(hotelId.String(), day(1), day(4).Add(9*time.Hour))
+	assert.NoError(t, err)
+	if !assert.Len(t, calendar, 4) {
+		return
+	}
+	price := func(p float64) *float64 { return &p }
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(1), Price: price(100), AvailableRooms: 2, Sellable: true}, calendar[0])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(2), Price: price(150), AvailableRooms: 1, Sellable: true}, calendar[1])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(3)}, calendar[2])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(4), Price: price(100), AvailableRooms: 2, Sellable: true}, calendar[3])
+
+	_, err = service.GetPriceCalendar(hotelId.String(), day(4), day(1))
+	assert.ErrorIs(t, err, ErrInvalidCalendarRange)
+	_, err = service.GetPriceCalendar(hotelId.String(), day(1), day(1).AddDate(1, 1, 0))
+	assert.ErrorIs(t, err, ErrInvalidCalendarRange)
+	_, err = service.GetPriceCalendar(uuid.New().String(), day(1), day(2))
+	assert.ErrorIs(t, err, ErrHotelNotFound)
+}
+
+func TestHotelService_PriceCalendarAppliesBookingRules(t *testing.T) {
+	db := setupStayRestrictionServiceTestDB(t)
+	hotelRepo := repository.NewHotelRepository(db)
+	restrictions := NewStayRestrictionService(repository.NewStayRestrictionRepository(db), hotelRepo)
+	service := NewHotelService(hotelRepo, repository.NewOrganizationRepository(db), nil, restrictions)
+
+	hotelId, cheapRoom := uuid.New(), uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, cheapRoom, 100))
+	suiteRoom := &domain.Room{Id: uuid.New(), HotelId: hotelId, Price: 150, Available: true}
+	assert.NoError(t, db.Create(suiteRoom).Error)
+
+	day := func(d int) time.Time { return time.Date(2030, 1, d, 0, 0, 0, 0, time.UTC) }
+	restrict := func(request domain.SetStayRestrictionsRequest) {
+		_, err := restrictions.SetRestrictions(hotelId.String(), &request)
+		assert.NoError(t, err)
+	}
+	// Nobody arrives on the 1st or leaves on the 7th
+	restrict(domain.SetStayRestrictionsRequest{From: day(1), To: day(1), ClosedToArrival: true})
+	restrict(domain.SetStayRestrictionsRequest{From: day(7), To: day(7), ClosedToDeparture: true})
+	// The cheap room needs two nights from the 2nd, but is booked on the night of the 3rd
+	restrict(domain.SetStayRestrictionsRequest{RoomId: &cheapRoom, From: day(2), To: day(2), MinStay: 2})
+	assert.NoError(t, db.Create(&domain.Booking{Id: uuid.New(), UserId: uuid.New(), HotelId: hotelId, RoomId: cheapRoom, CheckInDate: day(3), CheckOutDate: day(4)}).Error)
+	// The suite is held for a waitlist offer on the night of the 4th
+	expiresAt := time.Now().Add(time.Hour)
+	assert.NoError(t, db.Create(&domain.WaitlistEntry{
+		Id: uuid.New(), UserId: uuid.New(), HotelId: hotelId, RoomId: suiteRoom.Id, CheckInDate: day(4), CheckOutDate: day(5),
+		Status: domain.WaitlistStatusOffered, OfferExpiresAt: &expiresAt,
+	}).Error)
+
+	calendar, err := service.GetPriceCalendar(hotelId.String(), day(1), day(6))
+	assert.NoError(t, err)
+	if !assert.Len(t, calendar, 6) {
+		return
+	}
+	price := func(p float64) *float64 { return &p }
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(1)}, calendar[0])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(2), Price: price(150), AvailableRooms: 1, Sellable: true}, calendar[1])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(3), Price: price(150), AvailableRooms: 1, Sellable: true}, calendar[2])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(4), Price: price(100), AvailableRooms: 1, Sellable: true}, calendar[3])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(5), Price: price(100), AvailableRooms: 2, Sellable: true}, calendar[4])
+	assert.Equal(t, domain.PriceCalendarDay{Date: day(6)}, calendar[5])
+}
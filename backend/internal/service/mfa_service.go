@@ -0,0 +1,212 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	recoveryCodeCount = 10
+	// totpSkew is how many time steps either side of now a code is accepted for
+	totpSkew = 1
+)
+
+var (
+	ErrMFAAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrMFANotEnrolled    = errors.New("start two-factor enrollment first")
+	ErrMFANotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrInvalidMFACode    = errors.New("invalid two-factor code")
+)
+
+type MFAService interface {
+	GetStatus(userId uuid.UUID) (*domain.MFAStatus, error)
+	Enroll(userId uuid.UUID) (*domain.MFAEnrollmentResponse, error)
+	Confirm(userId uuid.UUID, code string) (*domain.MFARecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(userId uuid.UUID, code string) (*domain.MFARecoveryCodesResponse, error)
+	Disable(userId uuid.UUID, code string) error
+	IsEnabled(userId uuid.UUID) (bool, error)
+	VerifyCode(userId uuid.UUID, code string) error
+}
+
+type mfaService struct {
+	mfaRepository  repository.MFARepository
+	userRepository repository.UserRepository
+	// issuer names this service in authenticator apps
+	issuer string
+}
+
+func NewMFAService(mfaRepository repository.MFARepository, userRepository repository.UserRepository, issuer string) MFAService {
+	return &mfaService{mfaRepository: mfaRepository, userRepository: userRepository, issuer: issuer}
+}
+
+func (s *mfaService) GetStatus(userId uuid.UUID) (*domain.MFAStatus, error) {
+	status := &domain.MFAStatus{}
+	enrollment, err := s.mfaRepository.GetEnrollment(userId.String())
+	if err != nil {
+		return status, nil
+	}
+	status.Enabled = enrollment.ConfirmedAt != nil
+	status.Pending = !status.Enabled
+	if status.Enabled {
+		if status.RecoveryCodesLeft, err = s.mfaRepository.CountUnusedRecoveryCodes(userId.String()); err != nil {
+			return nil, err
+		}
+	}
+	return status, nil
+}
+
+/*
+Enroll
+Params: id of the signed-in user
+Returns: the new TOTP secret and the otpauth URI for adding it to an authenticator app, error
+Description: Start two-factor enrollment with a new secret, replacing any enrollment that was started but
+never confirmed. Two-factor login only turns on once Confirm is sent a code generated from the secret.
+*/
+func (s *mfaService) Enroll(userId uuid.UUID) (*domain.MFAEnrollmentResponse, error) {
+	user, err := s.userRepository.GetUserById(userId.String())
+	if err != nil {
+		return nil, err
+	}
+	enabled, err := s.mfaRepository.IsEnabled(userId.String())
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, ErrMFAAlreadyEnabled
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepository.SaveEnrollment(&domain.MFAEnrollment{UserId: user.Id, Secret: secret}); err != nil {
+		return nil, err
+	}
+	return &domain.MFAEnrollmentResponse{Secret: secret, OTPAuthURI: auth.TOTPURI(s.issuer, user.Email, secret)}, nil
+}
+
+// Confirm turns two-factor login on once the user proves their app generates the right codes, and returns their recovery codes
+func (s *mfaService) Confirm(userId uuid.UUID, code string) (*domain.MFARecoveryCodesResponse, error) {
+	enrollment, err := s.mfaRepository.GetEnrollment(userId.String())
+	if err != nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if enrollment.ConfirmedAt != nil {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	step, ok := auth.MatchTOTP(enrollment.Secret, normalizeMFACode(code), time.Now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidMFACode
+	}
+
+	codes, records, err := newRecoveryCodes(userId)
+	if err != nil {
+		return nil, err
+	}
+	confirmed, err := s.mfaRepository.ConfirmEnrollment(userId.String(), step, time.Now(), records)
+	if err != nil {
+		return nil, err
+	}
+	if !confirmed {
+		return nil, ErrMFAAlreadyEnabled
+	}
+	return &domain.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking a code from their authenticator app
+func (s *mfaService) RegenerateRecoveryCodes(userId uuid.UUID, code string) (*domain.MFARecoveryCodesResponse, error) {
+	if err := s.verifyTOTP(userId, code); err != nil {
+		return nil, err
+	}
+	codes, records, err := newRecoveryCodes(userId)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.mfaRepository.ReplaceRecoveryCodes(userId.String(), records); err != nil {
+		return nil, err
+	}
+	return &domain.MFARecoveryCodesResponse{RecoveryCodes: codes}, nil
+}
+
+// Disable turns two-factor login off after checking a code from the authenticator app or a recovery code
+func (s *mfaService) Disable(userId uuid.UUID, code string) error {
+	if err := s.VerifyCode(userId, code); err != nil {
+		return err
+	}
+	return s.mfaRepository.DeleteEnrollment(userId.String())
+}
+
+func (s *mfaService) IsEnabled(userId uuid.UUID) (bool, error) {
+	return s.mfaRepository.IsEnabled(userId.String())
+}
+
+/*
+VerifyCode
+Params: user id, a code from the authenticator app or one of the user's recovery codes
+Returns: error
+Description: Accept the code once. A TOTP code is refused when a code of the same or a later time step was
+already accepted, so a code seen over someone's shoulder cannot be replayed; a recovery code is used up.
+*/
+func (s *mfaService) VerifyCode(userId uuid.UUID, code string) error {
+	err := s.verifyTOTP(userId, code)
+	if !errors.Is(err, ErrInvalidMFACode) {
+		return err
+	}
+
+	used, err := s.mfaRepository.UseRecoveryCode(userId.String(), hashToken(normalizeMFACode(code)), time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+func (s *mfaService) verifyTOTP(userId uuid.UUID, code string) error {
+	enrollment, err := s.mfaRepository.GetEnrollment(userId.String())
+	if err != nil || enrollment.ConfirmedAt == nil {
+		return ErrMFANotEnabled
+	}
+	step, ok := auth.MatchTOTP(enrollment.Secret, normalizeMFACode(code), time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	used, err := s.mfaRepository.UseTOTPStep(userId.String(), step)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidMFACode
+	}
+	return nil
+}
+
+// newRecoveryCodes makes a set of recovery codes and the records that keep their hashes
+func newRecoveryCodes(userId uuid.UUID) ([]string, []domain.MFARecoveryCode, error) {
+	codes := make([]string, recoveryCodeCount)
+	records := make([]domain.MFARecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		buf := make([]byte, 5)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, err
+		}
+		code := hex.EncodeToString(buf)
+		codes[i] = code[:5] + "-" + code[5:]
+		records[i] = domain.MFARecoveryCode{Id: uuid.New(), UserId: userId, CodeHash: hashToken(code)}
+	}
+	return codes, records, nil
+}
+
+// normalizeMFACode drops the spaces and dashes people type codes with
+func normalizeMFACode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(code)))
+}
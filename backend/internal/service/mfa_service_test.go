@@ -0,0 +1,130 @@
+package service
+
+import (
+	"backend/internal/auth"
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newMFATestService(db *gorm.DB) MFAService {
+	return NewMFAService(repository.NewMFARepository(db), repository.NewUserRepository(db), "Hotel Booking")
+}
+
+func createMFATestUser(t *testing.T, db *gorm.DB) uuid.UUID {
+	user := &domain.User{Username: "staff", Email: "staff@example.com", Password: "hash"}
+	if err := repository.NewUserRepository(db).CreateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user.Id
+}
+
+// totpCode is the code of the secret offset time steps from now
+func totpCode(t *testing.T, secret string, offset int64) string {
+	code, err := auth.TOTPCode(secret, auth.TOTPStep(time.Now())+offset)
+	if err != nil {
+		t.Fatalf("Failed to compute code: %v", err)
+	}
+	return code
+}
+
+// enableMFA enrolls the user and confirms with the current code, so only the next step's code is still usable
+func enableMFA(t *testing.T, mfa MFAService, userId uuid.UUID) (string, []string) {
+	enrollment, err := mfa.Enroll(userId)
+	if err != nil {
+		t.Fatalf("Failed to enroll: %v", err)
+	}
+	confirmed, err := mfa.Confirm(userId, totpCode(t, enrollment.Secret, 0))
+	if err != nil {
+		t.Fatalf("Failed to confirm: %v", err)
+	}
+	return enrollment.Secret, confirmed.RecoveryCodes
+}
+
+func TestMFAService_EnrollAndConfirm(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mfa := newMFATestService(db)
+	userId := createMFATestUser(t, db)
+
+	_, err := mfa.Confirm(userId, "123456")
+	assert.ErrorIs(t, err, ErrMFANotEnrolled)
+
+	enrollment, err := mfa.Enroll(userId)
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(enrollment.OTPAuthURI, "otpauth://totp/Hotel%20Booking:staff@example.com?"))
+	assert.Contains(t, enrollment.OTPAuthURI, "secret="+enrollment.Secret)
+
+	status, err := mfa.GetStatus(userId)
+	assert.NoError(t, err)
+	assert.True(t, status.Pending)
+	assert.False(t, status.Enabled)
+
+	// Enrolling again before confirming replaces the secret
+	replaced, err := mfa.Enroll(userId)
+	assert.NoError(t, err)
+	assert.NotEqual(t, enrollment.Secret, replaced.Secret)
+	_, err = mfa.Confirm(userId, totpCode(t, enrollment.Secret, 0))
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+
+	confirmed, err := mfa.Confirm(userId, totpCode(t, replaced.Secret, 0))
+	assert.NoError(t, err)
+	assert.Len(t, confirmed.RecoveryCodes, recoveryCodeCount)
+
+	status, err = mfa.GetStatus(userId)
+	assert.NoError(t, err)
+	assert.True(t, status.Enabled)
+	assert.Equal(t, int64(recoveryCodeCount), status.RecoveryCodesLeft)
+
+	_, err = mfa.Enroll(userId)
+	assert.ErrorIs(t, err, ErrMFAAlreadyEnabled)
+}
+
+func TestMFAService_CodesCannotBeReplayed(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mfa := newMFATestService(db)
+	userId := createMFATestUser(t, db)
+	secret, _ := enableMFA(t, mfa, userId)
+
+	// The code that confirmed the enrollment is used up, as is every earlier one
+	assert.ErrorIs(t, mfa.VerifyCode(userId, totpCode(t, secret, 0)), ErrInvalidMFACode)
+	assert.ErrorIs(t, mfa.VerifyCode(userId, totpCode(t, secret, -1)), ErrInvalidMFACode)
+
+	next := totpCode(t, secret, 1)
+	assert.NoError(t, mfa.VerifyCode(userId, next[:3]+" "+next[3:]))
+	assert.ErrorIs(t, mfa.VerifyCode(userId, next), ErrInvalidMFACode)
+}
+
+func TestMFAService_RecoveryCodes(t *testing.T) {
+	db := setupAuthServiceTestDB(t)
+	mfa := newMFATestService(db)
+	userId := createMFATestUser(t, db)
+	secret, codes := enableMFA(t, mfa, userId)
+
+	assert.NoError(t, mfa.VerifyCode(userId, strings.ToUpper(codes[0])))
+	assert.ErrorIs(t, mfa.VerifyCode(userId, codes[0]), ErrInvalidMFACode)
+	status, err := mfa.GetStatus(userId)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(recoveryCodeCount-1), status.RecoveryCodesLeft)
+
+	// Regenerating needs a code from the app, not a recovery code, and replaces the old codes
+	_, err = mfa.RegenerateRecoveryCodes(userId, codes[1])
+	assert.ErrorIs(t, err, ErrInvalidMFACode)
+	regenerated, err := mfa.RegenerateRecoveryCodes(userId, totpCode(t, secret, 1))
+	assert.NoError(t, err)
+	if !assert.Len(t, regenerated.RecoveryCodes, recoveryCodeCount) {
+		return
+	}
+	assert.ErrorIs(t, mfa.VerifyCode(userId, codes[1]), ErrInvalidMFACode)
+
+	assert.NoError(t, mfa.Disable(userId, regenerated.RecoveryCodes[0]))
+	enabled, err := mfa.IsEnabled(userId)
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+	assert.ErrorIs(t, mfa.VerifyCode(userId, regenerated.RecoveryCodes[1]), ErrMFANotEnabled)
+}
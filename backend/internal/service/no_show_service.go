@@ -0,0 +1,171 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+const defaultNoShowCutoff = 24 * time.Hour
+
+type NoShowService interface {
+	ProcessNoShows(now time.Time) error
+}
+
+type noShowService struct {
+	bookingRepository repository.BookingRepository
+	hotelRepository   repository.HotelRepository
+	payments          PaymentService
+	audit             AuditService
+	inventoryListener InventoryListener
+}
+
+// NewNoShowService creates a no-show service. inventoryListener may be nil.
+func NewNoShowService(bookingRepository repository.BookingRepository, hotelRepository repository.HotelRepository, payments PaymentService, audit AuditService, inventoryListener InventoryListener) NoShowService {
+	return &noShowService{
+		bookingRepository: bookingRepository,
+		hotelRepository:   hotelRepository,
+		payments:          payments,
+		audit:             audit,
+		inventoryListener: inventoryListener,
+	}
+}
+
+/*
+ProcessNoShows
+Params: current time
+Returns: error
+Description: Mark confirmed bookings that were not checked in by their hotel's cutoff as no-shows,
+give the room back to inventory, charge the hotel's no-show penalty and record an audit entry.
+A booking that cannot be processed, or whose hotel cannot be loaded, is skipped and picked up again by the
+next run. The sweep carries on past it and returns the errors it met together.
+*/
+func (s *noShowService) ProcessNoShows(now time.Time) error {
+	bookings, err := s.bookingRepository.GetUnarrivedBookings(now)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	hotels := make(map[string]*domain.Hotel)
+	for i := range bookings {
+		booking := &bookings[i]
+
+		hotelId := booking.HotelId.String()
+		hotel, ok := hotels[hotelId]
+		if !ok {
+			hotel, err = s.hotelRepository.GetHotelById(hotelId)
+			if err != nil {
+				log.Printf("Skipping no-show check for booking %s, hotel %s could not be loaded: %v", booking.Id, hotelId, err)
+				continue
+			}
+			hotels[hotelId] = hotel
+		}
+
+		policy := noShowPolicy(hotel)
+		cutoff := noShowCutoff(policy)
+		if now.Before(booking.CheckInDate.Add(cutoff)) {
+			continue
+		}
+		if err := s.markNoShow(booking, policy, cutoff); err != nil {
+			log.Printf("Error marking booking %s as a no-show: %v", booking.Id, err)
+			errs = append(errs, fmt.Errorf("booking %s: %w", booking.Id, err))
+			continue
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// markNoShow charges the penalty before the booking leaves the confirmed state, so a charge that could not
+// be recorded leaves the booking for the next run to retry. A penalty already recorded for the booking, by an
+// earlier run that failed to save the no-show, is not charged again.
+func (s *noShowService) markNoShow(booking *domain.Booking, policy domain.NoShowPolicy, cutoff time.Duration) error {
+	details := fmt.Sprintf("not checked in within %s of check-in", cutoff)
+	penalty := noShowPenalty(booking, policy.PenaltyNights)
+	if penalty > 0 {
+		payment, err := s.chargedNoShowPenalty(booking)
+		if err != nil {
+			return err
+		}
+		if payment == nil {
+			payment, err = s.payments.ChargeBooking(booking, domain.PaymentKindNoShowPenalty, penalty, "No-show penalty")
+			switch {
+			case errors.Is(err, ErrPaymentFailed):
+				// The failed attempt is recorded with the payment; the no-show itself stands
+				log.Printf("No-show penalty for booking %s was declined: %v", booking.Id, err)
+			case err != nil:
+				return err
+			}
+		}
+		if payment.Status == domain.PaymentStatusFailed {
+			details += fmt.Sprintf("; penalty %.2f declined (payment %s)", payment.Amount, payment.Id)
+		} else {
+			details += fmt.Sprintf("; penalty %.2f %s (payment %s)", payment.Amount, payment.Status, payment.Id)
+		}
+	}
+
+	booking.Status = domain.BookingStatusNoShow
+	if err := s.bookingRepository.UpdateBooking(booking); err != nil {
+		return err
+	}
+	if s.inventoryListener != nil {
+		s.inventoryListener.InventoryReleased(booking)
+	}
+
+	return s.audit.Record(nil, AuditActionBookingNoShow, AuditEntityBooking, booking.Id, details)
+}
+
+// chargedNoShowPenalty returns the no-show penalty already charged for the booking, or nil when there is none
+func (s *noShowService) chargedNoShowPenalty(booking *domain.Booking) (*domain.Payment, error) {
+	payments, err := s.payments.GetPaymentsByBookingId(booking.Id.String())
+	if err != nil {
+		return nil, err
+	}
+	for i := range payments {
+		if payments[i].Kind == domain.PaymentKindNoShowPenalty {
+			return &payments[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// noShowPolicy is the hotel's own policy, with unset values taken from its organization's defaults.
+// A value the hotel sets, even 0, wins over the default, so a hotel can opt out of its organization's penalty.
+func noShowPolicy(hotel *domain.Hotel) domain.NoShowPolicy {
+	policy := hotel.NoShow
+	if hotel.Organization != nil {
+		defaults := hotel.Organization.Settings.DefaultNoShow
+		if policy.CutoffHours == nil {
+			policy.CutoffHours = defaults.CutoffHours
+		}
+		if policy.PenaltyNights == nil {
+			policy.PenaltyNights = defaults.PenaltyNights
+		}
+	}
+	return policy
+}
+
+func noShowCutoff(policy domain.NoShowPolicy) time.Duration {
+	if policy.CutoffHours == nil || *policy.CutoffHours < 0 {
+		return defaultNoShowCutoff
+	}
+	return time.Duration(*policy.CutoffHours) * time.Hour
+}
+
+// noShowPenalty charges the booked nightly room rate for up to penaltyNights nights; add-ons are not charged
+func noShowPenalty(booking *domain.Booking, penaltyNights *int) float64 {
+	nights := booking.CheckOutDate.Sub(booking.CheckInDate).Hours() / 24
+	if penaltyNights == nil || *penaltyNights <= 0 || nights <= 0 {
+		return 0
+	}
+
+	roomAmount := booking.TotalPrice
+	for _, line := range booking.AddOns {
+		roomAmount -= line.TotalPrice
+	}
+	return roomAmount / nights * math.Min(float64(*penaltyNights), nights)
+}
@@ -0,0 +1,260 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupNoShowServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupWaitlistServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE payments (
+			id TEXT PRIMARY KEY,
+			booking_id TEXT,
+			user_id TEXT,
+			kind TEXT,
+			amount REAL,
+			status TEXT,
+			reference TEXT,
+			description TEXT,
+			created_at DATETIME
+		);
+		CREATE TABLE audit_entries (
+			id TEXT PRIMARY KEY,
+			actor_id TEXT,
+			action TEXT,
+			entity_type TEXT,
+			entity_id TEXT,
+			details TEXT,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+type declineGateway struct{}
+
+func (declineGateway) Charge(userId uuid.UUID, amount float64, description string) (string, error) {
+	return "", errors.New("card declined")
+}
+
+func newNoShowTestServices(db *gorm.DB, gateway PaymentGateway) (NoShowService, BookingService, PaymentService, AuditService) {
+	hotelRepo := repository.NewHotelRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
+	waitlist := NewWaitlistService(repository.NewWaitlistRepository(db), hotelRepo, bookingRepo, repository.NewUserRepository(db), &recordingMailer{}, "http://app.test", 30*time.Minute)
+	payments := NewPaymentService(repository.NewPaymentRepository(db), gateway)
+	audit := NewAuditService(repository.NewAuditRepository(db))
+	noShows := NewNoShowService(bookingRepo, hotelRepo, payments, audit, waitlist)
+	return noShows, NewBookingService(hotelRepo, bookingRepo, nil, nil, waitlist, nil), payments, audit
+}
+
+func createNoShowTestBooking(t *testing.T, bookings BookingService, hotelId, roomId uuid.UUID, checkIn time.Time, nights int) *domain.Booking {
+	userId := uuid.New()
+	err := bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: userId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, nights),
+	})
+	assert.NoError(t, err)
+	created, err := bookings.GetBookingsByUserId(userId.String())
+	assert.NoError(t, err)
+	return &created[0]
+}
+
+func TestNoShowService_ProcessNoShows(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", hotelId).
+		Updates(map[string]interface{}{"no_show_cutoff_hours": 6, "no_show_penalty_nights": 1}).Error)
+	noShows, bookings, payments, audit := newNoShowTestServices(db, nil)
+
+	now := time.Now()
+	roomA, roomB, roomC := uuid.New(), uuid.New(), uuid.New()
+	for _, roomId := range []uuid.UUID{roomA, roomB, roomC} {
+		assert.NoError(t, db.Create(&domain.Room{Id: roomId, HotelId: hotelId, Price: 100, Available: true}).Error)
+	}
+
+	missed := createNoShowTestBooking(t, bookings, hotelId, roomA, now.Add(-8*time.Hour), 3)
+	arrived := createNoShowTestBooking(t, bookings, hotelId, roomB, now.Add(-8*time.Hour), 3)
+	_, err := bookings.CheckIn(arrived.Id.String(), now)
+	assert.NoError(t, err)
+	withinCutoff := createNoShowTestBooking(t, bookings, hotelId, roomC, now.Add(-2*time.Hour), 3)
+
+	assert.NoError(t, noShows.ProcessNoShows(now))
+
+	missed, err = bookings.GetBookingById(missed.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, missed.Status)
+
+	arrived, err = bookings.GetBookingById(arrived.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusCheckedIn, arrived.Status)
+
+	withinCutoff, err = bookings.GetBookingById(withinCutoff.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusConfirmed, withinCutoff.Status)
+
+	// One night of the room rate is charged
+	charged, err := payments.GetPaymentsByBookingId(missed.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, charged, 1)
+	assert.Equal(t, domain.PaymentKindNoShowPenalty, charged[0].Kind)
+	assert.Equal(t, domain.PaymentStatusPending, charged[0].Status)
+	assert.InDelta(t, 100.0, charged[0].Amount, 0.001)
+
+	entries, err := audit.GetEntriesByEntity(AuditEntityBooking, missed.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, AuditActionBookingNoShow, entries[0].Action)
+	assert.Nil(t, entries[0].ActorId)
+
+	// The remaining nights are sellable again
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomA, CheckInDate: now.AddDate(0, 0, 1), CheckOutDate: now.AddDate(0, 0, 2),
+	})
+	assert.NoError(t, err)
+
+	// Running the job again does not charge twice
+	assert.NoError(t, noShows.ProcessNoShows(now))
+	charged, err = payments.GetPaymentsByBookingId(missed.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, charged, 1)
+}
+
+func TestNoShowService_DeclinedPenaltyIsRecorded(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 80.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", hotelId).Update("no_show_penalty_nights", 5).Error)
+	noShows, bookings, payments, _ := newNoShowTestServices(db, declineGateway{})
+
+	// The default cutoff is 24 hours
+	now := time.Now()
+	booking := createNoShowTestBooking(t, bookings, hotelId, roomId, now.Add(-25*time.Hour), 2)
+
+	assert.NoError(t, noShows.ProcessNoShows(now))
+
+	booking, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, booking.Status)
+
+	// The penalty never exceeds the booked nights
+	charged, err := payments.GetPaymentsByBookingId(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, charged, 1)
+	assert.Equal(t, domain.PaymentStatusFailed, charged[0].Status)
+	assert.InDelta(t, 160.0, charged[0].Amount, 0.001)
+}
+
+func TestNoShowService_UnrecordedPenaltyIsRetried(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", hotelId).Update("no_show_penalty_nights", 1).Error)
+	noShows, bookings, payments, _ := newNoShowTestServices(db, nil)
+
+	now := time.Now()
+	booking := createNoShowTestBooking(t, bookings, hotelId, roomId, now.Add(-25*time.Hour), 2)
+
+	// The penalty cannot be recorded, so the booking stays confirmed for the next run
+	assert.NoError(t, db.Exec("ALTER TABLE payments RENAME TO payments_offline").Error)
+	assert.Error(t, noShows.ProcessNoShows(now))
+	unchanged, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusConfirmed, unchanged.Status)
+
+	assert.NoError(t, db.Exec("ALTER TABLE payments_offline RENAME TO payments").Error)
+	assert.NoError(t, noShows.ProcessNoShows(now))
+	marked, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, marked.Status)
+	charged, err := payments.GetPaymentsByBookingId(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, charged, 1)
+}
+
+func TestNoShowService_AlreadyChargedPenaltyIsNotChargedAgain(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", hotelId).Update("no_show_penalty_nights", 1).Error)
+	noShows, bookings, payments, _ := newNoShowTestServices(db, nil)
+
+	now := time.Now()
+	booking := createNoShowTestBooking(t, bookings, hotelId, roomId, now.Add(-25*time.Hour), 2)
+	// An earlier run charged the penalty but failed to save the no-show
+	_, err := payments.ChargeBooking(booking, domain.PaymentKindNoShowPenalty, 100, "No-show penalty")
+	assert.NoError(t, err)
+
+	assert.NoError(t, noShows.ProcessNoShows(now))
+	marked, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, marked.Status)
+	charged, err := payments.GetPaymentsByBookingId(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, charged, 1)
+}
+
+func TestNoShowService_MissingHotelDoesNotStopTheRun(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	noShows, bookings, _, _ := newNoShowTestServices(db, nil)
+
+	now := time.Now()
+	orphan := &domain.Booking{
+		Id: uuid.New(), UserId: uuid.New(), HotelId: uuid.New(), RoomId: uuid.New(),
+		CheckInDate: now.Add(-50 * time.Hour), CheckOutDate: now, Status: domain.BookingStatusConfirmed,
+	}
+	assert.NoError(t, db.Create(orphan).Error)
+	booking := createNoShowTestBooking(t, bookings, hotelId, roomId, now.Add(-25*time.Hour), 2)
+
+	assert.NoError(t, noShows.ProcessNoShows(now))
+	marked, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, marked.Status)
+}
+
+func TestNoShowService_FailedBookingDoesNotStopTheRun(t *testing.T) {
+	db := setupNoShowServiceTestDB(t)
+	penaltyHotelId := uuid.New()
+	penaltyRoomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, penaltyHotelId, penaltyRoomId, 100.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", penaltyHotelId).Update("no_show_penalty_nights", 1).Error)
+	freeHotelId := uuid.New()
+	freeRoomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, freeHotelId, freeRoomId, 100.0))
+	assert.NoError(t, db.Model(&domain.Hotel{}).Where("id = ?", freeHotelId).Update("no_show_penalty_nights", 0).Error)
+	noShows, bookings, _, _ := newNoShowTestServices(db, nil)
+
+	now := time.Now()
+	failing := createNoShowTestBooking(t, bookings, penaltyHotelId, penaltyRoomId, now.Add(-50*time.Hour), 2)
+	booking := createNoShowTestBooking(t, bookings, freeHotelId, freeRoomId, now.Add(-25*time.Hour), 2)
+
+	// The first booking's penalty cannot be recorded; the run still marks the second and reports the failure
+	assert.NoError(t, db.Exec("ALTER TABLE payments RENAME TO payments_offline").Error)
+	err := noShows.ProcessNoShows(now)
+	assert.ErrorContains(t, err, failing.Id.String())
+
+	unchanged, err := bookings.GetBookingById(failing.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusConfirmed, unchanged.Status)
+	marked, err := bookings.GetBookingById(booking.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.BookingStatusNoShow, marked.Status)
+}
@@ -0,0 +1,198 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultOrganizationCurrency = "USD"
+	maxReportRangeDays          = 366
+)
+
+var (
+	ErrOrganizationNotFound  = errors.New("organization not found")
+	ErrOrganizationNameTaken = errors.New("an organization with this name already exists")
+	ErrHotelAlreadyInPlace   = errors.New("hotel already belongs to this organization")
+	ErrInvalidReportRange    = errors.New("invalid report range")
+)
+
+type OrganizationService interface {
+	CreateOrganization(request *domain.OrganizationRequest) (*domain.Organization, error)
+	UpdateOrganization(id string, request *domain.OrganizationRequest) (*domain.Organization, error)
+	GetOrganizationById(id string) (*domain.Organization, error)
+	GetAllOrganizations() ([]domain.Organization, error)
+	GetOrganizationHotels(id string) ([]domain.Hotel, error)
+	MoveHotel(hotelId string, request *domain.MoveHotelRequest, actorId *uuid.UUID) (*domain.HotelOrganizationChange, error)
+	GetHotelOrganizationHistory(hotelId string) ([]domain.HotelOrganizationChange, error)
+	GetReport(id string, from time.Time, to time.Time) (*domain.OrganizationReport, error)
+}
+
+type organizationService struct {
+	organizationRepository repository.OrganizationRepository
+	hotelRepository        repository.HotelRepository
+}
+
+func NewOrganizationService(organizationRepository repository.OrganizationRepository, hotelRepository repository.HotelRepository) OrganizationService {
+	return &organizationService{organizationRepository: organizationRepository, hotelRepository: hotelRepository}
+}
+
+func (s *organizationService) CreateOrganization(request *domain.OrganizationRequest) (*domain.Organization, error) {
+	name := strings.TrimSpace(request.Name)
+	if err := s.ensureNameFree(name, nil); err != nil {
+		return nil, err
+	}
+
+	organization := &domain.Organization{Id: uuid.New(), Name: name, Settings: normalizeOrganizationSettings(request.Settings)}
+	if err := s.organizationRepository.CreateOrganization(organization); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (s *organizationService) UpdateOrganization(id string, request *domain.OrganizationRequest) (*domain.Organization, error) {
+	organization, err := s.GetOrganizationById(id)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimSpace(request.Name)
+	if err := s.ensureNameFree(name, &organization.Id); err != nil {
+		return nil, err
+	}
+
+	organization.Name = name
+	organization.Settings = normalizeOrganizationSettings(request.Settings)
+	if err := s.organizationRepository.UpdateOrganization(organization); err != nil {
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (s *organizationService) GetOrganizationById(id string) (*domain.Organization, error) {
+	organization, err := s.organizationRepository.GetOrganizationById(id)
+	if err != nil {
+		return nil, ErrOrganizationNotFound
+	}
+	return organization, nil
+}
+
+func (s *organizationService) GetAllOrganizations() ([]domain.Organization, error) {
+	return s.organizationRepository.GetAllOrganizations()
+}
+
+func (s *organizationService) GetOrganizationHotels(id string) ([]domain.Hotel, error) {
+	organization, err := s.GetOrganizationById(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.hotelRepository.SearchHotels(&domain.HotelFilter{OrganizationId: &organization.Id})
+}
+
+/*
+MoveHotel
+Params: hotel id, target organization (nil for independent) and reason, acting user
+Returns: the recorded change, error
+Description: Reassign a hotel to another organization. Bookings already made stay with the previous
+organization, and the change is kept in the hotel's organization history.
+*/
+func (s *organizationService) MoveHotel(hotelId string, request *domain.MoveHotelRequest, actorId *uuid.UUID) (*domain.HotelOrganizationChange, error) {
+	hotel, err := s.hotelRepository.GetHotelById(hotelId)
+	if err != nil {
+		return nil, ErrHotelNotFound
+	}
+	if request.OrganizationId != nil {
+		if _, err := s.GetOrganizationById(request.OrganizationId.String()); err != nil {
+			return nil, err
+		}
+	}
+	if sameOrganization(hotel.OrganizationId, request.OrganizationId) {
+		return nil, ErrHotelAlreadyInPlace
+	}
+
+	change := &domain.HotelOrganizationChange{
+		Id:                 uuid.New(),
+		HotelId:            hotel.Id,
+		FromOrganizationId: hotel.OrganizationId,
+		ToOrganizationId:   request.OrganizationId,
+		ChangedBy:          actorId,
+		Reason:             strings.TrimSpace(request.Reason),
+	}
+	if err := s.organizationRepository.MoveHotel(change); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+func (s *organizationService) GetHotelOrganizationHistory(hotelId string) ([]domain.HotelOrganizationChange, error) {
+	return s.organizationRepository.GetHotelChanges(hotelId)
+}
+
+/*
+GetReport
+Params: organization id, first and last check-in date
+Returns: booking counts and revenue per hotel with totals, error
+Description: Report on the bookings made with the organization's hotels. Hotels that have since moved
+to another organization still appear for the bookings made while they belonged to this one.
+*/
+func (s *organizationService) GetReport(id string, from time.Time, to time.Time) (*domain.OrganizationReport, error) {
+	from, to = truncateToDay(from), truncateToDay(to)
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: to must not be before from", ErrInvalidReportRange)
+	}
+	if daysBetween(from, to) >= maxReportRangeDays {
+		return nil, fmt.Errorf("%w: reports cannot span more than %d days", ErrInvalidReportRange, maxReportRangeDays)
+	}
+	organization, err := s.GetOrganizationById(id)
+	if err != nil {
+		return nil, err
+	}
+
+	hotels, err := s.organizationRepository.GetHotelReports(id, from, to.AddDate(0, 0, 1))
+	if err != nil {
+		return nil, err
+	}
+	report := &domain.OrganizationReport{
+		OrganizationId: organization.Id,
+		Currency:       organization.Settings.Currency,
+		From:           from,
+		To:             to,
+		Hotels:         hotels,
+	}
+	for _, hotel := range hotels {
+		report.Totals.Bookings += hotel.Bookings
+		report.Totals.Cancelled += hotel.Cancelled
+		report.Totals.NoShows += hotel.NoShows
+		report.Totals.Revenue += hotel.Revenue
+	}
+	return report, nil
+}
+
+func (s *organizationService) ensureNameFree(name string, except *uuid.UUID) error {
+	existing, err := s.organizationRepository.GetOrganizationByName(name)
+	if err == nil && (except == nil || existing.Id != *except) {
+		return ErrOrganizationNameTaken
+	}
+	return nil
+}
+
+func normalizeOrganizationSettings(settings domain.OrganizationSettings) domain.OrganizationSettings {
+	settings.Currency = strings.ToUpper(strings.TrimSpace(settings.Currency))
+	if settings.Currency == "" {
+		settings.Currency = defaultOrganizationCurrency
+	}
+	settings.Branding.DisplayName = strings.TrimSpace(settings.Branding.DisplayName)
+	return settings
+}
+
+func sameOrganization(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
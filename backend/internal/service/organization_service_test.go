@@ -0,0 +1,182 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupOrganizationServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupBookingServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE organizations (
+			id TEXT PRIMARY KEY,
+			name TEXT UNIQUE,
+			currency TEXT,
+			default_no_show_cutoff_hours INTEGER,
+			default_no_show_penalty_nights INTEGER,
+			brand_display_name TEXT,
+			brand_logo_url TEXT,
+			brand_primary_color TEXT,
+			created_at DATETIME,
+			updated_at DATETIME
+		);
+		CREATE TABLE hotel_organization_changes (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			from_organization_id TEXT,
+			to_organization_id TEXT,
+			changed_by TEXT,
+			reason TEXT,
+			created_at DATETIME
+		);
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func TestOrganizationService_CreateAndUpdate(t *testing.T) {
+	db := setupOrganizationServiceTestDB(t)
+	organizations := NewOrganizationService(repository.NewOrganizationRepository(db), repository.NewHotelRepository(db))
+
+	created, err := organizations.CreateOrganization(&domain.OrganizationRequest{Name: " Seaside Hotels "})
+	assert.NoError(t, err)
+	assert.Equal(t, "Seaside Hotels", created.Name)
+	assert.Equal(t, "USD", created.Settings.Currency)
+
+	_, err = organizations.CreateOrganization(&domain.OrganizationRequest{Name: "seaside hotels"})
+	assert.ErrorIs(t, err, ErrOrganizationNameTaken)
+
+	cutoffHours, penaltyNights := 6, 1
+	updated, err := organizations.UpdateOrganization(created.Id.String(), &domain.OrganizationRequest{
+		Name: "Seaside Hotels",
+		Settings: domain.OrganizationSettings{
+			Currency:      "eur",
+			DefaultNoShow: domain.NoShowPolicy{CutoffHours: &cutoffHours, PenaltyNights: &penaltyNights},
+			Branding:      domain.OrganizationBranding{DisplayName: "Seaside", PrimaryColor: "#0077cc"},
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "EUR", updated.Settings.Currency)
+
+	fetched, err := organizations.GetOrganizationById(created.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, updated.Settings, fetched.Settings)
+
+	_, err = organizations.GetOrganizationById(uuid.New().String())
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+}
+
+func TestOrganizationService_MoveHotelKeepsHistoryAndReports(t *testing.T) {
+	db := setupOrganizationServiceTestDB(t)
+	hotelRepo := repository.NewHotelRepository(db)
+	organizations := NewOrganizationService(repository.NewOrganizationRepository(db), hotelRepo)
+	bookings := NewBookingService(hotelRepo, repository.NewBookingRepository(db), nil, nil, nil, nil)
+
+	first, err := organizations.CreateOrganization(&domain.OrganizationRequest{Name: "First"})
+	assert.NoError(t, err)
+	second, err := organizations.CreateOrganization(&domain.OrganizationRequest{Name: "Second"})
+	assert.NoError(t, err)
+
+	hotelId, roomId := uuid.New(), uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100))
+	actor := uuid.New()
+
+	_, err = organizations.MoveHotel(hotelId.String(), &domain.MoveHotelRequest{OrganizationId: &first.Id}, &actor)
+	assert.NoError(t, err)
+	_, err = organizations.MoveHotel(hotelId.String(), &domain.MoveHotelRequest{OrganizationId: &first.Id}, &actor)
+	assert.ErrorIs(t, err, ErrHotelAlreadyInPlace)
+
+	checkIn := time.Date(2030, 3, 10, 0, 0, 0, 0, time.UTC)
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2),
+	}))
+
+	change, err := organizations.MoveHotel(hotelId.String(), &domain.MoveHotelRequest{OrganizationId: &second.Id, Reason: "Sold"}, &actor)
+	assert.NoError(t, err)
+	assert.Equal(t, &first.Id, change.FromOrganizationId)
+	assert.Equal(t, &second.Id, change.ToOrganizationId)
+
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomId, CheckInDate: checkIn.AddDate(0, 0, 5), CheckOutDate: checkIn.AddDate(0, 0, 6),
+	}))
+
+	hotels, err := organizations.GetOrganizationHotels(second.Id.String())
+	assert.NoError(t, err)
+	if assert.Len(t, hotels, 1) {
+		assert.Equal(t, hotelId, hotels[0].Id)
+		assert.Equal(t, "Second", hotels[0].Organization.Name)
+	}
+	hotels, err = organizations.GetOrganizationHotels(first.Id.String())
+	assert.NoError(t, err)
+	assert.Empty(t, hotels)
+
+	// Each organization keeps the bookings made while it owned the hotel
+	from, to := checkIn.AddDate(0, 0, -1), checkIn.AddDate(0, 0, 10)
+	report, err := organizations.GetReport(first.Id.String(), from, to)
+	assert.NoError(t, err)
+	if assert.Len(t, report.Hotels, 1) {
+		assert.Equal(t, int64(1), report.Hotels[0].Bookings)
+		assert.Equal(t, 200.0, report.Hotels[0].Revenue)
+	}
+	report, err = organizations.GetReport(second.Id.String(), from, to)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.HotelReport{Bookings: 1, Revenue: 100}, report.Totals)
+
+	history, err := organizations.GetHotelOrganizationHistory(hotelId.String())
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Nil(t, history[0].FromOrganizationId)
+		assert.Equal(t, "Sold", history[1].Reason)
+		assert.Equal(t, &actor, history[1].ChangedBy)
+	}
+
+	_, err = organizations.GetReport(first.Id.String(), to, from)
+	assert.ErrorIs(t, err, ErrInvalidReportRange)
+}
+
+func TestHotelService_CreateHotelChecksOrganization(t *testing.T) {
+	db := setupOrganizationServiceTestDB(t)
+	organizationRepo := repository.NewOrganizationRepository(db)
+	organizations := NewOrganizationService(organizationRepo, repository.NewHotelRepository(db))
+	hotels := NewHotelService(repository.NewHotelRepository(db), organizationRepo, nil, nil)
+
+	missing := uuid.New()
+	err := hotels.CreateHotel(&domain.Hotel{Id: uuid.New(), Name: "Stray Inn", OrganizationId: &missing})
+	assert.ErrorIs(t, err, ErrOrganizationNotFound)
+
+	organization, err := organizations.CreateOrganization(&domain.OrganizationRequest{Name: "Seaside"})
+	assert.NoError(t, err)
+	hotel := &domain.Hotel{Id: uuid.New(), Name: "Seaside Inn", OrganizationId: &organization.Id}
+	assert.NoError(t, hotels.CreateHotel(hotel))
+	stored, err := hotels.GetHotelById(hotel.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, &organization.Id, stored.OrganizationId)
+}
+
+func TestNoShowPolicy_FallsBackToOrganizationDefaults(t *testing.T) {
+	twelve, six, two, none := 12, 6, 2, 0
+	hotel := &domain.Hotel{
+		NoShow:       domain.NoShowPolicy{CutoffHours: &twelve},
+		Organization: &domain.Organization{Settings: domain.OrganizationSettings{DefaultNoShow: domain.NoShowPolicy{CutoffHours: &six, PenaltyNights: &two}}},
+	}
+	assert.Equal(t, domain.NoShowPolicy{CutoffHours: &twelve, PenaltyNights: &two}, noShowPolicy(hotel))
+
+	// A hotel opts out of its organization's penalty by setting it to 0
+	hotel.NoShow.PenaltyNights = &none
+	assert.Equal(t, domain.NoShowPolicy{CutoffHours: &twelve, PenaltyNights: &none}, noShowPolicy(hotel))
+	assert.Zero(t, noShowPenalty(&domain.Booking{TotalPrice: 200, CheckInDate: time.Now(), CheckOutDate: time.Now().AddDate(0, 0, 2)}, noShowPolicy(hotel).PenaltyNights))
+
+	hotel.NoShow.PenaltyNights = nil
+	hotel.Organization = nil
+	assert.Equal(t, domain.NoShowPolicy{CutoffHours: &twelve}, noShowPolicy(hotel))
+}
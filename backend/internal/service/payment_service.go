@@ -0,0 +1,72 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+var ErrPaymentFailed = errors.New("payment failed")
+
+// PaymentGateway charges a guest through an external card processor and returns its reference
+type PaymentGateway interface {
+	Charge(userId uuid.UUID, amount float64, description string) (string, error)
+}
+
+type PaymentService interface {
+	ChargeBooking(booking *domain.Booking, kind domain.PaymentKind, amount float64, description string) (*domain.Payment, error)
+	GetPaymentsByBookingId(bookingId string) ([]domain.Payment, error)
+}
+
+type paymentService struct {
+	paymentRepository repository.PaymentRepository
+	gateway           PaymentGateway
+}
+
+// NewPaymentService creates a payment service. Without a gateway charges are recorded as pending for the front desk to collect.
+func NewPaymentService(paymentRepository repository.PaymentRepository, gateway PaymentGateway) PaymentService {
+	return &paymentService{paymentRepository: paymentRepository, gateway: gateway}
+}
+
+/*
+ChargeBooking
+Params: booking, kind of charge, amount, description shown to the guest
+Returns: recorded payment, error
+Description: Charge the booking's guest and record the outcome. A declined charge is still
+recorded as failed and returned together with ErrPaymentFailed.
+*/
+func (s *paymentService) ChargeBooking(booking *domain.Booking, kind domain.PaymentKind, amount float64, description string) (*domain.Payment, error) {
+	payment := &domain.Payment{
+		Id:          uuid.New(),
+		BookingId:   booking.Id,
+		UserId:      booking.UserId,
+		Kind:        kind,
+		Amount:      amount,
+		Status:      domain.PaymentStatusPending,
+		Description: description,
+	}
+
+	var chargeErr error
+	if s.gateway != nil {
+		reference, err := s.gateway.Charge(booking.UserId, amount, description)
+		if err != nil {
+			payment.Status = domain.PaymentStatusFailed
+			chargeErr = fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+		} else {
+			payment.Status = domain.PaymentStatusCaptured
+			payment.Reference = reference
+		}
+	}
+
+	if err := s.paymentRepository.CreatePayment(payment); err != nil {
+		return nil, err
+	}
+	return payment, chargeErr
+}
+
+func (s *paymentService) GetPaymentsByBookingId(bookingId string) ([]domain.Payment, error) {
+	return s.paymentRepository.GetPaymentsByBookingId(bookingId)
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/storage"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif" // register GIF decoding
+	"image/jpeg"
+	_ "image/png" // register PNG decoding
+	"log"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxPhotoBytes        = 10 << 20
+	maxPhotoDimension    = 8000
+	thumbnailJPEGQuality = 85
+)
+
+var (
+	ErrPhotoNotFound      = errors.New("photo not found")
+	ErrRoomNotFound       = errors.New("room not found")
+	ErrInvalidImage       = errors.New("file must be a JPEG, PNG or GIF image")
+	ErrImageTooLarge      = fmt.Errorf("image must be at most %d MB and %dx%d pixels", MaxPhotoBytes>>20, maxPhotoDimension, maxPhotoDimension)
+	ErrPhotoOrderMismatch = errors.New("photo ids must list every photo being reordered exactly once")
+
+	photoExtensions = map[string]string{
+		"image/jpeg": "jpg",
+		"image/png":  "png",
+		"image/gif":  "gif",
+	}
+
+	// thumbnailWidths are the generated sizes; images narrower than a size are not scaled up
+	thumbnailWidths = map[string]int{
+		"small":  160,
+		"medium": 480,
+		"large":  1200,
+	}
+)
+
+type PhotoService interface {
+	UploadPhoto(hotelId string, roomId *uuid.UUID, data []byte) (*domain.Photo, error)
+	ReorderPhotos(hotelId string, request *domain.ReorderPhotosRequest) ([]domain.Photo, error)
+	SetCoverPhoto(hotelId string, photoId string) (*domain.Photo, error)
+	DeletePhoto(hotelId string, photoId string) error
+}
+
+type photoService struct {
+	photoRepository repository.PhotoRepository
+	hotelRepository repository.HotelRepository
+	blobStore       storage.BlobStore
+}
+
+func NewPhotoService(photoRepository repository.PhotoRepository, hotelRepository repository.HotelRepository, blobStore storage.BlobStore) PhotoService {
+	return &photoService{photoRepository: photoRepository, hotelRepository: hotelRepository, blobStore: blobStore}
+}
+
+/*
+UploadPhoto
+Params: hotel id, room id (nil for a hotel photo), file contents
+Returns: created photo, error
+Description: Validate the image by its content, store it with small, medium and large JPEG thumbnails
+and append it to the hotel's or room's photos. The first photo becomes the cover.
+*/
+func (s *photoService) UploadPhoto(hotelId string, roomId *uuid.UUID, data []byte) (*domain.Photo, error) {
+	if len(data) > MaxPhotoBytes {
+		return nil, ErrImageTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	extension, ok := photoExtensions[contentType]
+	if !ok {
+		return nil, ErrInvalidImage
+	}
+
+	// Check the dimensions before decoding so oversized images are never loaded into memory
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+	if config.Width > maxPhotoDimension || config.Height > maxPhotoDimension {
+		return nil, ErrImageTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrInvalidImage
+	}
+
+	hotel, err := s.hotelRepository.GetHotelById(hotelId)
+	if err != nil {
+		return nil, ErrHotelNotFound
+	}
+	if roomId != nil && !hotelHasRoom(hotel, *roomId) {
+		return nil, ErrRoomNotFound
+	}
+	existing, err := s.photoRepository.GetPhotos(hotelId, roomId)
+	if err != nil {
+		return nil, err
+	}
+
+	photo := &domain.Photo{
+		Id:          uuid.New(),
+		HotelId:     hotel.Id,
+		RoomId:      roomId,
+		Position:    len(existing),
+		IsCover:     len(existing) == 0,
+		ContentType: contentType,
+		Width:       config.Width,
+		Height:      config.Height,
+		Size:        len(data),
+		Thumbnails:  make(map[string]string, len(thumbnailWidths)),
+	}
+	prefix := fmt.Sprintf("hotels/%s/photos/%s", hotel.Id, photo.Id)
+	photo.StorageKey = prefix + "/original." + extension
+
+	if err := s.blobStore.Put(photo.StorageKey, data, contentType); err != nil {
+		return nil, err
+	}
+	photo.Url = s.blobStore.URL(photo.StorageKey)
+
+	for name, width := range thumbnailWidths {
+		thumbnail, err := encodeThumbnail(img, width)
+		if err != nil {
+			s.deleteBlobs(photo)
+			return nil, err
+		}
+		key := prefix + "/" + name + ".jpg"
+		if err := s.blobStore.Put(key, thumbnail, "image/jpeg"); err != nil {
+			s.deleteBlobs(photo)
+			return nil, err
+		}
+		photo.Thumbnails[name] = s.blobStore.URL(key)
+	}
+
+	if err := s.photoRepository.CreatePhoto(photo); err != nil {
+		s.deleteBlobs(photo)
+		return nil, err
+	}
+	return photo, nil
+}
+
+/*
+ReorderPhotos
+Params: hotel id, ReorderPhotosRequest
+Returns: photos in their new order, error
+Description: Set the display order of the hotel's own photos, or of one room's photos.
+The request must list every photo in that set exactly once.
+*/
+func (s *photoService) ReorderPhotos(hotelId string, request *domain.ReorderPhotosRequest) ([]domain.Photo, error) {
+	photos, err := s.photoRepository.GetPhotos(hotelId, request.RoomId)
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) != len(request.PhotoIds) {
+		return nil, ErrPhotoOrderMismatch
+	}
+
+	byId := make(map[uuid.UUID]domain.Photo, len(photos))
+	for _, photo := range photos {
+		byId[photo.Id] = photo
+	}
+	ordered := make([]domain.Photo, 0, len(photos))
+	for position, id := range request.PhotoIds {
+		photo, ok := byId[id]
+		if !ok {
+			return nil, ErrPhotoOrderMismatch
+		}
+		delete(byId, id)
+		photo.Position = position
+		ordered = append(ordered, photo)
+	}
+
+	if err := s.photoRepository.UpdatePhotos(ordered); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// SetCoverPhoto makes the photo the cover of its hotel or room, replacing the previous cover
+func (s *photoService) SetCoverPhoto(hotelId string, photoId string) (*domain.Photo, error) {
+	target, err := s.getHotelPhoto(hotelId, photoId)
+	if err != nil {
+		return nil, err
+	}
+	photos, err := s.photoRepository.GetPhotos(hotelId, target.RoomId)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range photos {
+		photos[i].IsCover = photos[i].Id == target.Id
+	}
+	if err := s.photoRepository.UpdatePhotos(photos); err != nil {
+		return nil, err
+	}
+	target.IsCover = true
+	return target, nil
+}
+
+/*
+DeletePhoto
+Params: hotel id, photo id
+Returns: error
+Description: Delete a photo and its files. The remaining photos close the gap in the order,
+and the first of them becomes the cover if the deleted photo was the cover.
+*/
+func (s *photoService) DeletePhoto(hotelId string, photoId string) error {
+	photo, err := s.getHotelPhoto(hotelId, photoId)
+	if err != nil {
+		return err
+	}
+	if err := s.photoRepository.DeletePhoto(photo); err != nil {
+		return err
+	}
+	s.deleteBlobs(photo)
+
+	remaining, err := s.photoRepository.GetPhotos(hotelId, photo.RoomId)
+	if err != nil {
+		return err
+	}
+	for i := range remaining {
+		remaining[i].Position = i
+		if photo.IsCover {
+			remaining[i].IsCover = i == 0
+		}
+	}
+	return s.photoRepository.UpdatePhotos(remaining)
+}
+
+func (s *photoService) getHotelPhoto(hotelId string, photoId string) (*domain.Photo, error) {
+	photo, err := s.photoRepository.GetPhotoById(photoId)
+	if err != nil || photo.HotelId.String() != hotelId {
+		return nil, ErrPhotoNotFound
+	}
+	return photo, nil
+}
+
+// deleteBlobs removes a photo's original and thumbnails; failures only leave orphaned files, so they are logged
+func (s *photoService) deleteBlobs(photo *domain.Photo) {
+	keys := []string{photo.StorageKey}
+	for name := range thumbnailWidths {
+		keys = append(keys, fmt.Sprintf("hotels/%s/photos/%s/%s.jpg", photo.HotelId, photo.Id, name))
+	}
+	for _, key := range keys {
+		if err := s.blobStore.Delete(key); err != nil {
+			log.Printf("Error deleting blob %s: %v", key, err)
+		}
+	}
+}
+
+// encodeThumbnail scales the image down to the given width and encodes it as JPEG on a white background
+func encodeThumbnail(src image.Image, width int) ([]byte, error) {
+	bounds := src.Bounds()
+	if bounds.Dx() < width {
+		width = bounds.Dx()
+	}
+	height := bounds.Dy() * width / bounds.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	scaleDown(dst, src)
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, dst, &jpeg.Options{Quality: thumbnailJPEGQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scaleDown fills dst by averaging the block of source pixels behind each destination pixel,
+// blending over what is already in dst so transparent areas keep its background
+func scaleDown(dst *image.RGBA, src image.Image) {
+	sb := src.Bounds()
+	db := dst.Bounds()
+	for y := 0; y < db.Dy(); y++ {
+		y0 := sb.Min.Y + y*sb.Dy()/db.Dy()
+		y1 := sb.Min.Y + (y+1)*sb.Dy()/db.Dy()
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+		for x := 0; x < db.Dx(); x++ {
+			x0 := sb.Min.X + x*sb.Dx()/db.Dx()
+			x1 := sb.Min.X + (x+1)*sb.Dx()/db.Dx()
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := src.At(sx, sy).RGBA()
+					r, g, b, a, n = r+uint64(pr), g+uint64(pg), b+uint64(pb), a+uint64(pa), n+1
+				}
+			}
+
+			// Premultiplied average composited over the background pixel
+			bg := dst.RGBAAt(x, y)
+			alpha := a / n
+			blend := func(sum uint64, background uint8) uint8 {
+				return uint8((sum/n + uint64(background)*0x101*(0xffff-alpha)/0xffff) >> 8)
+			}
+			dst.SetRGBA(x, y, color.RGBA{R: blend(r, bg.R), G: blend(g, bg.G), B: blend(b, bg.B), A: 0xff})
+		}
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"backend/internal/storage"
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newPhotoTestService(t *testing.T, db *gorm.DB) (PhotoService, string) {
+	root := t.TempDir()
+	store := storage.NewLocalBlobStore(root, "/media")
+	return NewPhotoService(repository.NewPhotoRepository(db), repository.NewHotelRepository(db), store), root
+}
+
+func encodeTestPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	assert.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestPhotoService_UploadPhoto(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	photos, root := newPhotoTestService(t, db)
+
+	photo, err := photos.UploadPhoto(hotelId.String(), nil, encodeTestPNG(t, 800, 600))
+	assert.NoError(t, err)
+	assert.Equal(t, "image/png", photo.ContentType)
+	assert.Equal(t, 800, photo.Width)
+	assert.True(t, photo.IsCover)
+	assert.True(t, strings.HasPrefix(photo.Url, "/media/hotels/"+hotelId.String()+"/"))
+	assert.Len(t, photo.Thumbnails, 3)
+
+	// Thumbnails keep the aspect ratio and are never scaled up
+	expectedWidths := map[string]int{"small": 160, "medium": 480, "large": 800}
+	for name, url := range photo.Thumbnails {
+		data, err := os.ReadFile(filepath.Join(root, strings.TrimPrefix(url, "/media/")))
+		assert.NoError(t, err)
+		thumbnail, err := jpeg.Decode(bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, expectedWidths[name], thumbnail.Bounds().Dx(), name)
+		assert.Equal(t, expectedWidths[name]*3/4, thumbnail.Bounds().Dy(), name)
+	}
+
+	roomPhoto, err := photos.UploadPhoto(hotelId.String(), &roomId, encodeTestPNG(t, 100, 100))
+	assert.NoError(t, err)
+	assert.True(t, roomPhoto.IsCover)
+
+	// Photos are returned with the hotel, room photos under their room
+	hotel, err := repository.NewHotelRepository(db).GetHotelById(hotelId.String())
+	assert.NoError(t, err)
+	assert.Len(t, hotel.Photos, 1)
+	assert.Len(t, hotel.Rooms[0].Photos, 1)
+	assert.Equal(t, roomPhoto.Id, hotel.Rooms[0].Photos[0].Id)
+}
+
+func TestPhotoService_RejectsInvalidImages(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	photos, _ := newPhotoTestService(t, db)
+
+	tests := []struct {
+		name string
+		data []byte
+		err  error
+	}{
+		{name: "text file", data: []byte("definitely not an image"), err: ErrInvalidImage},
+		{name: "html disguised as image", data: []byte("<html><img src=x onerror=alert(1)></html>"), err: ErrInvalidImage},
+		{name: "truncated png", data: encodeTestPNG(t, 50, 50)[:40], err: ErrInvalidImage},
+		{name: "too many bytes", data: append(encodeTestPNG(t, 10, 10), make([]byte, MaxPhotoBytes)...), err: ErrImageTooLarge},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := photos.UploadPhoto(hotelId.String(), nil, tt.data)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+
+	// Another hotel's room cannot be targeted
+	otherRoom := uuid.New()
+	_, err := photos.UploadPhoto(hotelId.String(), &otherRoom, encodeTestPNG(t, 10, 10))
+	assert.ErrorIs(t, err, ErrRoomNotFound)
+	_, err = photos.UploadPhoto(uuid.New().String(), nil, encodeTestPNG(t, 10, 10))
+	assert.ErrorIs(t, err, ErrHotelNotFound)
+}
+
+// thumbnailFailingStore stores originals but fails to store thumbnails
+type thumbnailFailingStore struct {
+	storage.BlobStore
+}
+
+func (s thumbnailFailingStore) Put(key string, data []byte, contentType string) error {
+	if !strings.Contains(key, "/original.") {
+		return errors.New("disk full")
+	}
+	return s.BlobStore.Put(key, data, contentType)
+}
+
+func TestPhotoService_FailedUploadLeavesNoFiles(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	root := t.TempDir()
+	store := thumbnailFailingStore{storage.NewLocalBlobStore(root, "/media")}
+	photos := NewPhotoService(repository.NewPhotoRepository(db), repository.NewHotelRepository(db), store)
+
+	_, err := photos.UploadPhoto(hotelId.String(), nil, encodeTestPNG(t, 100, 100))
+	assert.Error(t, err)
+
+	var files []string
+	assert.NoError(t, filepath.WalkDir(root, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && !entry.IsDir() {
+			files = append(files, path)
+		}
+		return err
+	}))
+	assert.Empty(t, files)
+}
+
+func TestPhotoService_OrderingAndCover(t *testing.T) {
+	db := setupBookingServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	photos, root := newPhotoTestService(t, db)
+
+	var uploaded []*domain.Photo
+	for i := 0; i < 3; i++ {
+		photo, err := photos.UploadPhoto(hotelId.String(), nil, encodeTestPNG(t, 20, 20))
+		assert.NoError(t, err)
+		assert.Equal(t, i, photo.Position)
+		uploaded = append(uploaded, photo)
+	}
+
+	_, err := photos.ReorderPhotos(hotelId.String(), &domain.ReorderPhotosRequest{PhotoIds: []uuid.UUID{uploaded[2].Id, uploaded[0].Id}})
+	assert.ErrorIs(t, err, ErrPhotoOrderMismatch)
+	_, err = photos.ReorderPhotos(hotelId.String(), &domain.ReorderPhotosRequest{PhotoIds: []uuid.UUID{uploaded[2].Id, uploaded[0].Id, uploaded[0].Id}})
+	assert.ErrorIs(t, err, ErrPhotoOrderMismatch)
+
+	ordered, err := photos.ReorderPhotos(hotelId.String(), &domain.ReorderPhotosRequest{PhotoIds: []uuid.UUID{uploaded[2].Id, uploaded[0].Id, uploaded[1].Id}})
+	assert.NoError(t, err)
+	assert.Equal(t, uploaded[2].Id, ordered[0].Id)
+
+	_, err = photos.SetCoverPhoto(hotelId.String(), uploaded[1].Id.String())
+	assert.NoError(t, err)
+	_, err = photos.SetCoverPhoto(uuid.New().String(), uploaded[1].Id.String())
+	assert.ErrorIs(t, err, ErrPhotoNotFound)
+
+	// Deleting the cover closes the gap and promotes the first remaining photo
+	assert.NoError(t, photos.DeletePhoto(hotelId.String(), uploaded[1].Id.String()))
+	_, err = os.Stat(filepath.Join(root, strings.TrimPrefix(uploaded[1].Url, "/media/")))
+	assert.True(t, os.IsNotExist(err))
+
+	hotel, err := repository.NewHotelRepository(db).GetHotelById(hotelId.String())
+	assert.NoError(t, err)
+	assert.Len(t, hotel.Photos, 2)
+	assert.Equal(t, uploaded[2].Id, hotel.Photos[0].Id)
+	assert.True(t, hotel.Photos[0].IsCover)
+	assert.Equal(t, 1, hotel.Photos[1].Position)
+	assert.False(t, hotel.Photos[1].IsCover)
+}
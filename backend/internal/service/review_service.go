@@ -0,0 +1,272 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrReviewNotFound           = errors.New("review not found")
+	ErrReviewNotAllowed         = errors.New("only guests who have checked out of a stay at this hotel can review it")
+	ErrAlreadyReviewed          = errors.New("this stay has already been reviewed")
+	ErrAlreadyReported          = errors.New("you have already reported this review")
+	ErrModerationReasonRequired = errors.New("a reason is required to reject or hide a review")
+)
+
+// Short comments such as "Great stay!" are legitimately repeated, so only longer texts are checked for duplicates
+const minDuplicateTextLength = 30
+
+var (
+	reviewWordPattern = regexp.MustCompile(`[\p{L}\p{N}']+`)
+	reviewLinkPattern = regexp.MustCompile(`(?i)(https?://|www\.)\S+|\b[a-z0-9-]+\.(com|net|org|info|biz|io|co|ru|xyz|top)\b`)
+
+	moderationOutcomes = map[domain.ModerationAction]struct {
+		status domain.ReviewStatus
+		audit  string
+	}{
+		domain.ModerationActionApprove: {domain.ReviewStatusApproved, AuditActionReviewApproved},
+		domain.ModerationActionReject:  {domain.ReviewStatusRejected, AuditActionReviewRejected},
+		domain.ModerationActionHide:    {domain.ReviewStatusHidden, AuditActionReviewHidden},
+	}
+)
+
+type ReviewService interface {
+	CreateReview(hotelId string, user *domain.User, request *domain.CreateReviewRequest) (*domain.Review, error)
+	GetReviewsByHotelId(hotelId string) ([]domain.Review, error)
+	ReplyToReview(hotelId string, reviewId string, reply string) (*domain.Review, error)
+	ReportReview(reviewId string, user *domain.User, reason string) error
+	GetModerationQueue() ([]domain.Review, error)
+	GetReports(reviewId string) ([]domain.ReviewReport, error)
+	ModerateReview(reviewId string, moderator *domain.User, request *domain.ModerateReviewRequest) (*domain.Review, error)
+	GetModerationHistory(reviewId string) ([]domain.AuditEntry, error)
+}
+
+type reviewService struct {
+	reviewRepository  repository.ReviewRepository
+	bookingRepository repository.BookingRepository
+	audit             AuditService
+	bannedWords       map[string]bool
+}
+
+// NewReviewService creates a review service. Reviews containing any of bannedWords are flagged for moderators.
+func NewReviewService(reviewRepository repository.ReviewRepository, bookingRepository repository.BookingRepository, audit AuditService, bannedWords []string) ReviewService {
+	banned := make(map[string]bool, len(bannedWords))
+	for _, word := range bannedWords {
+		banned[strings.ToLower(strings.TrimSpace(word))] = true
+	}
+	return &reviewService{
+		reviewRepository:  reviewRepository,
+		bookingRepository: bookingRepository,
+		audit:             audit,
+		bannedWords:       banned,
+	}
+}
+
+/*
+CreateReview
+Params: hotel id, reviewing user, CreateReviewRequest
+Returns: created review, error
+Description: Review a hotel from one of the user's checked-out bookings there. Each stay can be reviewed once.
+The overall rating is the average of the cleanliness, location and service scores. The review is screened
+and waits in the moderation queue until approved.
+*/
+func (s *reviewService) CreateReview(hotelId string, user *domain.User, request *domain.CreateReviewRequest) (*domain.Review, error) {
+	booking, err := s.bookingRepository.GetBookingById(request.BookingId.String())
+	if err != nil || booking.UserId != user.Id || booking.HotelId.String() != hotelId ||
+		booking.Status != domain.BookingStatusCheckedOut {
+		return nil, ErrReviewNotAllowed
+	}
+	if _, err := s.reviewRepository.GetReviewByBookingId(booking.Id.String()); err == nil {
+		return nil, ErrAlreadyReviewed
+	}
+
+	overall := float64(request.Cleanliness+request.Location+request.Service) / 3
+	review := &domain.Review{
+		Id:          uuid.New(),
+		HotelId:     booking.HotelId,
+		UserId:      user.Id,
+		BookingId:   booking.Id,
+		Cleanliness: request.Cleanliness,
+		Location:    request.Location,
+		Service:     request.Service,
+		Rating:      math.Round(overall*100) / 100,
+		Title:       strings.TrimSpace(request.Title),
+		Comment:     strings.TrimSpace(request.Comment),
+		Status:      domain.ReviewStatusPending,
+	}
+	if err := s.screenReview(review); err != nil {
+		return nil, err
+	}
+	if err := s.reviewRepository.CreateReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+// GetReviewsByHotelId returns the hotel's published reviews
+func (s *reviewService) GetReviewsByHotelId(hotelId string) ([]domain.Review, error) {
+	return s.reviewRepository.GetReviewsByHotelId(hotelId, domain.ReviewStatusApproved)
+}
+
+/*
+ReplyToReview
+Params: hotel id, review id, reply text
+Returns: updated review, error
+Description: Publish the hotel management's reply under a review, replacing any earlier reply
+*/
+func (s *reviewService) ReplyToReview(hotelId string, reviewId string, reply string) (*domain.Review, error) {
+	review, err := s.getHotelReview(hotelId, reviewId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	review.Reply = strings.TrimSpace(reply)
+	review.RepliedAt = &now
+	if err := s.reviewRepository.UpdateReview(review); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+/*
+ReportReview
+Params: review id, reporting user, reason
+Returns: error
+Description: Report a published review. It returns to the moderation queue until a moderator decides on it.
+*/
+func (s *reviewService) ReportReview(reviewId string, user *domain.User, reason string) error {
+	review, err := s.reviewRepository.GetReviewById(reviewId)
+	if err != nil || review.Status != domain.ReviewStatusApproved {
+		return ErrReviewNotFound
+	}
+
+	reports, err := s.reviewRepository.GetReportsByReviewId(reviewId)
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		if report.UserId == user.Id {
+			return ErrAlreadyReported
+		}
+	}
+
+	return s.reviewRepository.AddReport(&domain.ReviewReport{
+		Id:       uuid.New(),
+		ReviewId: review.Id,
+		UserId:   user.Id,
+		Reason:   strings.TrimSpace(reason),
+	})
+}
+
+// GetModerationQueue lists pending and reported reviews; reviews flagged by screening come first
+func (s *reviewService) GetModerationQueue() ([]domain.Review, error) {
+	reviews, err := s.reviewRepository.GetModerationQueue()
+	if err != nil {
+		return nil, err
+	}
+
+	queue := make([]domain.Review, 0, len(reviews))
+	var unflagged []domain.Review
+	for _, review := range reviews {
+		if len(review.Flags) > 0 {
+			queue = append(queue, review)
+		} else {
+			unflagged = append(unflagged, review)
+		}
+	}
+	return append(queue, unflagged...), nil
+}
+
+func (s *reviewService) GetReports(reviewId string) ([]domain.ReviewReport, error) {
+	return s.reviewRepository.GetReportsByReviewId(reviewId)
+}
+
+/*
+ModerateReview
+Params: review id, moderator, ModerateReviewRequest
+Returns: updated review, error
+Description: Approve, reject or hide a review and record the decision in the audit trail.
+Open reports are resolved by the decision and the hotel's rating is recalculated.
+*/
+func (s *reviewService) ModerateReview(reviewId string, moderator *domain.User, request *domain.ModerateReviewRequest) (*domain.Review, error) {
+	outcome, ok := moderationOutcomes[request.Action]
+	if !ok {
+		return nil, errors.New("unknown moderation action")
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" && request.Action != domain.ModerationActionApprove {
+		return nil, ErrModerationReasonRequired
+	}
+
+	review, err := s.reviewRepository.GetReviewById(reviewId)
+	if err != nil {
+		return nil, ErrReviewNotFound
+	}
+
+	now := time.Now()
+	review.Status = outcome.status
+	review.ModerationReason = reason
+	review.ModeratedBy = &moderator.Id
+	review.ModeratedAt = &now
+	review.ReportCount = 0
+	if err := s.reviewRepository.UpdateReview(review); err != nil {
+		return nil, err
+	}
+
+	if err := s.audit.Record(&moderator.Id, outcome.audit, AuditEntityReview, review.Id, reason); err != nil {
+		return nil, err
+	}
+	return review, nil
+}
+
+func (s *reviewService) GetModerationHistory(reviewId string) ([]domain.AuditEntry, error) {
+	return s.audit.GetEntriesByEntity(AuditEntityReview, reviewId)
+}
+
+// screenReview flags banned words, links and text copied from another review
+func (s *reviewService) screenReview(review *domain.Review) error {
+	text := review.Title + "\n" + review.Comment
+
+	for _, word := range reviewWordPattern.FindAllString(strings.ToLower(text), -1) {
+		if s.bannedWords[word] {
+			review.Flags = append(review.Flags, domain.ReviewFlagBannedWords)
+			break
+		}
+	}
+
+	if reviewLinkPattern.MatchString(text) {
+		review.Flags = append(review.Flags, domain.ReviewFlagContainsLink)
+	}
+
+	normalized := strings.Join(reviewWordPattern.FindAllString(strings.ToLower(review.Comment), -1), " ")
+	if len(normalized) >= minDuplicateTextLength {
+		sum := sha256.Sum256([]byte(normalized))
+		review.TextFingerprint = hex.EncodeToString(sum[:])
+		duplicate, err := s.reviewRepository.HasReviewWithFingerprint(review.TextFingerprint)
+		if err != nil {
+			return err
+		}
+		if duplicate {
+			review.Flags = append(review.Flags, domain.ReviewFlagDuplicateText)
+		}
+	}
+	return nil
+}
+
+func (s *reviewService) getHotelReview(hotelId string, reviewId string) (*domain.Review, error) {
+	review, err := s.reviewRepository.GetReviewById(reviewId)
+	if err != nil || review.HotelId.String() != hotelId {
+		return nil, ErrReviewNotFound
+	}
+	return review, nil
+}
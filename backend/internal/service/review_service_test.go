@@ -0,0 +1,274 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupReviewServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupBookingServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE reviews (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			user_id TEXT,
+			booking_id TEXT UNIQUE,
+			cleanliness INTEGER,
+			location INTEGER,
+			service INTEGER,
+			rating REAL,
+			title TEXT,
+			comment TEXT,
+			status TEXT,
+			flags TEXT,
+			text_fingerprint TEXT,
+			report_count INTEGER DEFAULT 0,
+			moderation_reason TEXT,
+			moderated_by TEXT,
+			moderated_at DATETIME,
+			reply TEXT,
+			replied_at DATETIME,
+			created_at DATETIME,
+			updated_at DATETIME
+		);
+		CREATE TABLE review_reports (
+			id TEXT PRIMARY KEY,
+			review_id TEXT,
+			user_id TEXT,
+			reason TEXT,
+			created_at DATETIME
+		);
+		CREATE TABLE audit_entries (
+			id TEXT PRIMARY KEY,
+			actor_id TEXT,
+			action TEXT,
+			entity_type TEXT,
+			entity_id TEXT,
+			details TEXT,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func newReviewTestServices(db *gorm.DB) (ReviewService, BookingService) {
+	bookingRepo := repository.NewBookingRepository(db)
+	bookings := NewBookingService(repository.NewHotelRepository(db), bookingRepo, nil, nil, nil, nil)
+	audit := NewAuditService(repository.NewAuditRepository(db))
+	return NewReviewService(repository.NewReviewRepository(db), bookingRepo, audit, []string{"scam"}), bookings
+}
+
+// createCheckedOutStay books a past stay for a new guest and runs it through check-in and check-out
+func createCheckedOutStay(t *testing.T, bookings BookingService, hotelId, roomId uuid.UUID, checkIn time.Time) (*domain.User, *domain.Booking) {
+	guest := &domain.User{Id: uuid.New()}
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: guest.Id, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 1),
+	}))
+	created, err := bookings.GetBookingsByUserId(guest.Id.String())
+	assert.NoError(t, err)
+	booking := &created[0]
+
+	_, err = bookings.CheckIn(booking.Id.String(), checkIn)
+	assert.NoError(t, err)
+	booking, err = bookings.CheckOut(booking.Id.String())
+	assert.NoError(t, err)
+	return guest, booking
+}
+
+func TestReviewService_OnlyCheckedOutStaysCanReview(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	reviews, bookings := newReviewTestServices(db)
+
+	request := func(bookingId uuid.UUID) *domain.CreateReviewRequest {
+		return &domain.CreateReviewRequest{BookingId: bookingId, Cleanliness: 5, Location: 4, Service: 3}
+	}
+
+	// An upcoming stay cannot be reviewed yet
+	upcoming := &domain.User{Id: uuid.New()}
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: upcoming.Id, RoomId: roomId, CheckInDate: time.Now().AddDate(0, 0, 5), CheckOutDate: time.Now().AddDate(0, 0, 6),
+	}))
+	upcomingBookings, err := bookings.GetBookingsByUserId(upcoming.Id.String())
+	assert.NoError(t, err)
+	_, err = reviews.CreateReview(hotelId.String(), upcoming, request(upcomingBookings[0].Id))
+	assert.ErrorIs(t, err, ErrReviewNotAllowed)
+
+	guest, stay := createCheckedOutStay(t, bookings, hotelId, roomId, time.Now().AddDate(0, 0, -3))
+
+	// Someone else's stay or another hotel is rejected
+	_, err = reviews.CreateReview(hotelId.String(), &domain.User{Id: uuid.New()}, request(stay.Id))
+	assert.ErrorIs(t, err, ErrReviewNotAllowed)
+	_, err = reviews.CreateReview(uuid.New().String(), guest, request(stay.Id))
+	assert.ErrorIs(t, err, ErrReviewNotAllowed)
+
+	review, err := reviews.CreateReview(hotelId.String(), guest, request(stay.Id))
+	assert.NoError(t, err)
+	assert.Equal(t, 4.0, review.Rating)
+	assert.Equal(t, domain.ReviewStatusPending, review.Status)
+	assert.Empty(t, review.Flags)
+
+	_, err = reviews.CreateReview(hotelId.String(), guest, request(stay.Id))
+	assert.ErrorIs(t, err, ErrAlreadyReviewed)
+}
+
+func TestReviewService_HotelRatingFollowsApprovedReviews(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	hotelRepo := repository.NewHotelRepository(db)
+	reviews, bookings := newReviewTestServices(db)
+	moderator := &domain.User{Id: uuid.New(), IsAdmin: true}
+
+	firstGuest, firstStay := createCheckedOutStay(t, bookings, hotelId, roomId, time.Now().AddDate(0, 0, -10))
+	secondGuest, secondStay := createCheckedOutStay(t, bookings, hotelId, roomId, time.Now().AddDate(0, 0, -5))
+
+	high, err := reviews.CreateReview(hotelId.String(), firstGuest, &domain.CreateReviewRequest{
+		BookingId: firstStay.Id, Cleanliness: 5, Location: 5, Service: 5,
+	})
+	assert.NoError(t, err)
+	low, err := reviews.CreateReview(hotelId.String(), secondGuest, &domain.CreateReviewRequest{
+		BookingId: secondStay.Id, Cleanliness: 2, Location: 3, Service: 2,
+	})
+	assert.NoError(t, err)
+
+	// Pending reviews do not count yet
+	hotel, err := hotelRepo.GetHotelById(hotelId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 0, hotel.ReviewCount)
+
+	approve := &domain.ModerateReviewRequest{Action: domain.ModerationActionApprove}
+	_, err = reviews.ModerateReview(high.Id.String(), moderator, approve)
+	assert.NoError(t, err)
+	_, err = reviews.ModerateReview(low.Id.String(), moderator, approve)
+	assert.NoError(t, err)
+
+	hotel, err = hotelRepo.GetHotelById(hotelId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 2, hotel.ReviewCount)
+	assert.Equal(t, 3.67, hotel.Rating)
+
+	// Hiding a review removes it from the aggregate
+	_, err = reviews.ModerateReview(low.Id.String(), moderator, &domain.ModerateReviewRequest{Action: domain.ModerationActionHide, Reason: "Off topic"})
+	assert.NoError(t, err)
+	hotel, err = hotelRepo.GetHotelById(hotelId.String())
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hotel.ReviewCount)
+	assert.Equal(t, 5.0, hotel.Rating)
+
+	replied, err := reviews.ReplyToReview(hotelId.String(), low.Id.String(), "Sorry to hear that")
+	assert.NoError(t, err)
+	assert.Equal(t, "Sorry to hear that", replied.Reply)
+	assert.NotNil(t, replied.RepliedAt)
+
+	_, err = reviews.ReplyToReview(uuid.New().String(), low.Id.String(), "Wrong hotel")
+	assert.ErrorIs(t, err, ErrReviewNotFound)
+
+	published, err := reviews.GetReviewsByHotelId(hotelId.String())
+	assert.NoError(t, err)
+	assert.Len(t, published, 1)
+}
+
+func TestReviewService_ScreeningFlagsSuspiciousReviews(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	reviews, bookings := newReviewTestServices(db)
+
+	copied := "The breakfast buffet was wonderful and the pool was spotless every day."
+	tests := []struct {
+		name    string
+		title   string
+		comment string
+		flags   []domain.ReviewFlag
+	}{
+		{name: "clean review", title: "Great stay", comment: copied, flags: nil},
+		{name: "banned word", title: "Total SCAM", comment: "Nothing was as advertised.", flags: []domain.ReviewFlag{domain.ReviewFlagBannedWords}},
+		{name: "link", title: "Cheap deals", comment: "Book through www.example.com instead", flags: []domain.ReviewFlag{domain.ReviewFlagContainsLink}},
+		{name: "bare domain", title: "Cheap deals", comment: "Visit cheaprooms.xyz", flags: []domain.ReviewFlag{domain.ReviewFlagContainsLink}},
+		{name: "duplicate text", title: "Nice", comment: "  the breakfast buffet was WONDERFUL, and the pool was spotless every day!", flags: []domain.ReviewFlag{domain.ReviewFlagDuplicateText}},
+		{name: "short repeated text", title: "Nice", comment: "Great stay", flags: nil},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			guest, stay := createCheckedOutStay(t, bookings, hotelId, roomId, time.Now().AddDate(0, 0, -30+i*2))
+			review, err := reviews.CreateReview(hotelId.String(), guest, &domain.CreateReviewRequest{
+				BookingId: stay.Id, Cleanliness: 3, Location: 3, Service: 3, Title: tt.title, Comment: tt.comment,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, tt.flags, review.Flags)
+		})
+	}
+
+	// Flagged reviews are at the front of the queue
+	queue, err := reviews.GetModerationQueue()
+	assert.NoError(t, err)
+	assert.Len(t, queue, len(tests))
+	assert.NotEmpty(t, queue[0].Flags)
+	assert.Empty(t, queue[len(queue)-1].Flags)
+}
+
+func TestReviewService_ReportsAndModerationAudit(t *testing.T) {
+	db := setupReviewServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	reviews, bookings := newReviewTestServices(db)
+	moderator := &domain.User{Id: uuid.New(), IsAdmin: true}
+
+	guest, stay := createCheckedOutStay(t, bookings, hotelId, roomId, time.Now().AddDate(0, 0, -3))
+	review, err := reviews.CreateReview(hotelId.String(), guest, &domain.CreateReviewRequest{
+		BookingId: stay.Id, Cleanliness: 4, Location: 4, Service: 4,
+	})
+	assert.NoError(t, err)
+
+	// Only published reviews can be reported
+	reporter := &domain.User{Id: uuid.New()}
+	assert.ErrorIs(t, reviews.ReportReview(review.Id.String(), reporter, "Fake"), ErrReviewNotFound)
+
+	_, err = reviews.ModerateReview(review.Id.String(), moderator, &domain.ModerateReviewRequest{Action: domain.ModerationActionApprove})
+	assert.NoError(t, err)
+	queue, err := reviews.GetModerationQueue()
+	assert.NoError(t, err)
+	assert.Empty(t, queue)
+
+	assert.NoError(t, reviews.ReportReview(review.Id.String(), reporter, "Fake"))
+	assert.ErrorIs(t, reviews.ReportReview(review.Id.String(), reporter, "Still fake"), ErrAlreadyReported)
+
+	queue, err = reviews.GetModerationQueue()
+	assert.NoError(t, err)
+	assert.Len(t, queue, 1)
+	assert.Equal(t, 1, queue[0].ReportCount)
+
+	_, err = reviews.ModerateReview(review.Id.String(), moderator, &domain.ModerateReviewRequest{Action: domain.ModerationActionReject})
+	assert.ErrorIs(t, err, ErrModerationReasonRequired)
+
+	rejected, err := reviews.ModerateReview(review.Id.String(), moderator, &domain.ModerateReviewRequest{Action: domain.ModerationActionReject, Reason: "Not a genuine stay"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.ReviewStatusRejected, rejected.Status)
+	assert.Equal(t, 0, rejected.ReportCount)
+
+	history, err := reviews.GetModerationHistory(review.Id.String())
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+	assert.Equal(t, AuditActionReviewApproved, history[0].Action)
+	assert.Equal(t, AuditActionReviewRejected, history[1].Action)
+	assert.Equal(t, "Not a genuine stay", history[1].Details)
+	assert.Equal(t, moderator.Id, *history[1].ActorId)
+}
@@ -0,0 +1,325 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUserNotFound           = errors.New("user not found")
+	ErrInvalidRole            = errors.New("invalid role")
+	ErrPermissionDenied       = errors.New("you do not have permission to do this")
+	ErrRoleAlreadyGranted     = errors.New("user already has this role")
+	ErrRoleAssignmentNotFound = errors.New("user does not have this role")
+)
+
+// roleOrder lists the roles in the order the admin API presents them
+var roleOrder = []domain.Role{domain.RoleSuperAdmin, domain.RoleHotelOwner, domain.RoleHotelManager, domain.RoleFrontDesk, domain.RoleGuest}
+
+type RoleService interface {
+	HasPermission(user *domain.User, permission domain.Permission, hotelId *uuid.UUID) (bool, error)
+	IsSuperAdmin(user *domain.User) (bool, error)
+	GetRoleDefinitions() []domain.RoleDefinition
+	GetUserRoles(actor *domain.User, userId string) (*domain.UserRoles, error)
+	GrantRole(actor *domain.User, userId string, request *domain.RoleGrantRequest) (*domain.UserRoles, error)
+	RevokeRole(actor *domain.User, userId string, request *domain.RoleGrantRequest) (*domain.UserRoles, error)
+	CheckRoleChange(actor *domain.User, request *domain.RoleGrantRequest) error
+}
+
+type roleService struct {
+	roleRepository         repository.RoleRepository
+	userRepository         repository.UserRepository
+	hotelRepository        repository.HotelRepository
+	organizationRepository repository.OrganizationRepository
+	authRepository         repository.IAuthRepository
+	audit                  AuditService
+}
+
+func NewRoleService(roleRepository repository.RoleRepository, userRepository repository.UserRepository, hotelRepository repository.HotelRepository, organizationRepository repository.OrganizationRepository, authRepository repository.IAuthRepository, audit AuditService) RoleService {
+	return &roleService{
+		roleRepository:         roleRepository,
+		userRepository:         userRepository,
+		hotelRepository:        hotelRepository,
+		organizationRepository: organizationRepository,
+		authRepository:         authRepository,
+		audit:                  audit,
+	}
+}
+
+/*
+HasPermission
+Params: user, permission, hotel the action is on (nil for actions that are not about one hotel)
+Returns: whether the user may act, error
+Description: Super admins may do anything. Other users need a role carrying the permission,
+assigned for the hotel itself or for the organization that owns it.
+*/
+func (s *roleService) HasPermission(user *domain.User, permission domain.Permission, hotelId *uuid.UUID) (bool, error) {
+	if user == nil {
+		return false, nil
+	}
+	admin, err := s.IsSuperAdmin(user)
+	if err != nil {
+		return false, err
+	}
+	if admin {
+		return true, nil
+	}
+
+	var roles []domain.Role
+	for role, permissions := range domain.RolePermissions {
+		for _, granted := range permissions {
+			if granted == permission {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	return s.roleRepository.HasAnyRole(user.Id, roles, hotelId)
+}
+
+/*
+IsSuperAdmin
+Params: user from the access token
+Returns: whether the user is a super admin, error
+Description: The token has to claim admin rights and the stored user has to still hold them, so revoking
+super_admin takes effect on the user's next request instead of when their access token expires.
+*/
+func (s *roleService) IsSuperAdmin(user *domain.User) (bool, error) {
+	if user == nil || !user.IsAdmin {
+		return false, nil
+	}
+	stored, err := s.userRepository.GetUserById(user.Id.String())
+	if err != nil {
+		// A user that no longer exists holds no rights
+		return false, nil
+	}
+	return stored.IsAdmin, nil
+}
+
+func (s *roleService) GetRoleDefinitions() []domain.RoleDefinition {
+	definitions := make([]domain.RoleDefinition, 0, len(roleOrder))
+	for _, role := range roleOrder {
+		permissions := domain.RolePermissions[role]
+		if role == domain.RoleSuperAdmin {
+			permissions = allPermissions()
+		}
+		definitions = append(definitions, domain.RoleDefinition{Role: role, Permissions: permissions})
+	}
+	return definitions
+}
+
+// GetUserRoles shows a user's roles to the user themselves or to a super admin
+func (s *roleService) GetUserRoles(actor *domain.User, userId string) (*domain.UserRoles, error) {
+	if actor == nil {
+		return nil, ErrPermissionDenied
+	}
+	if actor.Id.String() != userId {
+		admin, err := s.IsSuperAdmin(actor)
+		if err != nil {
+			return nil, err
+		}
+		if !admin {
+			return nil, ErrPermissionDenied
+		}
+	}
+	return s.userRoles(userId)
+}
+
+func (s *roleService) userRoles(userId string) (*domain.UserRoles, error) {
+	user, err := s.userRepository.GetUserById(userId)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	assignments, err := s.roleRepository.GetAssignmentsByUserId(userId)
+	if err != nil {
+		return nil, err
+	}
+	return &domain.UserRoles{UserId: user.Id, SuperAdmin: user.IsAdmin, Assignments: assignments}, nil
+}
+
+/*
+GrantRole
+Params: acting user, user receiving the role, role and scope
+Returns: the user's roles afterwards, error
+Description: Super admins may grant any role. A user allowed to manage roles at a hotel may make
+other users managers or front desk staff of that hotel. Granting super_admin sets the user's admin flag,
+which takes effect from their next login.
+*/
+func (s *roleService) GrantRole(actor *domain.User, userId string, request *domain.RoleGrantRequest) (*domain.UserRoles, error) {
+	user, err := s.authorizeRoleChange(actor, userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Role == domain.RoleSuperAdmin {
+		if user.IsAdmin {
+			return nil, ErrRoleAlreadyGranted
+		}
+		user.IsAdmin = true
+		if err := s.userRepository.UpdateUser(user); err != nil {
+			return nil, err
+		}
+	} else {
+		if _, err := s.roleRepository.FindAssignment(userId, request.Role, request.HotelId, request.OrganizationId); err == nil {
+			return nil, ErrRoleAlreadyGranted
+		}
+		assignment := &domain.RoleAssignment{
+			Id:             uuid.New(),
+			UserId:         user.Id,
+			Role:           request.Role,
+			HotelId:        request.HotelId,
+			OrganizationId: request.OrganizationId,
+			GrantedBy:      &actor.Id,
+		}
+		if err := s.roleRepository.CreateAssignment(assignment); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.audit.Record(&actor.Id, AuditActionRoleGranted, AuditEntityUser, user.Id, describeRoleGrant(request)); err != nil {
+		return nil, err
+	}
+	return s.userRoles(userId)
+}
+
+// RevokeRole removes a role with exactly the given scope, under the same rules as GrantRole. Revoking super
+// admin ends the user's admin access from their next request and signs them out everywhere.
+func (s *roleService) RevokeRole(actor *domain.User, userId string, request *domain.RoleGrantRequest) (*domain.UserRoles, error) {
+	user, err := s.authorizeRoleChange(actor, userId, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if request.Role == domain.RoleSuperAdmin {
+		if !user.IsAdmin {
+			return nil, ErrRoleAssignmentNotFound
+		}
+		if user.Id == actor.Id {
+			return nil, fmt.Errorf("%w: super admins cannot revoke their own role", ErrPermissionDenied)
+		}
+		if _, err := s.authRepository.RevokeUserRefreshTokens(userId, time.Now()); err != nil {
+			return nil, err
+		}
+		user.IsAdmin = false
+		if err := s.userRepository.UpdateUser(user); err != nil {
+			return nil, err
+		}
+	} else {
+		deleted, err := s.roleRepository.DeleteAssignment(userId, request.Role, request.HotelId, request.OrganizationId)
+		if err != nil {
+			return nil, err
+		}
+		if !deleted {
+			return nil, ErrRoleAssignmentNotFound
+		}
+	}
+
+	if err := s.audit.Record(&actor.Id, AuditActionRoleRevoked, AuditEntityUser, user.Id, describeRoleGrant(request)); err != nil {
+		return nil, err
+	}
+	return s.userRoles(userId)
+}
+
+// authorizeRoleChange validates the role and scope and checks the actor may change it, returning the target user
+func (s *roleService) authorizeRoleChange(actor *domain.User, userId string, request *domain.RoleGrantRequest) (*domain.User, error) {
+	if err := s.CheckRoleChange(actor, request); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepository.GetUserById(userId)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// CheckRoleChange validates the role and scope and checks the actor may grant or revoke it
+func (s *roleService) CheckRoleChange(actor *domain.User, request *domain.RoleGrantRequest) error {
+	if actor == nil {
+		return ErrPermissionDenied
+	}
+	if err := s.validateRoleScope(request); err != nil {
+		return err
+	}
+
+	// Only super admins hand out super admin, ownership and organization-wide roles
+	delegable := (request.Role == domain.RoleHotelManager || request.Role == domain.RoleFrontDesk) && request.HotelId != nil
+	admin, err := s.IsSuperAdmin(actor)
+	if err != nil {
+		return err
+	}
+	if admin {
+		return nil
+	}
+	if !delegable {
+		return ErrPermissionDenied
+	}
+	allowed, err := s.HasPermission(actor, domain.PermissionRolesManage, request.HotelId)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return ErrPermissionDenied
+	}
+	return nil
+}
+
+func (s *roleService) validateRoleScope(request *domain.RoleGrantRequest) error {
+	switch request.Role {
+	case domain.RoleSuperAdmin:
+		if request.HotelId != nil || request.OrganizationId != nil {
+			return fmt.Errorf("%w: super_admin applies everywhere and takes no hotel or organization", ErrInvalidRole)
+		}
+		return nil
+	case domain.RoleHotelOwner, domain.RoleHotelManager, domain.RoleFrontDesk:
+	case domain.RoleGuest:
+		return fmt.Errorf("%w: every user is a guest", ErrInvalidRole)
+	default:
+		return fmt.Errorf("%w: %s", ErrInvalidRole, request.Role)
+	}
+
+	if (request.HotelId == nil) == (request.OrganizationId == nil) {
+		return fmt.Errorf("%w: %s needs exactly one of hotel_id and organization_id", ErrInvalidRole, request.Role)
+	}
+	if request.HotelId != nil {
+		if _, err := s.hotelRepository.GetHotelById(request.HotelId.String()); err != nil {
+			return ErrHotelNotFound
+		}
+	}
+	if request.OrganizationId != nil {
+		if _, err := s.organizationRepository.GetOrganizationById(request.OrganizationId.String()); err != nil {
+			return ErrOrganizationNotFound
+		}
+	}
+	return nil
+}
+
+func describeRoleGrant(request *domain.RoleGrantRequest) string {
+	switch {
+	case request.HotelId != nil:
+		return fmt.Sprintf("%s at hotel %s", request.Role, request.HotelId)
+	case request.OrganizationId != nil:
+		return fmt.Sprintf("%s at organization %s", request.Role, request.OrganizationId)
+	default:
+		return string(request.Role)
+	}
+}
+
+func allPermissions() []domain.Permission {
+	seen := map[domain.Permission]bool{}
+	var permissions []domain.Permission
+	for _, role := range roleOrder {
+		for _, permission := range domain.RolePermissions[role] {
+			if !seen[permission] {
+				seen[permission] = true
+				permissions = append(permissions, permission)
+			}
+		}
+	}
+	return permissions
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupRoleServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupOrganizationServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			username TEXT UNIQUE,
+			email TEXT UNIQUE,
+			password TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
+		);
+		CREATE TABLE role_assignments (
+			id TEXT PRIMARY KEY,
+			user_id TEXT,
+			role TEXT,
+			hotel_id TEXT,
+			organization_id TEXT,
+			granted_by TEXT,
+			created_at DATETIME
+		);
+		CREATE TABLE audit_entries (
+			id TEXT PRIMARY KEY,
+			actor_id TEXT,
+			action TEXT,
+			entity_type TEXT,
+			entity_id TEXT,
+			details TEXT,
+			created_at DATETIME
+		);
+		CREATE TABLE refresh_tokens (
+			id TEXT PRIMARY KEY,
+			family_id TEXT,
+			user_id TEXT,
+			token_hash TEXT UNIQUE,
+			device TEXT,
+			expires_at DATETIME,
+			rotated_at DATETIME,
+			replaced_by_id TEXT,
+			revoked_at DATETIME,
+			mfa_verified INTEGER DEFAULT 0,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func newRoleTestService(db *gorm.DB) RoleService {
+	return NewRoleService(
+		repository.NewRoleRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewHotelRepository(db),
+		repository.NewOrganizationRepository(db),
+		repository.NewAuthRepository(db),
+		NewAuditService(repository.NewAuditRepository(db)),
+	)
+}
+
+func createRoleTestUser(t *testing.T, db *gorm.DB, name string, isAdmin bool) *domain.User {
+	user := &domain.User{Username: name, Email: name + "@example.com", Password: "password123", IsAdmin: isAdmin}
+	if err := repository.NewUserRepository(db).CreateUser(user); err != nil {
+		t.Fatalf("Failed to create user: %v", err)
+	}
+	return user
+}
+
+func TestRoleService_PermissionsFollowHotelAndOrganizationRoles(t *testing.T) {
+	db := setupRoleServiceTestDB(t)
+	roles := newRoleTestService(db)
+	organizations := NewOrganizationService(repository.NewOrganizationRepository(db), repository.NewHotelRepository(db))
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	desk := createRoleTestUser(t, db, "desk", false)
+	owner := createRoleTestUser(t, db, "owner", false)
+
+	hotelId, otherHotelId := uuid.New(), uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+	assert.NoError(t, createTestHotelAndRoom(db, otherHotelId, uuid.New(), 100))
+	organization, err := organizations.CreateOrganization(&domain.OrganizationRequest{Name: "Chain"})
+	assert.NoError(t, err)
+	_, err = organizations.MoveHotel(otherHotelId.String(), &domain.MoveHotelRequest{OrganizationId: &organization.Id}, &admin.Id)
+	assert.NoError(t, err)
+
+	_, err = roles.GrantRole(admin, desk.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleFrontDesk, HotelId: &hotelId})
+	assert.NoError(t, err)
+	_, err = roles.GrantRole(admin, owner.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelOwner, OrganizationId: &organization.Id})
+	assert.NoError(t, err)
+
+	allowed, err := roles.HasPermission(desk, domain.PermissionBookingsCheckIn, &hotelId)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+	allowed, _ = roles.HasPermission(desk, domain.PermissionRatesManage, &hotelId)
+	assert.False(t, allowed)
+	allowed, _ = roles.HasPermission(desk, domain.PermissionBookingsCheckIn, &otherHotelId)
+	assert.False(t, allowed)
+
+	allowed, _ = roles.HasPermission(owner, domain.PermissionRatesManage, &otherHotelId)
+	assert.True(t, allowed)
+	allowed, _ = roles.HasPermission(owner, domain.PermissionRatesManage, &hotelId)
+	assert.False(t, allowed)
+
+	allowed, _ = roles.HasPermission(admin, domain.PermissionRolesManage, &hotelId)
+	assert.True(t, allowed)
+}
+
+func TestRoleService_GrantAndRevokeRules(t *testing.T) {
+	db := setupRoleServiceTestDB(t)
+	roles := newRoleTestService(db)
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	owner := createRoleTestUser(t, db, "owner", false)
+	staff := createRoleTestUser(t, db, "staff", false)
+
+	hotelId, otherHotelId := uuid.New(), uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+	assert.NoError(t, createTestHotelAndRoom(db, otherHotelId, uuid.New(), 100))
+
+	_, err := roles.GrantRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelManager, HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = roles.GrantRole(admin, owner.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelOwner, HotelId: &hotelId})
+	assert.NoError(t, err)
+	_, err = roles.GrantRole(admin, owner.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelOwner, HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrRoleAlreadyGranted)
+
+	// Owners delegate staff roles at their own hotel only, and never ownership or super admin
+	granted, err := roles.GrantRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelManager, HotelId: &hotelId})
+	assert.NoError(t, err)
+	assert.Len(t, granted.Assignments, 1)
+	_, err = roles.GrantRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleFrontDesk, HotelId: &otherHotelId})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = roles.GrantRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelOwner, HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = roles.GrantRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleSuperAdmin})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	// Managers cannot hand out roles themselves
+	_, err = roles.GrantRole(staff, owner.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleFrontDesk, HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	_, err = roles.GrantRole(admin, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleFrontDesk})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	_, err = roles.GrantRole(admin, staff.Id.String(), &domain.RoleGrantRequest{Role: "janitor", HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+	missingHotel := uuid.New()
+	_, err = roles.GrantRole(admin, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleFrontDesk, HotelId: &missingHotel})
+	assert.ErrorIs(t, err, ErrHotelNotFound)
+
+	revoked, err := roles.RevokeRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelManager, HotelId: &hotelId})
+	assert.NoError(t, err)
+	assert.Empty(t, revoked.Assignments)
+	_, err = roles.RevokeRole(owner, staff.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelManager, HotelId: &hotelId})
+	assert.ErrorIs(t, err, ErrRoleAssignmentNotFound)
+
+	var audited int64
+	db.Table("audit_entries").Where("entity_id = ?", staff.Id.String()).Count(&audited)
+	assert.Equal(t, int64(2), audited)
+}
+
+func TestRoleService_SuperAdminUsesAdminFlag(t *testing.T) {
+	db := setupRoleServiceTestDB(t)
+	roles := newRoleTestService(db)
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	user := createRoleTestUser(t, db, "user", false)
+
+	granted, err := roles.GrantRole(admin, user.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleSuperAdmin})
+	assert.NoError(t, err)
+	assert.True(t, granted.SuperAdmin)
+
+	stored, err := repository.NewUserRepository(db).GetUserById(user.Id.String())
+	assert.NoError(t, err)
+	assert.True(t, stored.IsAdmin)
+
+	_, err = roles.RevokeRole(admin, admin.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleSuperAdmin})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+
+	// An access token issued while the user was a super admin stops carrying admin rights once it is revoked
+	tokenUser := &domain.User{Id: user.Id, IsAdmin: true}
+	admitted, err := roles.IsSuperAdmin(tokenUser)
+	assert.NoError(t, err)
+	assert.True(t, admitted)
+
+	// Revoking super admin ends the user's sessions
+	session := &domain.RefreshToken{Id: uuid.New(), FamilyId: uuid.New(), UserId: user.Id, TokenHash: "hash", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.NoError(t, db.Create(session).Error)
+	revoked, err := roles.RevokeRole(admin, user.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleSuperAdmin})
+	assert.NoError(t, err)
+	assert.False(t, revoked.SuperAdmin)
+	assert.NoError(t, db.First(session, "id = ?", session.Id).Error)
+	assert.NotNil(t, session.RevokedAt)
+	admitted, err = roles.IsSuperAdmin(tokenUser)
+	assert.NoError(t, err)
+	assert.False(t, admitted)
+	allowed, err := roles.HasPermission(tokenUser, domain.PermissionBookingsRead, nil)
+	assert.NoError(t, err)
+	assert.False(t, allowed)
+
+	_, err = roles.GetUserRoles(user, user.Id.String())
+	assert.NoError(t, err)
+	_, err = roles.GetUserRoles(&domain.User{Id: uuid.New()}, user.Id.String())
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+}
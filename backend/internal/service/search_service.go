@@ -0,0 +1,267 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	maxSearchMatches   = 200
+	maxSuggestions     = 10
+	minCorrectableTerm = 4
+	// vocabularyTTL is how long the typo-correction vocabulary is kept before it is loaded again,
+	// picking up words indexed by other servers
+	vocabularyTTL = 5 * time.Minute
+)
+
+// HotelTextSearch keeps the full-text index in step with hotels and answers text queries
+type HotelTextSearch interface {
+	IndexHotel(hotel *domain.Hotel) error
+	MatchHotels(query string) ([]domain.SearchMatch, error)
+}
+
+type SearchService interface {
+	HotelTextSearch
+	RebuildIndex() error
+	Suggest(prefix string) ([]domain.SearchSuggestion, error)
+}
+
+type searchService struct {
+	searchRepository repository.SearchRepository
+	hotelRepository  repository.HotelRepository
+
+	// vocabulary caches the indexed words by length for typo correction
+	vocabularyMu       sync.Mutex
+	vocabulary         map[int][]string
+	vocabularyLoadedAt time.Time
+}
+
+func NewSearchService(searchRepository repository.SearchRepository, hotelRepository repository.HotelRepository) SearchService {
+	return &searchService{searchRepository: searchRepository, hotelRepository: hotelRepository}
+}
+
+// IndexHotel stores the hotel's name, description, address and room facility names in the search index
+func (s *searchService) IndexHotel(hotel *domain.Hotel) error {
+	seen := map[string]bool{}
+	var facilities []string
+	for _, room := range hotel.Rooms {
+		for _, facility := range room.Facilities {
+			if facility != nil && !seen[facility.Name] {
+				seen[facility.Name] = true
+				facilities = append(facilities, facility.Name)
+			}
+		}
+	}
+
+	address := strings.Join(nonEmpty(hotel.Address, hotel.City, hotel.Country), ", ")
+	document := &domain.HotelSearchDocument{
+		HotelId:     hotel.Id,
+		Name:        hotel.Name,
+		Description: hotel.Description,
+		Address:     address,
+		Facilities:  strings.Join(facilities, ", "),
+	}
+
+	var terms []string
+	added := map[string]bool{}
+	for _, word := range searchWords(strings.Join([]string{document.Name, document.Description, document.Address, document.Facilities}, " ")) {
+		if len([]rune(word)) >= minCorrectableTerm && !added[word] {
+			added[word] = true
+			terms = append(terms, word)
+		}
+	}
+	if err := s.searchRepository.IndexHotel(document, terms); err != nil {
+		return err
+	}
+	s.forgetVocabulary()
+	return nil
+}
+
+// RebuildIndex creates the search tables if needed and indexes every hotel again
+func (s *searchService) RebuildIndex() error {
+	if err := s.searchRepository.EnsureIndex(); err != nil {
+		return err
+	}
+	hotels, err := s.hotelRepository.GetAllHotels()
+	if err != nil {
+		return err
+	}
+	for i := range hotels {
+		if err := s.IndexHotel(&hotels[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+/*
+MatchHotels
+Params: free text query
+Returns: every matching hotel ordered by relevance, error
+Description: Split the query into words and correct misspelt words to the closest word in the index
+before matching, so "oceanveiw" still finds "Oceanview". Words that already start a known word are kept
+as they are, letting a partially typed word match. The matches are not limited here, so that the caller
+can apply its other filters first.
+*/
+func (s *searchService) MatchHotels(query string) ([]domain.SearchMatch, error) {
+	words := searchWords(query)
+	terms := make([]string, 0, len(words))
+	for _, word := range words {
+		term, err := s.correctTerm(word)
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, term)
+	}
+	return s.searchRepository.MatchHotels(terms)
+}
+
+// correctTerm returns the closest indexed word within the edit distance allowed for the word's length
+func (s *searchService) correctTerm(word string) (string, error) {
+	length := len([]rune(word))
+	if length < minCorrectableTerm {
+		return word, nil
+	}
+	known, err := s.searchRepository.HasTermWithPrefix(word)
+	if err != nil || known {
+		return word, err
+	}
+
+	maxEdits := 1
+	if length >= 8 {
+		maxEdits = 2
+	}
+	vocabulary, err := s.loadVocabulary()
+	if err != nil {
+		return word, err
+	}
+
+	best, bestDistance := word, maxEdits+1
+	for candidateLength := length - maxEdits; candidateLength <= length+maxEdits; candidateLength++ {
+		for _, candidate := range vocabulary[candidateLength] {
+			distance := editDistance(word, candidate)
+			if distance < bestDistance || (distance == bestDistance && candidate < best) {
+				best, bestDistance = candidate, distance
+			}
+		}
+	}
+	return best, nil
+}
+
+// loadVocabulary returns the indexed words grouped by length, loading them when the cache is empty or stale
+func (s *searchService) loadVocabulary() (map[int][]string, error) {
+	s.vocabularyMu.Lock()
+	defer s.vocabularyMu.Unlock()
+	if s.vocabulary != nil && time.Since(s.vocabularyLoadedAt) < vocabularyTTL {
+		return s.vocabulary, nil
+	}
+
+	terms, err := s.searchRepository.GetTerms()
+	if err != nil {
+		return nil, err
+	}
+	vocabulary := make(map[int][]string)
+	for _, term := range terms {
+		length := len([]rune(term))
+		vocabulary[length] = append(vocabulary[length], term)
+	}
+	s.vocabulary, s.vocabularyLoadedAt = vocabulary, time.Now()
+	return vocabulary, nil
+}
+
+// forgetVocabulary drops the cached vocabulary so the next correction sees newly indexed words
+func (s *searchService) forgetVocabulary() {
+	s.vocabularyMu.Lock()
+	defer s.vocabularyMu.Unlock()
+	s.vocabulary = nil
+}
+
+/*
+Suggest
+Params: what the user has typed so far
+Returns: matching cities followed by matching hotel names, error
+Description: Autocomplete for the search box. A suggestion matches when its text, or any word in it,
+starts with the prefix.
+*/
+func (s *searchService) Suggest(prefix string) ([]domain.SearchSuggestion, error) {
+	prefix = strings.TrimSpace(prefix)
+	suggestions := []domain.SearchSuggestion{}
+	if prefix == "" {
+		return suggestions, nil
+	}
+
+	cities, err := s.searchRepository.SuggestCities(prefix, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	for _, city := range cities {
+		suggestions = append(suggestions, domain.SearchSuggestion{Type: domain.SearchSuggestionCity, Text: city})
+	}
+
+	hotels, err := s.searchRepository.SuggestHotels(prefix, maxSuggestions)
+	if err != nil {
+		return nil, err
+	}
+	for _, hotel := range hotels {
+		hotelId := hotel.Id
+		suggestions = append(suggestions, domain.SearchSuggestion{Type: domain.SearchSuggestionHotel, Text: hotel.Name, HotelId: &hotelId})
+	}
+
+	// Names that start with the prefix are better completions than those that only contain a word starting with it
+	lower := strings.ToLower(prefix)
+	sort.SliceStable(suggestions, func(i, j int) bool {
+		return strings.HasPrefix(strings.ToLower(suggestions[i].Text), lower) && !strings.HasPrefix(strings.ToLower(suggestions[j].Text), lower)
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions, nil
+}
+
+// searchWords lowercases text and splits it into words of letters and digits
+func searchWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c)
+	})
+}
+
+func nonEmpty(values ...string) []string {
+	var result []string
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			result = append(result, value)
+		}
+	}
+	return result
+}
+
+// editDistance is the Levenshtein distance, counting an adjacent transposition as one edit
+func editDistance(a string, b string) int {
+	s, t := []rune(a), []rune(b)
+	rows := make([][]int, len(s)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(t)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(s); i++ {
+		for j := 1; j <= len(t); j++ {
+			cost := 1
+			if s[i-1] == t[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && s[i-1] == t[j-2] && s[i-2] == t[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(s)][len(t)]
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newSearchTestServices(t *testing.T) (*gorm.DB, SearchService, HotelService) {
+	db := setupBookingServiceTestDB(t)
+	hotelRepo := repository.NewHotelRepository(db)
+	search := NewSearchService(repository.NewSearchRepository(db), hotelRepo)
+	assert.NoError(t, search.RebuildIndex())
+	return db, search, NewHotelService(hotelRepo, repository.NewOrganizationRepository(db), search, nil)
+}
+
+func createSearchTestHotels(t *testing.T, hotels HotelService) map[string]uuid.UUID {
+	ids := map[string]uuid.UUID{}
+	for _, hotel := range []*domain.Hotel{
+		{
+			Name: "Oceanview Resort", Description: "Beachfront rooms with sunset views", Address: "Jl. Pantai 1",
+			City: "Denpasar", Country: "Indonesia",
+			Rooms: []*domain.Room{{Price: 120, Facilities: []*domain.Facility{{Name: "Infinity Pool"}}}},
+		},
+		{
+			Name: "Mountain Lodge", Description: "Quiet cabins, a short walk from the ocean", Address: "Jl. Raya Ubud",
+			City: "Ubud", Country: "Indonesia",
+			Rooms: []*domain.Room{{Price: 80, Facilities: []*domain.Facility{{Name: "Sauna"}}}},
+		},
+		{
+			Name: "City Hostel", Description: "Dorms in the old town", Address: "Main Street 5",
+			City: "Denpasar", Country: "Indonesia",
+		},
+	} {
+		hotel.Id = uuid.New()
+		for _, room := range hotel.Rooms {
+			room.Id = uuid.New()
+			for _, facility := range room.Facilities {
+				facility.Id = uuid.New()
+			}
+		}
+		assert.NoError(t, hotels.CreateHotel(hotel))
+		ids[hotel.Name] = hotel.Id
+	}
+	return ids
+}
+
+func TestSearchService_RanksByRelevance(t *testing.T) {
+	_, _, hotels := newSearchTestServices(t)
+	ids := createSearchTestHotels(t, hotels)
+
+	// A match in the name outranks one in the description
+	results, err := hotels.SearchHotels(&domain.HotelSearch{Query: "ocean"})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 2) {
+		return
+	}
+	assert.Equal(t, ids["Oceanview Resort"], results[0].Id)
+	assert.Equal(t, ids["Mountain Lodge"], results[1].Id)
+	assert.Greater(t, *results[0].Relevance, *results[1].Relevance)
+
+	// Facility names and the address are searchable, and every word must match
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "sauna ubud"})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, ids["Mountain Lodge"], results[0].Id)
+
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "sauna denpasar"})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	// The text query combines with the other filters
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "ocean", City: "Ubud"})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, ids["Mountain Lodge"], results[0].Id)
+}
+
+func TestSearchService_ToleratesTypos(t *testing.T) {
+	_, _, hotels := newSearchTestServices(t)
+	ids := createSearchTestHotels(t, hotels)
+
+	for _, query := range []string{"oceanveiw", "Infinty pool", "hostle"} {
+		results, err := hotels.SearchHotels(&domain.HotelSearch{Query: query})
+		assert.NoError(t, err, query)
+		if !assert.NotEmpty(t, results, query) {
+			continue
+		}
+		if query == "hostle" {
+			assert.Equal(t, ids["City Hostel"], results[0].Id)
+		} else {
+			assert.Equal(t, ids["Oceanview Resort"], results[0].Id, query)
+		}
+	}
+
+	results, err := hotels.SearchHotels(&domain.HotelSearch{Query: "xylophone"})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	// Words of hotels added after the vocabulary was loaded are corrected to as well
+	assert.NoError(t, hotels.CreateHotel(&domain.Hotel{Id: uuid.New(), Name: "Xylophone House", City: "Ubud", Country: "Indonesia"}))
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "xylophnoe"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 1)
+}
+
+func TestSearchService_LimitsTextMatchesAfterFilters(t *testing.T) {
+	db, search, hotels := newSearchTestServices(t)
+	for i := 0; i < maxSearchMatches; i++ {
+		assert.NoError(t, db.Exec("INSERT INTO hotels (id, name, description, address, city, country) VALUES (?, ?, ?, ?, ?, ?)",
+			uuid.New().String(), "Ocean Hotel", "", "", "Denpasar", "Indonesia").Error)
+	}
+	// The least relevant match is the only one in its city
+	sanurId := uuid.New()
+	assert.NoError(t, db.Exec("INSERT INTO hotels (id, name, description, address, city, country) VALUES (?, ?, ?, ?, ?, ?)",
+		sanurId.String(), "Harbour Inn", "A long walk from the ocean", "", "Sanur", "Indonesia").Error)
+	assert.NoError(t, search.RebuildIndex())
+
+	results, err := hotels.SearchHotels(&domain.HotelSearch{Query: "ocean"})
+	assert.NoError(t, err)
+	assert.Len(t, results, maxSearchMatches)
+
+	// Facets count the hotels shown, not every match
+	assert.NoError(t, db.Exec("UPDATE hotels SET rating = 4.5").Error)
+	faceted, err := hotels.SearchHotelsWithFacets(&domain.HotelSearch{Query: "ocean"})
+	assert.NoError(t, err)
+	assert.Len(t, faceted.Hotels, maxSearchMatches)
+	assert.Equal(t, int64(maxSearchMatches), faceted.Facets.Ratings[0].Count)
+
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "ocean", City: "Sanur"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, sanurId, results[0].Id)
+	}
+}
+
+func TestSearchService_RebuildIndexPicksUpExistingHotels(t *testing.T) {
+	db, search, hotels := newSearchTestServices(t)
+	hotelId := uuid.New()
+	assert.NoError(t, db.Exec("INSERT INTO hotels (id, name, description, address, city, country) VALUES (?, ?, ?, ?, ?, ?)",
+		hotelId.String(), "Harbour Inn", "Seaside", "Quay 2", "Sanur", "Indonesia").Error)
+
+	results, err := hotels.SearchHotels(&domain.HotelSearch{Query: "harbour"})
+	assert.NoError(t, err)
+	assert.Empty(t, results)
+
+	assert.NoError(t, search.RebuildIndex())
+	results, err = hotels.SearchHotels(&domain.HotelSearch{Query: "harbour"})
+	assert.NoError(t, err)
+	if !assert.Len(t, results, 1) {
+		return
+	}
+	assert.Equal(t, hotelId, results[0].Id)
+}
+
+func TestSearchService_Suggest(t *testing.T) {
+	_, search, hotels := newSearchTestServices(t)
+	ids := createSearchTestHotels(t, hotels)
+
+	suggestions, err := search.Suggest("den")
+	assert.NoError(t, err)
+	if !assert.Len(t, suggestions, 1) {
+		return
+	}
+	assert.Equal(t, domain.SearchSuggestion{Type: domain.SearchSuggestionCity, Text: "Denpasar"}, suggestions[0])
+
+	suggestions, err = search.Suggest("lod")
+	assert.NoError(t, err)
+	if !assert.Len(t, suggestions, 1) {
+		return
+	}
+	assert.Equal(t, domain.SearchSuggestionHotel, suggestions[0].Type)
+	assert.Equal(t, "Mountain Lodge", suggestions[0].Text)
+	assert.Equal(t, ids["Mountain Lodge"], *suggestions[0].HotelId)
+
+	// LIKE wildcards in the prefix are matched literally
+	suggestions, err = search.Suggest("%")
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+
+	suggestions, err = search.Suggest("  ")
+	assert.NoError(t, err)
+	assert.Empty(t, suggestions)
+}
+
+func TestEditDistance(t *testing.T) {
+	assert.Equal(t, 0, editDistance("ocean", "ocean"))
+	assert.Equal(t, 1, editDistance("oceanveiw", "oceanview"))
+	assert.Equal(t, 1, editDistance("hostle", "hostel"))
+	assert.Equal(t, 2, editDistance("resrot", "resort")+editDistance("pol", "pool"))
+	assert.Equal(t, 3, editDistance("", "spa"))
+}
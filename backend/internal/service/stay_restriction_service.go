@@ -0,0 +1,186 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// maxRestrictionRangeDays caps a single bulk update so one request cannot write years of rows
+const maxRestrictionRangeDays = 366
+
+const dateLayout = "2006-01-02"
+
+var ErrStayRestricted = errors.New("stay violates hotel restrictions")
+
+type StayRestrictionService interface {
+	SetRestrictions(hotelId string, request *domain.SetStayRestrictionsRequest) ([]domain.StayRestriction, error)
+	ClearRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) error
+	GetRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) ([]domain.StayRestriction, error)
+	ValidateStay(hotelId uuid.UUID, roomId uuid.UUID, checkIn time.Time, checkOut time.Time, bookedAt time.Time) error
+}
+
+type stayRestrictionService struct {
+	restrictionRepository repository.StayRestrictionRepository
+	hotelRepository       repository.HotelRepository
+}
+
+func NewStayRestrictionService(restrictionRepository repository.StayRestrictionRepository, hotelRepository repository.HotelRepository) StayRestrictionService {
+	return &stayRestrictionService{restrictionRepository: restrictionRepository, hotelRepository: hotelRepository}
+}
+
+/*
+SetRestrictions
+Params: hotel id, SetStayRestrictionsRequest
+Returns: stored restrictions, error
+Description: Write one restriction row per date in the range, replacing any rows already set for the same scope
+*/
+func (s *stayRestrictionService) SetRestrictions(hotelId string, request *domain.SetStayRestrictionsRequest) ([]domain.StayRestriction, error) {
+	from := truncateToDay(request.From)
+	to := truncateToDay(request.To)
+	if err := validateRestrictionRange(from, to); err != nil {
+		return nil, err
+	}
+	if request.MaxStay > 0 && request.MinStay > request.MaxStay {
+		return nil, errors.New("min stay cannot be greater than max stay")
+	}
+	if request.MaxAdvanceDays > 0 && request.MinAdvanceDays > request.MaxAdvanceDays {
+		return nil, errors.New("min advance days cannot be greater than max advance days")
+	}
+
+	hotel, err := s.hotelRepository.GetHotelById(hotelId)
+	if err != nil {
+		return nil, err
+	}
+	if request.RoomId != nil && !hotelHasRoom(hotel, *request.RoomId) {
+		return nil, errors.New("room not found")
+	}
+
+	var restrictions []domain.StayRestriction
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		restrictions = append(restrictions, domain.StayRestriction{
+			Id:                uuid.New(),
+			HotelId:           hotel.Id,
+			RoomId:            request.RoomId,
+			Date:              date,
+			MinStay:           request.MinStay,
+			MaxStay:           request.MaxStay,
+			ClosedToArrival:   request.ClosedToArrival,
+			ClosedToDeparture: request.ClosedToDeparture,
+			MinAdvanceDays:    request.MinAdvanceDays,
+			MaxAdvanceDays:    request.MaxAdvanceDays,
+		})
+	}
+
+	if err := s.restrictionRepository.ReplaceRestrictions(hotel.Id.String(), request.RoomId, from, to, restrictions); err != nil {
+		return nil, err
+	}
+	return restrictions, nil
+}
+
+func (s *stayRestrictionService) ClearRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) error {
+	from = truncateToDay(from)
+	to = truncateToDay(to)
+	if err := validateRestrictionRange(from, to); err != nil {
+		return err
+	}
+	return s.restrictionRepository.DeleteRestrictions(hotelId, roomId, from, to)
+}
+
+func (s *stayRestrictionService) GetRestrictions(hotelId string, roomId *uuid.UUID, from time.Time, to time.Time) ([]domain.StayRestriction, error) {
+	return s.restrictionRepository.GetRestrictions(hotelId, roomId, truncateToDay(from), truncateToDay(to))
+}
+
+/*
+ValidateStay
+Params: hotel id, room id, check in, check out, time the booking is made
+Returns: error wrapping ErrStayRestricted when a rule is broken
+Description: Length of stay, closed-to-arrival and advance window rules are read from the arrival date,
+closed-to-departure from the departure date. Room rules override hotel-wide rules for the same date.
+*/
+func (s *stayRestrictionService) ValidateStay(hotelId uuid.UUID, roomId uuid.UUID, checkIn time.Time, checkOut time.Time, bookedAt time.Time) error {
+	arrival := truncateToDay(checkIn)
+	departure := truncateToDay(checkOut)
+
+	rows, err := s.restrictionRepository.GetRestrictions(hotelId.String(), &roomId, arrival, departure)
+	if err != nil {
+		return err
+	}
+	return checkStay(effectiveRestrictions(rows), arrival, departure, bookedAt)
+}
+
+// checkStay applies the rules in effect for one room to a stay between two dates produced by truncateToDay
+func checkStay(byDate map[time.Time]domain.StayRestriction, arrival time.Time, departure time.Time, bookedAt time.Time) error {
+	if rule, ok := byDate[arrival]; ok {
+		nights := daysBetween(arrival, departure)
+		leadDays := daysBetween(truncateToDay(bookedAt), arrival)
+		arrivalLabel := arrival.Format(dateLayout)
+
+		if rule.ClosedToArrival {
+			return fmt.Errorf("%w: arrivals are closed on %s", ErrStayRestricted, arrivalLabel)
+		}
+		if rule.MinStay > 0 && nights < rule.MinStay {
+			return fmt.Errorf("%w: minimum stay for arrival on %s is %d nights", ErrStayRestricted, arrivalLabel, rule.MinStay)
+		}
+		if rule.MaxStay > 0 && nights > rule.MaxStay {
+			return fmt.Errorf("%w: maximum stay for arrival on %s is %d nights", ErrStayRestricted, arrivalLabel, rule.MaxStay)
+		}
+		if rule.MinAdvanceDays > 0 && leadDays < rule.MinAdvanceDays {
+			return fmt.Errorf("%w: arrival on %s must be booked at least %d days in advance", ErrStayRestricted, arrivalLabel, rule.MinAdvanceDays)
+		}
+		if rule.MaxAdvanceDays > 0 && leadDays > rule.MaxAdvanceDays {
+			return fmt.Errorf("%w: arrival on %s cannot be booked more than %d days in advance", ErrStayRestricted, arrivalLabel, rule.MaxAdvanceDays)
+		}
+	}
+
+	if rule, ok := byDate[departure]; ok && rule.ClosedToDeparture {
+		return fmt.Errorf("%w: departures are closed on %s", ErrStayRestricted, departure.Format(dateLayout))
+	}
+	return nil
+}
+
+// effectiveRestrictions keys rows by date, letting a room row replace the hotel-wide row
+func effectiveRestrictions(rows []domain.StayRestriction) map[time.Time]domain.StayRestriction {
+	byDate := make(map[time.Time]domain.StayRestriction, len(rows))
+	for _, row := range rows {
+		date := truncateToDay(row.Date.UTC())
+		if existing, ok := byDate[date]; ok && existing.RoomId != nil && row.RoomId == nil {
+			continue
+		}
+		byDate[date] = row
+	}
+	return byDate
+}
+
+func validateRestrictionRange(from time.Time, to time.Time) error {
+	if to.Before(from) {
+		return errors.New("to date must not be before from date")
+	}
+	if daysBetween(from, to) >= maxRestrictionRangeDays {
+		return fmt.Errorf("date range cannot exceed %d days", maxRestrictionRangeDays)
+	}
+	return nil
+}
+
+func hotelHasRoom(hotel *domain.Hotel, roomId uuid.UUID) bool {
+	for _, room := range hotel.Rooms {
+		if room.Id == roomId {
+			return true
+		}
+	}
+	return false
+}
+
+// truncateToDay keeps the calendar date of t and drops the time of day
+func truncateToDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// daysBetween counts calendar days between two dates produced by truncateToDay
+func daysBetween(from time.Time, to time.Time) int {
+	return int(to.Sub(from).Hours() / 24)
+}
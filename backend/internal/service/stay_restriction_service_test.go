@@ -0,0 +1,148 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupStayRestrictionServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupBookingServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE stay_restrictions (
+			id TEXT PRIMARY KEY,
+			hotel_id TEXT,
+			room_id TEXT,
+			date DATETIME,
+			min_stay INTEGER DEFAULT 0,
+			max_stay INTEGER DEFAULT 0,
+			closed_to_arrival INTEGER DEFAULT 0,
+			closed_to_departure INTEGER DEFAULT 0,
+			min_advance_days INTEGER DEFAULT 0,
+			max_advance_days INTEGER DEFAULT 0
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	return db
+}
+
+func TestStayRestrictionService_ValidateStay(t *testing.T) {
+	db := setupStayRestrictionServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	service := NewStayRestrictionService(repository.NewStayRestrictionRepository(db), repository.NewHotelRepository(db))
+
+	day := func(d int) time.Time { return time.Date(2030, 3, d, 0, 0, 0, 0, time.UTC) }
+	bookedAt := time.Date(2030, 1, 1, 9, 0, 0, 0, time.UTC)
+
+	// Hotel-wide: 2 night minimum across the first half of March, max 7 nights
+	_, err := service.SetRestrictions(hotelId.String(), &domain.SetStayRestrictionsRequest{
+		From: day(1), To: day(15), MinStay: 2, MaxStay: 7,
+	})
+	assert.NoError(t, err)
+	// The room itself is closed to arrival on the 5th
+	_, err = service.SetRestrictions(hotelId.String(), &domain.SetStayRestrictionsRequest{
+		RoomId: &roomId, From: day(5), To: day(5), ClosedToArrival: true,
+	})
+	assert.NoError(t, err)
+	// Closed to departure on the 12th and bookable at most 30 days ahead on the 20th
+	_, err = service.SetRestrictions(hotelId.String(), &domain.SetStayRestrictionsRequest{
+		From: day(12), To: day(12), MinStay: 2, MaxStay: 7, ClosedToDeparture: true,
+	})
+	assert.NoError(t, err)
+	_, err = service.SetRestrictions(hotelId.String(), &domain.SetStayRestrictionsRequest{
+		From: day(20), To: day(20), MaxAdvanceDays: 30,
+	})
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		checkIn     time.Time
+		checkOut    time.Time
+		shouldError bool
+	}{
+		{name: "meets minimum stay", checkIn: day(2), checkOut: day(4), shouldError: false},
+		{name: "below minimum stay", checkIn: day(2), checkOut: day(3), shouldError: true},
+		{name: "above maximum stay", checkIn: day(2), checkOut: day(10), shouldError: true},
+		{name: "room closed to arrival", checkIn: day(5), checkOut: day(8), shouldError: true},
+		{name: "closed to departure", checkIn: day(9), checkOut: day(12), shouldError: true},
+		{name: "booked too far ahead", checkIn: day(20), checkOut: day(22), shouldError: true},
+		{name: "no restriction set", checkIn: day(25), checkOut: day(26), shouldError: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := service.ValidateStay(hotelId, roomId, tt.checkIn, tt.checkOut, bookedAt)
+			if tt.shouldError {
+				assert.ErrorIs(t, err, ErrStayRestricted)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestStayRestrictionService_SetRestrictionsValidation(t *testing.T) {
+	db := setupStayRestrictionServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100.0))
+	service := NewStayRestrictionService(repository.NewStayRestrictionRepository(db), repository.NewHotelRepository(db))
+
+	from := time.Date(2030, 3, 1, 0, 0, 0, 0, time.UTC)
+	unknownRoom := uuid.New()
+
+	tests := []struct {
+		name    string
+		request *domain.SetStayRestrictionsRequest
+	}{
+		{name: "to before from", request: &domain.SetStayRestrictionsRequest{From: from, To: from.AddDate(0, 0, -1)}},
+		{name: "range too long", request: &domain.SetStayRestrictionsRequest{From: from, To: from.AddDate(2, 0, 0)}},
+		{name: "min stay above max stay", request: &domain.SetStayRestrictionsRequest{From: from, To: from, MinStay: 5, MaxStay: 2}},
+		{name: "room from another hotel", request: &domain.SetStayRestrictionsRequest{RoomId: &unknownRoom, From: from, To: from}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := service.SetRestrictions(hotelId.String(), tt.request)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestBookingService_CreateBookingRespectsStayRestrictions(t *testing.T) {
+	db := setupStayRestrictionServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+
+	hotelRepo := repository.NewHotelRepository(db)
+	restrictions := NewStayRestrictionService(repository.NewStayRestrictionRepository(db), hotelRepo)
+	bookings := NewBookingService(hotelRepo, repository.NewBookingRepository(db), nil, restrictions, nil, nil)
+
+	checkIn := time.Now().AddDate(0, 0, 10)
+	_, err := restrictions.SetRestrictions(hotelId.String(), &domain.SetStayRestrictionsRequest{
+		From: checkIn, To: checkIn, MinStay: 3,
+	})
+	assert.NoError(t, err)
+
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 1),
+	})
+	assert.ErrorIs(t, err, ErrStayRestricted)
+	assert.Contains(t, err.Error(), "minimum stay")
+
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 3),
+	})
+	assert.NoError(t, err)
+}
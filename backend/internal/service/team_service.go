@@ -0,0 +1,265 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var (
+	ErrInvitationNotFound    = errors.New("invitation not found")
+	ErrInvitationExpired     = errors.New("invitation has expired")
+	ErrInvitationClosed      = errors.New("invitation has already been used or revoked")
+	ErrAccountDetailsMissing = errors.New("username and password are required to create an account")
+	ErrEmailAlreadyOnTeam    = errors.New("this email address already has the role at this hotel")
+)
+
+type TeamService interface {
+	InviteStaff(actor *domain.User, hotelId string, request *domain.InvitationRequest) (*domain.StaffInvitation, error)
+	GetInvitations(hotelId string) ([]domain.StaffInvitation, error)
+	RevokeInvitation(actor *domain.User, hotelId string, invitationId string) (*domain.StaffInvitation, error)
+	AcceptInvitation(request *domain.AcceptInvitationRequest) (*domain.StaffInvitation, error)
+	GetTeam(hotelId string) ([]domain.TeamMember, error)
+}
+
+type teamService struct {
+	invitationRepository repository.InvitationRepository
+	roleRepository       repository.RoleRepository
+	userRepository       repository.UserRepository
+	hotelRepository      repository.HotelRepository
+	roleService          RoleService
+	audit                AuditService
+	mailer               mail.Mailer
+	appURL               string
+	invitationTTL        time.Duration
+}
+
+func NewTeamService(invitationRepository repository.InvitationRepository, roleRepository repository.RoleRepository, userRepository repository.UserRepository, hotelRepository repository.HotelRepository, roleService RoleService, audit AuditService, mailer mail.Mailer, appURL string, invitationTTL time.Duration) TeamService {
+	return &teamService{
+		invitationRepository: invitationRepository,
+		roleRepository:       roleRepository,
+		userRepository:       userRepository,
+		hotelRepository:      hotelRepository,
+		roleService:          roleService,
+		audit:                audit,
+		mailer:               mailer,
+		appURL:               appURL,
+		invitationTTL:        invitationTTL,
+	}
+}
+
+/*
+InviteStaff
+Params: acting user, hotel id, InvitationRequest
+Returns: the invitation, error
+Description: Email an invitation to a staff role at the hotel. The actor must be allowed to grant the role
+there, as for RoleService.GrantRole. Inviting the same address again revokes its earlier open invitations,
+so only the newest link works. Nothing is saved when the email cannot be sent. The token is only ever sent to the invited address, because holding it
+proves control of that address.
+*/
+func (s *teamService) InviteStaff(actor *domain.User, hotelId string, request *domain.InvitationRequest) (*domain.StaffInvitation, error) {
+	parsedHotelId, err := uuid.Parse(hotelId)
+	if err != nil {
+		return nil, ErrHotelNotFound
+	}
+	grant := &domain.RoleGrantRequest{Role: request.Role, HotelId: &parsedHotelId}
+	if request.Role == domain.RoleSuperAdmin {
+		return nil, fmt.Errorf("%w: super_admin cannot be granted by invitation", ErrInvalidRole)
+	}
+	if err := s.roleService.CheckRoleChange(actor, grant); err != nil {
+		return nil, err
+	}
+
+	email := normalizeEmail(request.Email)
+	if user, err := s.userRepository.GetUserByEmail(email); err == nil {
+		if _, err := s.roleRepository.FindAssignment(user.Id.String(), request.Role, &parsedHotelId, nil); err == nil {
+			return nil, ErrEmailAlreadyOnTeam
+		}
+	}
+
+	hotel, err := s.hotelRepository.GetHotelById(hotelId)
+	if err != nil {
+		return nil, ErrHotelNotFound
+	}
+	token, err := generateAccountToken()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	invitation := &domain.StaffInvitation{
+		Id:        uuid.New(),
+		Email:     email,
+		Role:      request.Role,
+		HotelId:   parsedHotelId,
+		TokenHash: hashToken(token),
+		InvitedBy: actor.Id,
+		ExpiresAt: now.Add(s.invitationTTL),
+	}
+	link := strings.TrimSuffix(s.appURL, "/") + "/invitations/accept?token=" + url.QueryEscape(token)
+	err = s.invitationRepository.ReplaceOpenInvitations(invitation, now, func() error {
+		return s.mailer.Send(&mail.Message{
+			To:      email,
+			Subject: fmt.Sprintf("You're invited to join %s", hotel.Name),
+			Body: fmt.Sprintf("You have been invited to join the team at %s as %s.\n\nAccept the invitation before %s:\n\n%s",
+				hotel.Name, strings.ReplaceAll(string(request.Role), "_", " "), invitation.ExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"), link),
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(&actor.Id, AuditActionInvitationSent, AuditEntityInvitation, invitation.Id, fmt.Sprintf("%s invited as %s", email, request.Role)); err != nil {
+		return nil, err
+	}
+
+	invitation.Status = invitationStatus(invitation, now)
+	return invitation, nil
+}
+
+// GetInvitations lists every invitation sent for the hotel, newest first
+func (s *teamService) GetInvitations(hotelId string) ([]domain.StaffInvitation, error) {
+	if _, err := s.hotelRepository.GetHotelById(hotelId); err != nil {
+		return nil, ErrHotelNotFound
+	}
+	invitations, err := s.invitationRepository.GetInvitationsByHotelId(hotelId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	for i := range invitations {
+		invitations[i].Status = invitationStatus(&invitations[i], now)
+	}
+	return invitations, nil
+}
+
+func (s *teamService) RevokeInvitation(actor *domain.User, hotelId string, invitationId string) (*domain.StaffInvitation, error) {
+	invitation, err := s.invitationRepository.GetInvitationById(invitationId)
+	if err != nil || invitation.HotelId.String() != hotelId {
+		return nil, ErrInvitationNotFound
+	}
+	if invitation.AcceptedAt != nil || invitation.RevokedAt != nil {
+		return nil, ErrInvitationClosed
+	}
+
+	now := time.Now()
+	if err := s.invitationRepository.RevokeInvitation(invitationId, now); err != nil {
+		return nil, err
+	}
+	if err := s.audit.Record(&actor.Id, AuditActionInvitationRevoked, AuditEntityInvitation, invitation.Id, invitation.Email); err != nil {
+		return nil, err
+	}
+	invitation.RevokedAt = &now
+	invitation.Status = domain.InvitationRevoked
+	return invitation, nil
+}
+
+/*
+AcceptInvitation
+Params: AcceptInvitationRequest
+Returns: the accepted invitation, error
+Description: Holding the token proves control of the invited address. When an account already uses the
+address the role is added to it; otherwise an account is created with the given username and password.
+*/
+func (s *teamService) AcceptInvitation(request *domain.AcceptInvitationRequest) (*domain.StaffInvitation, error) {
+	invitation, err := s.invitationRepository.GetInvitationByTokenHash(hashToken(request.Token))
+	if err != nil {
+		return nil, ErrInvitationNotFound
+	}
+	now := time.Now()
+	switch invitationStatus(invitation, now) {
+	case domain.InvitationExpired:
+		return nil, ErrInvitationExpired
+	case domain.InvitationAccepted, domain.InvitationRevoked:
+		return nil, ErrInvitationClosed
+	}
+
+	var newUser *domain.User
+	user, err := s.userRepository.GetUserByEmail(invitation.Email)
+	if err != nil {
+		username := strings.TrimSpace(request.Username)
+		if username == "" || request.Password == "" {
+			return nil, ErrAccountDetailsMissing
+		}
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(request.Password), bcrypt.DefaultCost)
+		if err != nil {
+			return nil, err
+		}
+		// The invitation link was emailed, so following it proves the address
+		newUser = &domain.User{
+			Id:              uuid.New(),
+			Username:        username,
+			Email:           invitation.Email,
+			Password:        string(hashedPassword),
+			EmailVerifiedAt: &now,
+			CreatedAt:       now,
+			UpdatedAt:       now,
+		}
+		user = newUser
+	}
+
+	var assignment *domain.RoleAssignment
+	if _, err := s.roleRepository.FindAssignment(user.Id.String(), invitation.Role, &invitation.HotelId, nil); err != nil {
+		assignment = &domain.RoleAssignment{
+			Id:        uuid.New(),
+			UserId:    user.Id,
+			Role:      invitation.Role,
+			HotelId:   &invitation.HotelId,
+			GrantedBy: &invitation.InvitedBy,
+		}
+	}
+
+	invitation.AcceptedAt = &now
+	invitation.AcceptedBy = &user.Id
+	accepted, err := s.invitationRepository.AcceptInvitation(invitation, newUser, assignment)
+	if err != nil {
+		return nil, err
+	}
+	if !accepted {
+		return nil, ErrInvitationClosed
+	}
+
+	if err := s.audit.Record(&user.Id, AuditActionInvitationAccepted, AuditEntityInvitation, invitation.Id, invitation.Email); err != nil {
+		return nil, err
+	}
+	if assignment != nil {
+		details := describeRoleGrant(&domain.RoleGrantRequest{Role: invitation.Role, HotelId: &invitation.HotelId}) + " by invitation"
+		if err := s.audit.Record(&invitation.InvitedBy, AuditActionRoleGranted, AuditEntityUser, user.Id, details); err != nil {
+			return nil, err
+		}
+	}
+
+	invitation.Status = domain.InvitationAccepted
+	return invitation, nil
+}
+
+// GetTeam lists the hotel's staff, including organization-wide roles covering it
+func (s *teamService) GetTeam(hotelId string) ([]domain.TeamMember, error) {
+	if _, err := s.hotelRepository.GetHotelById(hotelId); err != nil {
+		return nil, ErrHotelNotFound
+	}
+	return s.roleRepository.GetHotelTeam(hotelId)
+}
+
+func invitationStatus(invitation *domain.StaffInvitation, now time.Time) domain.InvitationStatus {
+	switch {
+	case invitation.AcceptedAt != nil:
+		return domain.InvitationAccepted
+	case invitation.RevokedAt != nil:
+		return domain.InvitationRevoked
+	case !now.Before(invitation.ExpiresAt):
+		return domain.InvitationExpired
+	default:
+		return domain.InvitationPending
+	}
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
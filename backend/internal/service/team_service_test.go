@@ -0,0 +1,221 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupTeamServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupRoleServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE staff_invitations (
+			id TEXT PRIMARY KEY,
+			email TEXT,
+			role TEXT,
+			hotel_id TEXT,
+			token_hash TEXT UNIQUE,
+			invited_by TEXT,
+			expires_at DATETIME,
+			accepted_at DATETIME,
+			accepted_by TEXT,
+			revoked_at DATETIME,
+			created_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create tables: %v", err)
+	}
+
+	return db
+}
+
+func newTeamTestService(db *gorm.DB, mailer *recordingMailer, ttl time.Duration) TeamService {
+	return NewTeamService(
+		repository.NewInvitationRepository(db),
+		repository.NewRoleRepository(db),
+		repository.NewUserRepository(db),
+		repository.NewHotelRepository(db),
+		newRoleTestService(db),
+		NewAuditService(repository.NewAuditRepository(db)),
+		mailer,
+		"http://app.test",
+		ttl,
+	)
+}
+
+func TestTeamService_InviteAndAcceptCreatesAccount(t *testing.T) {
+	db := setupTeamServiceTestDB(t)
+	mailer := &recordingMailer{}
+	team := newTeamTestService(db, mailer, time.Hour)
+	roles := newRoleTestService(db)
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	owner := createRoleTestUser(t, db, "owner", false)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+	_, err := roles.GrantRole(admin, owner.Id.String(), &domain.RoleGrantRequest{Role: domain.RoleHotelOwner, HotelId: &hotelId})
+	assert.NoError(t, err)
+
+	invitation, err := team.InviteStaff(owner, hotelId.String(), &domain.InvitationRequest{Email: " Desk@Example.com ", Role: domain.RoleFrontDesk})
+	assert.NoError(t, err)
+	assert.Equal(t, "desk@example.com", invitation.Email)
+	assert.Equal(t, domain.InvitationPending, invitation.Status)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	assert.Equal(t, "desk@example.com", mailer.messages[0].To)
+	token := tokenFromLink(t, mailer.messages[0], "/invitations/accept")
+	assert.NotEqual(t, token, invitation.TokenHash)
+
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: token})
+	assert.ErrorIs(t, err, ErrAccountDetailsMissing)
+
+	accepted, err := team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: token, Username: "desk", Password: "password123"})
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InvitationAccepted, accepted.Status)
+
+	user, err := repository.NewUserRepository(db).GetUserByEmail("desk@example.com")
+	assert.NoError(t, err)
+	assert.NotEqual(t, "password123", user.Password)
+	assert.True(t, user.IsEmailVerified())
+	allowed, err := roles.HasPermission(user, domain.PermissionBookingsCheckIn, &hotelId)
+	assert.NoError(t, err)
+	assert.True(t, allowed)
+
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: token, Username: "desk", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvitationClosed)
+
+	members, err := team.GetTeam(hotelId.String())
+	assert.NoError(t, err)
+	if assert.Len(t, members, 2) {
+		assert.Equal(t, "owner", members[0].Username)
+		assert.Equal(t, domain.RoleFrontDesk, members[1].Role)
+		assert.Equal(t, "desk@example.com", members[1].Email)
+	}
+
+	_, err = team.InviteStaff(owner, hotelId.String(), &domain.InvitationRequest{Email: "desk@example.com", Role: domain.RoleFrontDesk})
+	assert.ErrorIs(t, err, ErrEmailAlreadyOnTeam)
+}
+
+func TestTeamService_AcceptLinksExistingAccount(t *testing.T) {
+	db := setupTeamServiceTestDB(t)
+	mailer := &recordingMailer{}
+	team := newTeamTestService(db, mailer, time.Hour)
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	existing := createRoleTestUser(t, db, "manager", false)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+
+	_, err := team.InviteStaff(admin, hotelId.String(), &domain.InvitationRequest{Email: existing.Email, Role: domain.RoleHotelManager})
+	assert.NoError(t, err)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+
+	accepted, err := team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: tokenFromLink(t, mailer.messages[0], "/invitations/accept")})
+	assert.NoError(t, err)
+	assert.Equal(t, &existing.Id, accepted.AcceptedBy)
+
+	users, err := repository.NewUserRepository(db).GetAllUsers()
+	assert.NoError(t, err)
+	assert.Len(t, users, 2)
+	members, err := team.GetTeam(hotelId.String())
+	assert.NoError(t, err)
+	if assert.Len(t, members, 1) {
+		assert.Equal(t, existing.Id, members[0].UserId)
+		assert.Equal(t, domain.RoleHotelManager, members[0].Role)
+	}
+}
+
+func TestTeamService_UnsentInvitationKeepsEarlierLink(t *testing.T) {
+	db := setupTeamServiceTestDB(t)
+	mailer := &recordingMailer{}
+	team := newTeamTestService(db, mailer, time.Hour)
+
+	admin := createRoleTestUser(t, db, "admin", true)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+	request := &domain.InvitationRequest{Email: "desk@example.com", Role: domain.RoleFrontDesk}
+
+	_, err := team.InviteStaff(admin, hotelId.String(), request)
+	assert.NoError(t, err)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+
+	mailer.err = errors.New("smtp unavailable")
+	_, err = team.InviteStaff(admin, hotelId.String(), request)
+	assert.Error(t, err)
+
+	invitations, err := team.GetInvitations(hotelId.String())
+	assert.NoError(t, err)
+	if assert.Len(t, invitations, 1) {
+		assert.Equal(t, domain.InvitationPending, invitations[0].Status)
+	}
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{
+		Token: tokenFromLink(t, mailer.messages[0], "/invitations/accept"), Username: "desk", Password: "password123",
+	})
+	assert.NoError(t, err)
+}
+
+func TestTeamService_ExpiryRevocationAndPermissions(t *testing.T) {
+	db := setupTeamServiceTestDB(t)
+	hotelId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, uuid.New(), 100))
+	admin := createRoleTestUser(t, db, "admin", true)
+	stranger := createRoleTestUser(t, db, "stranger", false)
+
+	mailer := &recordingMailer{}
+	expired, err := newTeamTestService(db, mailer, -time.Minute).InviteStaff(admin, hotelId.String(), &domain.InvitationRequest{Email: "late@example.com", Role: domain.RoleFrontDesk})
+	assert.NoError(t, err)
+	team := newTeamTestService(db, mailer, time.Hour)
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: tokenFromLink(t, mailer.messages[0], "/invitations/accept"), Username: "late", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvitationExpired)
+
+	// Inviting the same address again leaves only the newest token usable
+	first, err := team.InviteStaff(admin, hotelId.String(), &domain.InvitationRequest{Email: "new@example.com", Role: domain.RoleFrontDesk})
+	assert.NoError(t, err)
+	second, err := team.InviteStaff(admin, hotelId.String(), &domain.InvitationRequest{Email: "new@example.com", Role: domain.RoleHotelManager})
+	assert.NoError(t, err)
+	if !assert.Len(t, mailer.messages, 3) {
+		return
+	}
+	firstToken, secondToken := tokenFromLink(t, mailer.messages[1], "/invitations/accept"), tokenFromLink(t, mailer.messages[2], "/invitations/accept")
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: firstToken, Username: "new", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvitationClosed)
+
+	revoked, err := team.RevokeInvitation(admin, hotelId.String(), second.Id.String())
+	assert.NoError(t, err)
+	assert.Equal(t, domain.InvitationRevoked, revoked.Status)
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: secondToken, Username: "new", Password: "password123"})
+	assert.ErrorIs(t, err, ErrInvitationClosed)
+	_, err = team.RevokeInvitation(admin, uuid.New().String(), second.Id.String())
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+
+	_, err = team.AcceptInvitation(&domain.AcceptInvitationRequest{Token: "not-a-token"})
+	assert.ErrorIs(t, err, ErrInvitationNotFound)
+
+	invitations, err := team.GetInvitations(hotelId.String())
+	assert.NoError(t, err)
+	statuses := map[uuid.UUID]domain.InvitationStatus{}
+	for _, invitation := range invitations {
+		statuses[invitation.Id] = invitation.Status
+	}
+	assert.Equal(t, domain.InvitationExpired, statuses[expired.Id])
+	assert.Equal(t, domain.InvitationRevoked, statuses[first.Id])
+	assert.Equal(t, domain.InvitationRevoked, statuses[second.Id])
+
+	_, err = team.InviteStaff(stranger, hotelId.String(), &domain.InvitationRequest{Email: "x@example.com", Role: domain.RoleFrontDesk})
+	assert.ErrorIs(t, err, ErrPermissionDenied)
+	_, err = team.InviteStaff(admin, hotelId.String(), &domain.InvitationRequest{Email: "x@example.com", Role: domain.RoleSuperAdmin})
+	assert.ErrorIs(t, err, ErrInvalidRole)
+}
@@ -62,7 +62,8 @@ func TestUserService_CreateUser(t *testing.T) {
 					password TEXT,
 					created_at DATETIME,
 					updated_at DATETIME,
-					is_admin INTEGER DEFAULT 0
+					is_admin INTEGER DEFAULT 0,
+					email_verified_at DATETIME
 				)
 			`).Error
 			assert.NoError(t, err)
@@ -103,7 +104,8 @@ func TestUserService_CreateUser_DuplicateEmail(t *testing.T) {
 			password TEXT,
 			created_at DATETIME,
 			updated_at DATETIME,
-			is_admin INTEGER DEFAULT 0
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
 		)
 	`).Error
 	assert.NoError(t, err)
@@ -153,7 +155,8 @@ func TestNewUserService(t *testing.T) {
 			password TEXT,
 			created_at DATETIME,
 			updated_at DATETIME,
-			is_admin INTEGER DEFAULT 0
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
 		)
 	`).Error
 	assert.NoError(t, err)
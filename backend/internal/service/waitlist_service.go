@@ -0,0 +1,277 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/mail"
+	"backend/internal/repository"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrWaitlistEntryNotFound = errors.New("waitlist entry not found")
+	ErrRoomStillAvailable    = errors.New("room is available for the selected dates, book it directly")
+	ErrAlreadyOnWaitlist     = errors.New("already on the waitlist for this room and dates")
+	ErrOfferNotFound         = errors.New("offer not found")
+	ErrOfferExpired          = errors.New("offer has expired")
+	ErrWaitlistEntryClosed   = errors.New("waitlist entry is no longer active")
+)
+
+type WaitlistService interface {
+	InventoryListener
+	JoinWaitlist(userId uuid.UUID, request *domain.JoinWaitlistRequest) (*domain.WaitlistEntry, error)
+	GetEntriesByUserId(userId string) ([]domain.WaitlistEntry, error)
+	LeaveWaitlist(id string, userId uuid.UUID) error
+	ExpireOffers(now time.Time) error
+}
+
+type waitlistService struct {
+	waitlistRepository repository.WaitlistRepository
+	hotelRepository    repository.HotelRepository
+	bookingRepository  repository.BookingRepository
+	userRepository     repository.UserRepository
+	mailer             mail.Mailer
+	appURL             string
+	offerTTL           time.Duration
+}
+
+func NewWaitlistService(waitlistRepository repository.WaitlistRepository, hotelRepository repository.HotelRepository, bookingRepository repository.BookingRepository, userRepository repository.UserRepository, mailer mail.Mailer, appURL string, offerTTL time.Duration) WaitlistService {
+	return &waitlistService{
+		waitlistRepository: waitlistRepository,
+		hotelRepository:    hotelRepository,
+		bookingRepository:  bookingRepository,
+		userRepository:     userRepository,
+		mailer:             mailer,
+		appURL:             appURL,
+		offerTTL:           offerTTL,
+	}
+}
+
+/*
+JoinWaitlist
+Params: user id, JoinWaitlistRequest
+Returns: created entry, error
+Description: Queue the user for a room that is fully booked over the requested dates
+*/
+func (s *waitlistService) JoinWaitlist(userId uuid.UUID, request *domain.JoinWaitlistRequest) (*domain.WaitlistEntry, error) {
+	if !request.CheckOutDate.After(request.CheckInDate) {
+		return nil, errors.New("check out date must be after check in date")
+	}
+
+	hotel, err := s.hotelRepository.GetHotelById(request.HotelId.String())
+	if err != nil {
+		return nil, err
+	}
+	if !hotelHasRoom(hotel, request.RoomId) {
+		return nil, errors.New("room not found")
+	}
+
+	free, err := s.isRoomFree(request.RoomId, request.CheckInDate, request.CheckOutDate)
+	if err != nil {
+		return nil, err
+	}
+	if free {
+		return nil, ErrRoomStillAvailable
+	}
+
+	existing, err := s.waitlistRepository.GetEntriesByUserId(userId.String())
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range existing {
+		if entry.RoomId == request.RoomId &&
+			entry.CheckInDate.Equal(request.CheckInDate) &&
+			entry.CheckOutDate.Equal(request.CheckOutDate) &&
+			(entry.Status == domain.WaitlistStatusWaiting || entry.Status == domain.WaitlistStatusOffered) {
+			return nil, ErrAlreadyOnWaitlist
+		}
+	}
+
+	entry := &domain.WaitlistEntry{
+		Id:           uuid.New(),
+		UserId:       userId,
+		HotelId:      request.HotelId,
+		RoomId:       request.RoomId,
+		CheckInDate:  request.CheckInDate,
+		CheckOutDate: request.CheckOutDate,
+		Status:       domain.WaitlistStatusWaiting,
+	}
+	if err := s.waitlistRepository.CreateEntry(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+func (s *waitlistService) GetEntriesByUserId(userId string) ([]domain.WaitlistEntry, error) {
+	return s.waitlistRepository.GetEntriesByUserId(userId)
+}
+
+/*
+LeaveWaitlist
+Params: entry id, user id
+Returns: error
+Description: Remove the user from the queue. Declining an open offer passes it to the next guest.
+*/
+func (s *waitlistService) LeaveWaitlist(id string, userId uuid.UUID) error {
+	entry, err := s.waitlistRepository.GetEntryById(id)
+	if err != nil || entry.UserId != userId {
+		return ErrWaitlistEntryNotFound
+	}
+	if entry.Status != domain.WaitlistStatusWaiting && entry.Status != domain.WaitlistStatusOffered {
+		return ErrWaitlistEntryClosed
+	}
+
+	wasOffered := entry.Status == domain.WaitlistStatusOffered
+	entry.Status = domain.WaitlistStatusCancelled
+	entry.OfferTokenHash = ""
+	entry.OfferExpiresAt = nil
+	if err := s.waitlistRepository.UpdateEntry(entry); err != nil {
+		return err
+	}
+
+	if wasOffered {
+		return s.offerFreedRoom(entry.RoomId, entry.CheckInDate, entry.CheckOutDate)
+	}
+	return nil
+}
+
+/*
+GetOpenOffer
+Params: offer token, user id
+Returns: entry holding the offer, error
+Description: Check that the offer behind a claim link is still open for this user.
+BookingService.ClaimWaitlistOffer books the room and closes the offer together.
+*/
+func (s *waitlistService) GetOpenOffer(token string, userId uuid.UUID) (*domain.WaitlistEntry, error) {
+	entry, err := s.waitlistRepository.GetEntryByOfferTokenHash(hashToken(token))
+	if err != nil || entry.UserId != userId {
+		return nil, ErrOfferNotFound
+	}
+	if entry.Status != domain.WaitlistStatusOffered {
+		return nil, ErrWaitlistEntryClosed
+	}
+	if entry.OfferExpiresAt == nil || time.Now().After(*entry.OfferExpiresAt) {
+		return nil, ErrOfferExpired
+	}
+	return entry, nil
+}
+
+/*
+ExpireOffers
+Params: current time
+Returns: error
+Description: Close offers whose claim window has passed and offer the room to the next guest in line
+*/
+func (s *waitlistService) ExpireOffers(now time.Time) error {
+	expired, err := s.waitlistRepository.GetExpiredOffers(now)
+	if err != nil {
+		return err
+	}
+
+	for i := range expired {
+		entry := &expired[i]
+		entry.Status = domain.WaitlistStatusExpired
+		entry.OfferTokenHash = ""
+		entry.OfferExpiresAt = nil
+		if err := s.waitlistRepository.UpdateEntry(entry); err != nil {
+			return err
+		}
+		if err := s.offerFreedRoom(entry.RoomId, entry.CheckInDate, entry.CheckOutDate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// InventoryReleased offers a room given back by a cancelled booking to the waitlist
+func (s *waitlistService) InventoryReleased(booking *domain.Booking) {
+	if err := s.offerFreedRoom(booking.RoomId, booking.CheckInDate, booking.CheckOutDate); err != nil {
+		log.Printf("Error offering released room %s to waitlist: %v", booking.RoomId, err)
+	}
+}
+
+// offerFreedRoom walks the room's queue in order and sends an offer to every entry
+// whose stay touches the freed range and is now entirely sellable
+func (s *waitlistService) offerFreedRoom(roomId uuid.UUID, from time.Time, to time.Time) error {
+	queue, err := s.waitlistRepository.GetWaitingEntriesByRoomId(roomId.String())
+	if err != nil {
+		return err
+	}
+
+	for i := range queue {
+		entry := &queue[i]
+		if !entry.CheckInDate.Before(to) || !entry.CheckOutDate.After(from) {
+			continue
+		}
+
+		free, err := s.isRoomFree(entry.RoomId, entry.CheckInDate, entry.CheckOutDate)
+		if err != nil {
+			return err
+		}
+		if !free {
+			continue
+		}
+
+		token, err := generateAccountToken()
+		if err != nil {
+			return err
+		}
+		expiresAt := time.Now().Add(s.offerTTL)
+		entry.Status = domain.WaitlistStatusOffered
+		entry.OfferTokenHash = hashToken(token)
+		entry.OfferExpiresAt = &expiresAt
+		if err := s.waitlistRepository.UpdateEntry(entry); err != nil {
+			return err
+		}
+		// The offer stands even when the email fails and passes to the next guest when it expires
+		if err := s.sendOffer(entry, token); err != nil {
+			log.Printf("Error emailing waitlist offer for entry %s to user %s: %v", entry.Id, entry.UserId, err)
+		}
+	}
+	return nil
+}
+
+// sendOffer emails the guest the link for claiming their offer. Only the hash of the token is stored,
+// so the email is the one place the link is available.
+func (s *waitlistService) sendOffer(entry *domain.WaitlistEntry, token string) error {
+	user, err := s.userRepository.GetUserById(entry.UserId.String())
+	if err != nil {
+		return err
+	}
+	hotel, err := s.hotelRepository.GetHotelById(entry.HotelId.String())
+	if err != nil {
+		return err
+	}
+
+	link := strings.TrimSuffix(s.appURL, "/") + "/waitlist/claim?token=" + url.QueryEscape(token)
+	return s.mailer.Send(&mail.Message{
+		To:      user.Email,
+		Subject: fmt.Sprintf("A room at %s is available", hotel.Name),
+		Body: fmt.Sprintf("The room you are waiting for at %s is free from %s to %s.\n\nIt is held for you until %s. Book it here:\n\n%s",
+			hotel.Name, entry.CheckInDate.Format("2 Jan 2006"), entry.CheckOutDate.Format("2 Jan 2006"),
+			entry.OfferExpiresAt.UTC().Format("2 Jan 2006 15:04 MST"), link),
+	})
+}
+
+// isRoomFree checks bookings and any open offer on the room
+func (s *waitlistService) isRoomFree(roomId uuid.UUID, checkIn time.Time, checkOut time.Time) (bool, error) {
+	taken, err := s.bookingRepository.HasOverlappingBooking(roomId.String(), checkIn, checkOut)
+	if err != nil {
+		return false, err
+	}
+	if taken {
+		return false, nil
+	}
+
+	offers, err := s.waitlistRepository.GetOverlappingOffers(roomId.String(), checkIn, checkOut)
+	if err != nil {
+		return false, err
+	}
+	return len(offers) == 0, nil
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"backend/internal/domain"
+	"backend/internal/repository"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func setupWaitlistServiceTestDB(t *testing.T) *gorm.DB {
+	db := setupBookingServiceTestDB(t)
+
+	err := db.Exec(`
+		CREATE TABLE users (
+			id TEXT PRIMARY KEY,
+			username TEXT UNIQUE,
+			email TEXT UNIQUE,
+			password TEXT,
+			created_at DATETIME,
+			updated_at DATETIME,
+			is_admin INTEGER DEFAULT 0,
+			email_verified_at DATETIME
+		)
+	`).Error
+	if err != nil {
+		t.Fatalf("Failed to create table: %v", err)
+	}
+
+	return db
+}
+
+func newWaitlistTestServices(db *gorm.DB) (WaitlistService, BookingService) {
+	return newWaitlistTestServicesWithMailer(db, &recordingMailer{})
+}
+
+func newWaitlistTestServicesWithMailer(db *gorm.DB, mailer *recordingMailer) (WaitlistService, BookingService) {
+	hotelRepo := repository.NewHotelRepository(db)
+	bookingRepo := repository.NewBookingRepository(db)
+	waitlist := NewWaitlistService(repository.NewWaitlistRepository(db), hotelRepo, bookingRepo, repository.NewUserRepository(db), mailer, "http://app.test", 30*time.Minute)
+	booking := NewBookingService(hotelRepo, bookingRepo, nil, nil, waitlist, nil)
+	return waitlist, booking
+}
+
+func TestWaitlistService_JoinWaitlist(t *testing.T) {
+	db := setupWaitlistServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	waitlist, bookings := newWaitlistTestServices(db)
+
+	checkIn := time.Now().AddDate(0, 0, 10)
+	checkOut := checkIn.AddDate(0, 0, 2)
+	request := &domain.JoinWaitlistRequest{HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut}
+
+	// The room is free, so the guest should book it instead
+	_, err := waitlist.JoinWaitlist(uuid.New(), request)
+	assert.ErrorIs(t, err, ErrRoomStillAvailable)
+
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: uuid.New(), RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut,
+	})
+	assert.NoError(t, err)
+
+	guestId := uuid.New()
+	entry, err := waitlist.JoinWaitlist(guestId, request)
+	assert.NoError(t, err)
+	assert.Equal(t, domain.WaitlistStatusWaiting, entry.Status)
+
+	_, err = waitlist.JoinWaitlist(guestId, request)
+	assert.ErrorIs(t, err, ErrAlreadyOnWaitlist)
+}
+
+func TestWaitlistService_CancellationOffersRoomToFirstInQueue(t *testing.T) {
+	db := setupWaitlistServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	mailer := &recordingMailer{}
+	waitlist, bookings := newWaitlistTestServicesWithMailer(db, mailer)
+	firstGuest := &domain.User{Id: uuid.New(), Username: "first", Email: "first@example.com"}
+	assert.NoError(t, db.Create(firstGuest).Error)
+
+	checkIn := time.Now().AddDate(0, 0, 10)
+	checkOut := checkIn.AddDate(0, 0, 2)
+	owner := &domain.User{Id: uuid.New()}
+	err := bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: owner.Id, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut,
+	})
+	assert.NoError(t, err)
+
+	request := &domain.JoinWaitlistRequest{HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut}
+	first, err := waitlist.JoinWaitlist(firstGuest.Id, request)
+	assert.NoError(t, err)
+	second, err := waitlist.JoinWaitlist(uuid.New(), request)
+	assert.NoError(t, err)
+
+	existing, err := bookings.GetBookingsByUserId(owner.Id.String())
+	assert.NoError(t, err)
+	assert.NoError(t, bookings.CancelBooking(existing[0].Id.String(), owner))
+
+	offered := findWaitlistEntry(t, waitlist, first.UserId, first.Id)
+	assert.Equal(t, domain.WaitlistStatusOffered, offered.Status)
+	assert.NotNil(t, offered.OfferExpiresAt)
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	assert.Equal(t, "first@example.com", mailer.messages[0].To)
+	token := tokenFromLink(t, mailer.messages[0], "/waitlist/claim")
+	// Only the hash of the emailed token is stored
+	assert.Equal(t, hashToken(token), offered.OfferTokenHash)
+	assert.Equal(t, domain.WaitlistStatusWaiting, findWaitlistEntry(t, waitlist, second.UserId, second.Id).Status)
+
+	// The room is held for the first guest while the offer is open
+	err = bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: second.UserId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut,
+	})
+	assert.ErrorIs(t, err, ErrRoomUnavailable)
+
+	_, err = bookings.ClaimWaitlistOffer(token, second.UserId)
+	assert.ErrorIs(t, err, ErrOfferNotFound)
+
+	booking, err := bookings.ClaimWaitlistOffer(token, first.UserId)
+	assert.NoError(t, err)
+	assert.Equal(t, first.UserId, booking.UserId)
+	assert.Equal(t, domain.WaitlistStatusClaimed, findWaitlistEntry(t, waitlist, first.UserId, first.Id).Status)
+
+	_, err = bookings.ClaimWaitlistOffer(token, first.UserId)
+	assert.ErrorIs(t, err, ErrOfferNotFound)
+}
+
+func TestWaitlistService_FailedClaimKeepsOfferOpen(t *testing.T) {
+	db := setupWaitlistServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	mailer := &recordingMailer{}
+	waitlist, bookings := newWaitlistTestServicesWithMailer(db, mailer)
+	guest := &domain.User{Id: uuid.New(), Username: "guest", Email: "guest@example.com"}
+	assert.NoError(t, db.Create(guest).Error)
+
+	checkIn := time.Now().AddDate(0, 0, 10)
+	checkOut := checkIn.AddDate(0, 0, 2)
+	owner := &domain.User{Id: uuid.New()}
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: owner.Id, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut,
+	}))
+	entry, err := waitlist.JoinWaitlist(guest.Id, &domain.JoinWaitlistRequest{HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut})
+	assert.NoError(t, err)
+	existing, err := bookings.GetBookingsByUserId(owner.Id.String())
+	assert.NoError(t, err)
+	assert.NoError(t, bookings.CancelBooking(existing[0].Id.String(), owner))
+	if !assert.Len(t, mailer.messages, 1) {
+		return
+	}
+	token := tokenFromLink(t, mailer.messages[0], "/waitlist/claim")
+
+	// A booking that got in anyway, e.g. made by staff, makes the claim fail without closing the offer
+	assert.NoError(t, db.Create(&domain.Booking{
+		Id: uuid.New(), UserId: uuid.New(), HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut, Status: domain.BookingStatusConfirmed,
+	}).Error)
+	_, err = bookings.ClaimWaitlistOffer(token, entry.UserId)
+	assert.ErrorIs(t, err, ErrRoomUnavailable)
+	assert.Equal(t, domain.WaitlistStatusOffered, findWaitlistEntry(t, waitlist, entry.UserId, entry.Id).Status)
+}
+
+func TestWaitlistService_ExpireOffersFallsThroughQueue(t *testing.T) {
+	db := setupWaitlistServiceTestDB(t)
+	hotelId := uuid.New()
+	roomId := uuid.New()
+	assert.NoError(t, createTestHotelAndRoom(db, hotelId, roomId, 100.0))
+	waitlist, bookings := newWaitlistTestServices(db)
+
+	checkIn := time.Now().AddDate(0, 0, 10)
+	checkOut := checkIn.AddDate(0, 0, 2)
+	owner := &domain.User{Id: uuid.New()}
+	assert.NoError(t, bookings.CreateBooking(&domain.CreateBookingRequest{
+		HotelId: hotelId, UserId: owner.Id, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut,
+	}))
+
+	request := &domain.JoinWaitlistRequest{HotelId: hotelId, RoomId: roomId, CheckInDate: checkIn, CheckOutDate: checkOut}
+	first, err := waitlist.JoinWaitlist(uuid.New(), request)
+	assert.NoError(t, err)
+	second, err := waitlist.JoinWaitlist(uuid.New(), request)
+	assert.NoError(t, err)
+
+	existing, err := bookings.GetBookingsByUserId(owner.Id.String())
+	assert.NoError(t, err)
+	assert.NoError(t, bookings.CancelBooking(existing[0].Id.String(), owner))
+
+	assert.NoError(t, waitlist.ExpireOffers(time.Now().Add(time.Hour)))
+
+	assert.Equal(t, domain.WaitlistStatusExpired, findWaitlistEntry(t, waitlist, first.UserId, first.Id).Status)
+	assert.Equal(t, domain.WaitlistStatusOffered, findWaitlistEntry(t, waitlist, second.UserId, second.Id).Status)
+}
+
+func findWaitlistEntry(t *testing.T, waitlist WaitlistService, userId uuid.UUID, id uuid.UUID) domain.WaitlistEntry {
+	entries, err := waitlist.GetEntriesByUserId(userId.String())
+	assert.NoError(t, err)
+	for _, entry := range entries {
+		if entry.Id == id {
+			return entry
+		}
+	}
+	t.Fatalf("waitlist entry %s not found", id)
+	return domain.WaitlistEntry{}
+}
@@ -62,3 +62,8 @@ func NewUnauthorizedResponse(message string, path string) *ErrorResponse {
 func NewForbiddenResponse(message string, path string) *ErrorResponse {
 	return NewErrorResponse(message, path, http.StatusForbidden)
 }
+
+// NewConflictResponse is a convenience function for 409 Conflict
+func NewConflictResponse(message string, path string) *ErrorResponse {
+	return NewErrorResponse(message, path, http.StatusConflict)
+}
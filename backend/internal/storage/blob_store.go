@@ -0,0 +1,12 @@
+package storage
+
+import "errors"
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// BlobStore keeps uploaded files such as photos. Keys are slash-separated paths like "hotels/<id>/photo.jpg".
+type BlobStore interface {
+	Put(key string, data []byte, contentType string) error
+	Delete(key string) error
+	URL(key string) string
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// LocalBlobStore writes blobs under a directory on disk that is served at baseURL
+type LocalBlobStore struct {
+	root    string
+	baseURL string
+}
+
+func NewLocalBlobStore(root string, baseURL string) *LocalBlobStore {
+	return &LocalBlobStore{root: root, baseURL: strings.TrimSuffix(baseURL, "/")}
+}
+
+// Root returns the directory blobs are written to
+func (s *LocalBlobStore) Root() string {
+	return s.root
+}
+
+func (s *LocalBlobStore) Put(key string, data []byte, contentType string) error {
+	target, err := s.pathFor(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so readers never see a partial blob
+	tmp := target + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, target)
+}
+
+func (s *LocalBlobStore) Delete(key string) error {
+	target, err := s.pathFor(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+func (s *LocalBlobStore) URL(key string) string {
+	return s.baseURL + "/" + key
+}
+
+// pathFor maps a key to a file under root, rejecting keys that would escape it
+func (s *LocalBlobStore) pathFor(key string) (string, error) {
+	cleaned := path.Clean("/" + key)
+	if key == "" || cleaned != "/"+key || strings.Contains(key, "\\") {
+		return "", ErrInvalidKey
+	}
+	return filepath.Join(s.root, filepath.FromSlash(cleaned)), nil
+}